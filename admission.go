@@ -0,0 +1,145 @@
+package confucius
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"reflect"
+)
+
+// admissionReview is the subset of the Kubernetes `admission.k8s.io/v1`
+// AdmissionReview object that is needed to validate a ConfigMap.
+type admissionReview struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Request    *admissionRequest  `json:"request,omitempty"`
+	Response   *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID    string          `json:"uid"`
+	Object json.RawMessage `json:"object,omitempty"`
+}
+
+type admissionResponse struct {
+	UID     string           `json:"uid"`
+	Allowed bool             `json:"allowed"`
+	Result  *admissionStatus `json:"status,omitempty"`
+}
+
+type admissionStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Code    int    `json:"code"`
+}
+
+type configMap struct {
+	Data map[string]string `json:"data"`
+}
+
+// AdmissionHandler returns an http.Handler that can be registered as a
+// Kubernetes validating admission webhook. It loads the config files stored
+// in the reviewed ConfigMap into a new value of cfg's type like `Load`,
+// with the same transforms, defaults and validations, rejecting the
+// ConfigMap when loading fails.
+//
+//   http.Handle("/validate", confucius.AdmissionHandler(&Config{}, confucius.File("app.yaml")))
+//
+// The ConfigMap keys that are loaded are the config filename and its
+// profile files (see `File`, `Profiles` and `ProfileLayout`), which are
+// looked up in the ConfigMap instead of the directories set by `Dirs`.
+// Profile files absent from the ConfigMap are skipped, and ConfigMaps that
+// do not contain the config file are allowed. The environment is never
+// consulted.
+func AdmissionHandler(cfg interface{}, options ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isStructPtr(cfg) {
+			http.Error(w, "cfg must be a pointer to a struct", http.StatusInternalServerError)
+			return
+		}
+
+		var review admissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+			http.Error(w, "invalid admission review", http.StatusBadRequest)
+			return
+		}
+
+		response := &admissionResponse{UID: review.Request.UID, Allowed: true}
+		if err := admit(reflect.TypeOf(cfg).Elem(), review.Request.Object, options); err != nil {
+			response.Allowed = false
+			response.Result = &admissionStatus{
+				Status:  "Failure",
+				Message: err.Error(),
+				Reason:  "Invalid",
+				Code:    http.StatusUnprocessableEntity,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(admissionReview{
+			APIVersion: review.APIVersion,
+			Kind:       review.Kind,
+			Response:   response,
+		})
+	})
+}
+
+// admit loads the config files found in the ConfigMap object into a new
+// value of type t with the given options.
+func admit(t reflect.Type, object json.RawMessage, options []Option) error {
+	if len(object) == 0 || string(object) == "null" {
+		return nil
+	}
+
+	var cm configMap
+	if err := json.Unmarshal(object, &cm); err != nil {
+		return err
+	}
+	fsys, err := configMapFS(cm.Data)
+	if err != nil {
+		return err
+	}
+
+	// loading records state, so each review gets its own confucius.
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+	c.useEnv = false
+	c.useEmbedFS = true
+	c.embedFS = fsys
+	c.dirs = nil
+	c.optionalProfiles = true
+
+	err = c.Load(reflect.New(t).Interface())
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
+
+// configMapFS returns a file system that holds the data of a ConfigMap, a
+// file for each key.
+func configMapFS(data map[string]string) (fs.FS, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for key, content := range data {
+		w, err := zw.Create(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+}
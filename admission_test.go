@@ -0,0 +1,124 @@
+package confucius
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_AdmissionHandler(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host" validate:"required"`
+		Port int    `conf:"port" default:"8080"`
+	}
+
+	handler := AdmissionHandler(&Config{}, File("app.yaml"))
+
+	review := func(t *testing.T, object string) admissionResponse {
+		body := `{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview","request":{"uid":"42","object":` + object + `}}`
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("unexpected status code %d", rec.Code)
+		}
+
+		var got admissionReview
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if got.Kind != "AdmissionReview" || got.Response == nil || got.Response.UID != "42" {
+			t.Fatalf("unexpected review: %+v", got)
+		}
+		return *got.Response
+	}
+
+	t.Run("valid config map", func(t *testing.T) {
+		resp := review(t, `{"data":{"app.yaml":"host: example.com"}}`)
+		if !resp.Allowed {
+			t.Errorf("expected config map to be allowed: %+v", resp.Result)
+		}
+	})
+
+	t.Run("invalid config map", func(t *testing.T) {
+		resp := review(t, `{"data":{"app.yaml":"port: 9000"}}`)
+		if resp.Allowed {
+			t.Fatalf("expected config map to be rejected")
+		}
+		if resp.Result == nil || !strings.Contains(resp.Result.Message, "host") {
+			t.Errorf("unexpected status: %+v", resp.Result)
+		}
+	})
+
+	t.Run("undecodable config map", func(t *testing.T) {
+		resp := review(t, `{"data":{"app.yaml":"host: [example.com"}}`)
+		if resp.Allowed {
+			t.Fatalf("expected config map to be rejected")
+		}
+	})
+
+	t.Run("unrelated config map", func(t *testing.T) {
+		resp := review(t, `{"data":{"other.yaml":"port: 9000"}}`)
+		if !resp.Allowed {
+			t.Errorf("expected config map to be allowed: %+v", resp.Result)
+		}
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader("{")))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+type admissionTimeouts struct {
+	Timeout time.Duration `conf:"timeout,unit=s" default:"10s"`
+}
+
+func (a *admissionTimeouts) Validate() error {
+	if a.Timeout > time.Minute {
+		return fmt.Errorf("timeout %s is longer than a minute", a.Timeout)
+	}
+	return nil
+}
+
+func Test_AdmissionHandler_Load(t *testing.T) {
+	handler := AdmissionHandler(&admissionTimeouts{}, File("app.yaml"), Profiles("prod"))
+
+	for object, allowed := range map[string]bool{
+		`{"data":{"app.yaml":"timeout: 30"}}`:                               true,
+		`{"data":{"app.yaml":"timeout: 120"}}`:                              false,
+		`{"data":{"app.yaml":"timeout: 30","app.prod.yaml":"timeout: 90"}}`: false,
+		`{"data":{"app.prod.yaml":"timeout: 90"}}`:                          true,
+	} {
+		object, allowed := object, allowed
+		t.Run(object, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					body := `{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview","request":{"uid":"42","object":` + object + `}}`
+					rec := httptest.NewRecorder()
+					handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body)))
+
+					var got admissionReview
+					if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || got.Response == nil {
+						t.Errorf("unexpected review: %s", rec.Body)
+						return
+					}
+					if got.Response.Allowed != allowed {
+						t.Errorf("allowed == %t, expected %t: %+v", got.Response.Allowed, allowed, got.Response.Result)
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Package age decrypts the inline values of confucius configs that are
+// encrypted with age (https://age-encryption.org):
+//
+//   password: ENC[age:YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOS...]
+//
+//   err := confucius.Load(&cfg, confage.WithIdentityFile("/etc/myapp/key.txt"))
+//
+// The data of a value is an age file in the binary format, encoded with
+// standard base64. `Encrypt` produces such values.
+package age
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"filippo.io/age"
+	"github.com/hasanozgan/confucius"
+)
+
+// Scheme is the scheme of the values encrypted with age.
+const Scheme = "age"
+
+// WithIdentities returns an option that decrypts the values in the form
+// ENC[age:...] with the given identities.
+func WithIdentities(identities ...age.Identity) confucius.Option {
+	return confucius.WithDecrypter(Scheme, func(data string) (string, error) {
+		return decrypt(data, identities)
+	})
+}
+
+// WithIdentityFile returns an option that decrypts the values in the form
+// ENC[age:...] with the identities of the file at path, as written by
+// age-keygen. The file is read once, when the first value is decrypted,
+// and its identities, or the error reading it, are used for every value
+// decrypted with the option. An error reading the file fails the load.
+func WithIdentityFile(path string) confucius.Option {
+	var (
+		once       sync.Once
+		identities []age.Identity
+		err        error
+	)
+	return confucius.WithDecrypter(Scheme, func(data string) (string, error) {
+		once.Do(func() {
+			identities, err = readIdentities(path)
+		})
+		if err != nil {
+			return "", err
+		}
+		return decrypt(data, identities)
+	})
+}
+
+// readIdentities parses the identities of the file at path.
+func readIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return identities, nil
+}
+
+// Encrypt encrypts plaintext to the given recipients and returns it as an
+// inline encrypted value, e.g. ENC[age:...].
+func Encrypt(plaintext string, recipients ...age.Recipient) (string, error) {
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return "ENC[" + Scheme + ":" + base64.StdEncoding.EncodeToString(buf.Bytes()) + "]", nil
+}
+
+// decrypt decrypts the base64 encoded age file data with identities.
+func decrypt(data string, identities []age.Identity) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return "", err
+	}
+	r, err := age.Decrypt(bytes.NewReader(b), identities...)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,82 @@
+package age
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/hasanozgan/confucius"
+)
+
+func Test_WithIdentities(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	enc, err := Encrypt("hunter2", identity.Recipient())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !strings.HasPrefix(enc, "ENC[age:") {
+		t.Fatalf("Encrypt() == %s, expected an ENC[age:...] value", enc)
+	}
+
+	type Config struct {
+		User     string `conf:"user"`
+		Password string `conf:"password"`
+	}
+	content := "user: admin\npassword: " + enc + "\n"
+
+	keyFile := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for name, opt := range map[string]confucius.Option{
+		"identities":    WithIdentities(other, identity),
+		"identity file": WithIdentityFile(keyFile),
+	} {
+		var cfg Config
+		if err := confucius.Load(&cfg, confucius.String(content, confucius.DecoderYaml), opt); err != nil {
+			t.Fatalf("%s: unexpected err: %v", name, err)
+		}
+		if cfg.User != "admin" || cfg.Password != "hunter2" {
+			t.Errorf("%s: unexpected cfg: %+v", name, cfg)
+		}
+	}
+
+	for name, opt := range map[string]confucius.Option{
+		"wrong identity":   WithIdentities(other),
+		"no identity file": WithIdentityFile(filepath.Join(t.TempDir(), "missing.txt")),
+	} {
+		var cfg Config
+		if err := confucius.Load(&cfg, confucius.String(content, confucius.DecoderYaml), opt); err == nil {
+			t.Errorf("%s: expected err", name)
+		}
+	}
+
+	// the identity file is read once, for the first value.
+	opt := WithIdentityFile(keyFile)
+	var cfg Config
+	content = "user: " + enc + "\npassword: " + enc + "\n"
+	if err := confucius.Load(&cfg, confucius.String(content, confucius.DecoderYaml), opt); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := os.Remove(keyFile); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	cfg = Config{}
+	if err := confucius.Load(&cfg, confucius.String(content, confucius.DecoderYaml), opt); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.User != "hunter2" || cfg.Password != "hunter2" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+}
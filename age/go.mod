@@ -0,0 +1,23 @@
+module github.com/hasanozgan/confucius/age
+
+go 1.18
+
+require (
+	filippo.io/age v1.0.0
+	github.com/hasanozgan/confucius v0.0.0
+)
+
+require (
+	github.com/imdario/mergo v0.3.12 // indirect
+	github.com/klauspost/compress v1.15.15 // indirect
+	github.com/mitchellh/mapstructure v1.5.0 // indirect
+	github.com/pelletier/go-toml/v2 v2.0.5 // indirect
+	github.com/robfig/cron/v3 v3.0.1 // indirect
+	github.com/vmihailenco/msgpack/v5 v5.3.5 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5 // indirect
+	golang.org/x/sys v0.0.0-20210903071746-97244b99971b // indirect
+	gopkg.in/yaml.v2 v2.3.0 // indirect
+)
+
+replace github.com/hasanozgan/confucius => ../
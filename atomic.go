@@ -0,0 +1,78 @@
+package confucius
+
+import "reflect"
+
+// atomically calls load with cfg, or with a copy of cfg that is only copied
+// back into cfg if load succeeds when the load is atomic. cfg must be a
+// pointer to a struct.
+func (c *confucius) atomically(cfg interface{}, load func(cfg interface{}) error) error {
+	if !c.atomic {
+		return load(cfg)
+	}
+
+	v := reflect.ValueOf(cfg).Elem()
+	tmp := reflect.New(v.Type())
+	tmp.Elem().Set(deepCopy(v))
+	if err := load(tmp.Interface()); err != nil {
+		return err
+	}
+	v.Set(tmp.Elem())
+	return nil
+}
+
+// deepCopy returns a copy of v that does not share pointers, slices and
+// maps reachable through exported fields with v, so that decoding into the
+// copy leaves v untouched.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(deepCopy(v.Elem()))
+		return p
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		i := reflect.New(v.Type()).Elem()
+		i.Set(deepCopy(v.Elem()))
+		return i
+	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if s.Field(i).CanSet() {
+				s.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return s
+	case reflect.Array:
+		a := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			a.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return a
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return m
+	}
+	return v
+}
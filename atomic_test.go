@@ -0,0 +1,64 @@
+package confucius
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_Load_Atomic(t *testing.T) {
+	type Server struct {
+		Host string `conf:"host"`
+		Port int    `conf:"port" validate:"required"`
+	}
+	type Config struct {
+		Name    string            `conf:"name"`
+		Server  *Server           `conf:"server"`
+		Tags    []string          `conf:"tags"`
+		Labels  map[string]string `conf:"labels"`
+		Timeout int               `conf:"timeout" validate:"required"`
+	}
+
+	initial := func() Config {
+		return Config{
+			Name:   "initial",
+			Server: &Server{Host: "localhost", Port: 80},
+			Tags:   []string{"a"},
+			Labels: map[string]string{"team": "core"},
+		}
+	}
+
+	content := `{"name": "app", "server": {"host": "example.com"}, "tags": ["b", "c"], "labels": {"team": "infra"}}`
+
+	cfg := initial()
+	err := Load(&cfg, String(content, DecoderJSON), Atomic())
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+	if !reflect.DeepEqual(initial(), cfg) {
+		t.Errorf("cfg was modified: %+v %+v", cfg, cfg.Server)
+	}
+
+	cfg = initial()
+	err = Load(&cfg, String(content, DecoderJSON), Atomic(), Lenient())
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected *LoadError, got %v", err)
+	}
+	if partial := loadErr.Config.(*Config); partial.Name != "app" || partial.Server.Host != "example.com" {
+		t.Errorf("unexpected partial cfg: %+v", partial)
+	}
+	if !reflect.DeepEqual(initial(), cfg) {
+		t.Errorf("cfg was modified: %+v %+v", cfg, cfg.Server)
+	}
+
+	cfg = initial()
+	if err := Load(&cfg, String(`{"timeout": 5}`, DecoderJSON), Atomic()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := initial()
+	want.Timeout = 5
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+}
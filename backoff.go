@@ -0,0 +1,102 @@
+package confucius
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// jitterRand returns a random number in [0, 1) used to jitter backoff delays.
+var jitterRand = rand.Float64
+
+// Backoff is a config section for an exponential backoff policy.
+//
+//   type Config struct {
+//     Retry confucius.Backoff `conf:"retry"`
+//   }
+//
+//   retry:
+//     initial: 200ms
+//     max: 1m
+//     multiplier: 1.5
+//     jitter: 20%
+//
+// The first delay is Initial, each following delay is Multiplier times the
+// previous one, up to Max. Jitter randomly shortens each delay by up to the
+// given ratio. Settings that are not set default to an initial delay of
+// 100ms, a maximum of 30s, a multiplier of 2 and no jitter.
+type Backoff struct {
+	Initial    time.Duration `conf:"initial" default:"100ms"`
+	Max        time.Duration `conf:"max" default:"30s"`
+	Multiplier float64       `conf:"multiplier" default:"2"`
+	Jitter     Percent       `conf:"jitter"`
+}
+
+// Validate checks that the delays, the multiplier and the jitter are in
+// range.
+func (b Backoff) Validate() error {
+	if b.Initial <= 0 {
+		return fmt.Errorf("initial delay must be positive")
+	}
+	if b.Max < b.Initial {
+		return fmt.Errorf("max delay %v is less than initial delay %v", b.Max, b.Initial)
+	}
+	if b.Multiplier < 1 || math.IsInf(b.Multiplier, 0) {
+		return fmt.Errorf("multiplier must be at least 1")
+	}
+	if b.Jitter < 0 || b.Jitter > 1 {
+		return fmt.Errorf("jitter out of range [0%%, 100%%]")
+	}
+	return nil
+}
+
+// Delay returns the delay before the given retry attempt, starting at 0.
+func (b Backoff) Delay(attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	d := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt))
+	if d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d -= d * b.Jitter.Float() * jitterRand()
+	}
+	return time.Duration(d)
+}
+
+// Sequence returns a new sequence of delays that follows the policy.
+//
+//   delays := cfg.Retry.Sequence()
+//   for err := call(); err != nil; err = call() {
+//     time.Sleep(delays.Next())
+//   }
+func (b Backoff) Sequence() *BackoffSequence {
+	return &BackoffSequence{policy: b}
+}
+
+// BackoffSequence is a sequence of delays created by `Backoff.Sequence()`.
+// It is not safe for concurrent use.
+type BackoffSequence struct {
+	policy  Backoff
+	attempt int
+}
+
+// Next returns the next delay of the sequence.
+func (s *BackoffSequence) Next() time.Duration {
+	d := s.policy.Delay(s.attempt)
+	s.attempt++
+	return d
+}
+
+// Attempt returns the number of delays returned since the sequence was
+// created or reset.
+func (s *BackoffSequence) Attempt() int {
+	return s.attempt
+}
+
+// Reset restarts the sequence from the initial delay.
+func (s *BackoffSequence) Reset() {
+	s.attempt = 0
+}
@@ -0,0 +1,74 @@
+package confucius
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff_Sequence(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+
+	seq := b.Sequence()
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, w := range want {
+		if got := seq.Next(); got != w {
+			t.Errorf("delay %d: want %v, got %v", i, w, got)
+		}
+	}
+	if seq.Attempt() != len(want) {
+		t.Errorf("unexpected attempt %d", seq.Attempt())
+	}
+
+	seq.Reset()
+	if got := seq.Next(); got != 100*time.Millisecond {
+		t.Errorf("expected initial delay after reset, got %v", got)
+	}
+
+	if got := b.Delay(10000); got != time.Second {
+		t.Errorf("expected max delay, got %v", got)
+	}
+}
+
+func TestBackoff_Delay_Jitter(t *testing.T) {
+	defer func(fn func() float64) { jitterRand = fn }(jitterRand)
+	jitterRand = func() float64 { return 0.5 }
+
+	b := Backoff{Initial: time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.2}
+	if got := b.Delay(1); got != 1800*time.Millisecond {
+		t.Errorf("want 1.8s, got %v", got)
+	}
+}
+
+func Test_confucius_Load_Backoff(t *testing.T) {
+	type Config struct {
+		Retry Backoff   `conf:"retry"`
+		Limit RateLimit `conf:"limit"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"retry": {"max": "1m", "jitter": "20%"}, "limit": {"rps": 5}}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := Backoff{Initial: 100 * time.Millisecond, Max: time.Minute, Multiplier: 2, Jitter: 0.2}
+	if cfg.Retry != want {
+		t.Errorf("want %+v, got %+v", want, cfg.Retry)
+	}
+	if cfg.Limit.RPS != 5 {
+		t.Errorf("unexpected cfg.Limit: %+v", cfg.Limit)
+	}
+
+	cfg = Config{}
+	err = Load(&cfg, String(`{"retry": {"initial": "1m", "max": "1s"}, "limit": {"rps": -1}}`, DecoderJSON))
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok || fieldErrs["retry"] == nil || fieldErrs["limit"] == nil {
+		t.Fatalf("expected retry and limit errs, got %v", err)
+	}
+}
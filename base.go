@@ -0,0 +1,325 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Base marks a struct as a base config that holds the sections shared by
+// many config structs, e.g. logging, telemetry and server settings.
+//
+//   type Common struct {
+//     confucius.Base
+//     Log       LogConfig           `conf:"log"`
+//     Telemetry confucius.Telemetry `conf:"telemetry"`
+//   }
+//
+//   type Config struct {
+//     Common
+//     DB DBConfig `conf:"db"`
+//   }
+//
+// The fields of an embedded base struct are treated as fields of the struct
+// that embeds it, in config files, in environment variables and when the
+// config is encoded. With the struct above the config file contains `log`,
+// `telemetry` and `db` at the top level and the log level is set from
+// MYAPP_LOG_LEVEL, rather than from `Common.log` and MYAPP_COMMON_LOG_LEVEL
+// as for other embedded structs. The embedded base struct type must be
+// exported.
+type Base struct{}
+
+var baseType = reflect.TypeOf(Base{})
+
+var (
+	baseDefaultsMu sync.RWMutex
+	baseDefaults   = map[reflect.Type]reflect.Value{}
+)
+
+// RegisterBaseDefaults registers defaults for a base struct type, so that
+// they are declared once and applied to every config struct that embeds
+// the type.
+//
+//   confucius.RegisterBaseDefaults(Common{
+//     Log: LogConfig{Level: "info", Format: "json"},
+//   })
+//
+// When a config is loaded, the fields of an embedded base struct that are
+// absent from all sources and hold their zero value are set to the non-zero
+// fields of the registered defaults. Registered defaults take precedence
+// over the default tags of the base struct's fields, and are overridden by
+// the environment. Registering defaults for a type again replaces them.
+// RegisterBaseDefaults panics if defaults is not a base struct.
+func RegisterBaseDefaults(defaults interface{}) {
+	v := reflect.ValueOf(defaults)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !isBaseType(v.Type()) {
+		panic(fmt.Sprintf("confucius: RegisterBaseDefaults: %s does not embed confucius.Base", v.Type()))
+	}
+
+	baseDefaultsMu.Lock()
+	defer baseDefaultsMu.Unlock()
+	baseDefaults[v.Type()] = v
+}
+
+// isBaseType reports whether t is Base or a struct that embeds it.
+func isBaseType(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if t == baseType {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if sf := t.Field(i); sf.Anonymous && sf.Type == baseType {
+			return true
+		}
+	}
+	return false
+}
+
+// isSquashed reports whether the fields of the struct field sf are treated
+// as fields of the surrounding struct.
+func isSquashed(sf reflect.StructField) bool {
+	return sf.Anonymous && isBaseType(sf.Type)
+}
+
+// fieldName returns the name of the struct field sf in config files.
+func (tc *tagCache) fieldName(sf reflect.StructField, tagKey string) string {
+	if st := tc.parse(sf.Tag, tagKey); st.altName != "" {
+		return st.altName
+	}
+	return sf.Name
+}
+
+// nestSquashed moves the values of the fields of embedded base structs into
+// a nested map under the name of the embedded field, which is where
+// mapstructure expects them. Values of fields that are named by a tag key
+// other than the first one are moved to the name mapstructure expects as
+// well. If renames is not nil, the decoded paths of the moved values are
+// recorded in it along with their name in config files, or an empty name
+// for embedded base structs, so that the decoded keys can be mapped back.
+func (c *confucius) nestSquashed(vals map[string]interface{}, t reflect.Type, path string, renames map[string]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name := c.tags.fieldName(sf, c.tag)
+		decodedName := c.tags.fieldName(sf, c.decodeTag())
+		fieldPath := joinPath(path, decodedName)
+
+		if isSquashed(sf) {
+			sub := make(map[string]interface{})
+			c.extractSquashed(vals, sub, sf.Type)
+			c.nestSquashed(sub, sf.Type, fieldPath, renames)
+			vals[decodedName] = sub
+			if renames != nil {
+				renames[fieldPath] = ""
+			}
+			continue
+		}
+
+		if name != decodedName && renames != nil {
+			renames[fieldPath] = name
+		}
+		key, ok := matchKey(vals, name)
+		if !ok {
+			continue
+		}
+		val := c.nestSquashedValue(vals[key], sf.Type, fieldPath, renames)
+		if name != decodedName {
+			delete(vals, key)
+			key = decodedName
+		}
+		vals[key] = val
+	}
+}
+
+// nestSquashedValue calls nestSquashed on val if it holds the values of a
+// struct, of a slice or of a map.
+func (c *confucius) nestSquashedValue(val interface{}, t reflect.Type, path string, renames map[string]string) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := toStringMap(val); ok {
+			c.nestSquashed(m, t, path, renames)
+			return m
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := val.([]interface{}); ok {
+			for i := range s {
+				s[i] = c.nestSquashedValue(s[i], t.Elem(), fmt.Sprintf("%s[%d]", path, i), renames)
+			}
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if m, ok := toStringMap(val); ok {
+			for key := range m {
+				m[key] = c.nestSquashedValue(m[key], t.Elem(), fmt.Sprintf("%s[%s]", path, key), renames)
+			}
+			return m
+		}
+	}
+	return val
+}
+
+// extractSquashed moves the values of the fields of the base struct type t
+// from vals to sub. The fields of base structs embedded in t are moved as
+// well, as they are at the same level.
+func (c *confucius) extractSquashed(vals, sub map[string]interface{}, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if isSquashed(sf) {
+			c.extractSquashed(vals, sub, sf.Type)
+			continue
+		}
+		if key, ok := matchKey(vals, c.tags.fieldName(sf, c.tag)); ok {
+			sub[key] = vals[key]
+			delete(vals, key)
+		}
+	}
+}
+
+// unsquashKey maps a key decoded by mapstructure back to the field's path
+// using the renames recorded by nestSquashed, removing the names of
+// embedded base structs. The returned bool is false if the key is the key
+// of an embedded base struct itself.
+func unsquashKey(key string, renames map[string]string) (string, bool) {
+	if len(renames) == 0 {
+		return key, true
+	}
+
+	var path string
+	var segments []string
+	for _, segment := range strings.Split(key, ".") {
+		index := ""
+		if i := strings.Index(segment, "["); i != -1 {
+			segment, index = segment[:i], segment[i:]
+		}
+		path = joinPath(path, segment) + index
+		name, ok := renames[strings.TrimSuffix(path, index)]
+		if !ok {
+			segments = append(segments, segment+index)
+		} else if name != "" {
+			segments = append(segments, name+index)
+		}
+	}
+	return strings.Join(segments, "."), len(segments) > 0
+}
+
+// applyBaseDefaults sets the registered defaults of the base structs
+// embedded in v. path is the path of v.
+func (c *confucius) applyBaseDefaults(v reflect.Value, path string) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if isLeafStruct(v.Type()) {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			if isSquashed(sf) {
+				baseDefaultsMu.RLock()
+				defaults, ok := baseDefaults[sf.Type]
+				baseDefaultsMu.RUnlock()
+				if ok {
+					c.fillFromDefaults(v.Field(i), defaults, path)
+				}
+				c.applyBaseDefaults(v.Field(i), path)
+				continue
+			}
+			c.applyBaseDefaults(v.Field(i), joinPath(path, c.tags.fieldName(sf, c.tag)))
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			c.applyBaseDefaults(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// fillFromDefaults sets the fields of dst that are absent from all sources
+// and hold their zero value to the corresponding non-zero value of src.
+// path is the path of dst.
+func (c *confucius) fillFromDefaults(dst, src reflect.Value, path string) {
+	if dst.Kind() == reflect.Struct && !isLeafStruct(dst.Type()) {
+		for i := 0; i < dst.NumField(); i++ {
+			sf := dst.Type().Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			fieldPath := path
+			if !isSquashed(sf) {
+				fieldPath = joinPath(path, c.tags.fieldName(sf, c.tag))
+			}
+			c.fillFromDefaults(dst.Field(i), src.Field(i), fieldPath)
+		}
+		return
+	}
+
+	if c.present[path] || !isZero(dst) || isZero(src) || !dst.CanSet() {
+		return
+	}
+	dst.Set(copyValue(src))
+	c.setSource(path, Source{Kind: SourceDefault}, "")
+}
+
+// isLeafStruct reports whether the struct type t holds a single value
+// rather than fields of a config.
+func isLeafStruct(t reflect.Type) bool {
+	return t == reflect.TypeOf(time.Time{}) || isOptionalType(t) || reflect.PtrTo(t).Implements(parserType) || isTextUnmarshalerType(t) || isNetType(t)
+}
+
+// copyValue returns a copy of v that does not share the elements of slices
+// and maps with v.
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(s, v)
+		return s
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return m
+	}
+	return v
+}
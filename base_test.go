@@ -0,0 +1,115 @@
+package confucius
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type testLog struct {
+	Level  string `conf:"level" default:"info"`
+	Format string `conf:"format" default:"text"`
+}
+
+type SharedConfig struct {
+	Base
+	Log   testLog  `conf:"log"`
+	Name  string   `conf:"name" validate:"required"`
+	Tags  []string `conf:"tags"`
+	Ports []int    `conf:"ports"`
+}
+
+type testServiceConfig struct {
+	SharedConfig
+	Port int `conf:"port" default:"8080"`
+}
+
+func Test_confucius_Load_Base(t *testing.T) {
+	setenv(t, "APP_LOG_LEVEL", "debug")
+	defer os.Unsetenv("APP_LOG_LEVEL")
+
+	var cfg testServiceConfig
+	err := Load(&cfg, String(`{"name": "orders", "log": {"format": "json"}, "port": 9000}`, DecoderJSON), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := testServiceConfig{
+		SharedConfig: SharedConfig{Log: testLog{Level: "debug", Format: "json"}, Name: "orders"},
+		Port:       9000,
+	}
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+
+	err = Load(&testServiceConfig{}, String(`{}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["name"] == nil {
+		t.Errorf("expected err for name, got %v", err)
+	}
+}
+
+func Test_RegisterBaseDefaults(t *testing.T) {
+	RegisterBaseDefaults(SharedConfig{
+		Log:  testLog{Level: "warn"},
+		Tags: []string{"shared"},
+	})
+	defer func() {
+		baseDefaultsMu.Lock()
+		delete(baseDefaults, reflect.TypeOf(SharedConfig{}))
+		baseDefaultsMu.Unlock()
+	}()
+
+	var cfg testServiceConfig
+	if err := Load(&cfg, String(`{"name": "orders", "ports": []}`, DecoderJSON)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Log.Level != "warn" || cfg.Log.Format != "text" {
+		t.Errorf("unexpected cfg.Log: %+v", cfg.Log)
+	}
+	if !reflect.DeepEqual(cfg.Tags, []string{"shared"}) {
+		t.Errorf("unexpected cfg.Tags: %v", cfg.Tags)
+	}
+
+	cfg.Tags[0] = "modified"
+	var other testServiceConfig
+	if err := Load(&other, String(`{"name": "billing", "log": {"level": "error"}}`, DecoderJSON)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if other.Log.Level != "error" || other.Tags[0] != "shared" {
+		t.Errorf("unexpected cfg: %+v", other)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic for a struct that is not a base")
+		}
+	}()
+	RegisterBaseDefaults(testLog{})
+}
+
+func Test_Snapshot_Base(t *testing.T) {
+	cfg := testServiceConfig{SharedConfig: SharedConfig{Name: "orders", Log: testLog{Level: "info"}}, Port: 1}
+	b, err := Snapshot(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var doc struct {
+		Values map[string]interface{} `json:"values"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if doc.Values["name"] != "orders" || doc.Values["SharedConfig"] != nil {
+		t.Errorf("expected base fields at the top level: %s", b)
+	}
+
+	var restored testServiceConfig
+	if err := RestoreSnapshot(b, &restored); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, restored) {
+		t.Errorf("\nwant %+v\ngot  %+v", cfg, restored)
+	}
+}
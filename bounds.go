@@ -0,0 +1,88 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+	"unicode/utf8"
+)
+
+// boundRule returns a rule that compares a field with the number in its
+// parameter using op, which is one of "<=", ">=" and "==". Numbers are
+// compared by value, including durations whose parameter is written as a
+// duration, e.g. `min=1s`. Strings (by their number of characters),
+// slices, arrays and maps are compared by their length, which is the only
+// comparison made if lengthOnly is set.
+//
+// Nil pointers are left to the required rule. Elements of slices and maps
+// share the tag of their field but are not checked themselves.
+func boundRule(name, op string, lengthOnly bool) ruleFunc {
+	return func(f *field, param string) error {
+		if f.sliceIdx >= 0 || f.mapKey.IsValid() {
+			return nil
+		}
+		tok, err := numberToken(param)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		bound := tok.value.(float64)
+
+		v := f.v
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+		}
+
+		var val float64
+		what := "length"
+		switch v.Kind() {
+		case reflect.String:
+			val = float64(utf8.RuneCountInString(v.String()))
+		case reflect.Slice, reflect.Array, reflect.Map:
+			val = float64(v.Len())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if lengthOnly {
+				return fmt.Errorf("%s: not supported for %s", name, v.Type())
+			}
+			val, what = exprValue(v).(float64), "value"
+		default:
+			return fmt.Errorf("%s: not supported for %s", name, v.Type())
+		}
+
+		if compareBound(op, val, bound) {
+			return nil
+		}
+		return fmt.Errorf("%s %s %s %s", what, formatBound(v, val), boundVerbs[op], formatBound(v, bound))
+	}
+}
+
+// boundVerbs describe the failures of the comparisons of boundRule.
+var boundVerbs = map[string]string{
+	"<=": "is greater than the maximum",
+	">=": "is less than the minimum",
+	"==": "is not",
+}
+
+func compareBound(op string, val, bound float64) bool {
+	switch op {
+	case "<=":
+		return val <= bound
+	case ">=":
+		return val >= bound
+	default:
+		return val == bound
+	}
+}
+
+// formatBound formats n, which is a value or a bound of v, as a duration if
+// v holds a duration.
+func formatBound(v reflect.Value, n float64) string {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(n).String()
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,95 @@
+package confucius
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_confucius_Load_Bounds(t *testing.T) {
+	type Server struct {
+		Host string `conf:"host"`
+	}
+	type Config struct {
+		Port     int               `conf:"port" validate:"min=1,max=65535"`
+		Ratio    *float64          `conf:"ratio" validate:"gte=0,lte=1"`
+		Workers  uint              `conf:"workers" validate:"lte=64"`
+		Timeout  time.Duration     `conf:"timeout" validate:"min=1s,max=1m"`
+		Name     string            `conf:"name" validate:"minlen=2,maxlen=5"`
+		Code     string            `conf:"code" validate:"len=3"`
+		Tags     []string          `conf:"tags" validate:"maxlen=2"`
+		Servers  []Server          `conf:"servers" validate:"min=1"`
+		Labels   map[string]string `conf:"labels" validate:"max=1"`
+		Replicas int               `conf:"replicas" validate:"len=3"`
+	}
+	valid := `"port": 80, "timeout": "5s", "name": "ab", "code": "abc", "servers": [{"host": "a"}], "replicas": 3`
+
+	for _, tc := range []struct {
+		name    string
+		config  string
+		wantErr map[string]string
+	}{
+		{name: "valid", config: `{` + valid + `}`},
+		{name: "valid with optional", config: `{` + valid + `, "ratio": 0.5, "workers": 64, "tags": ["a", "b"], "labels": {"a": "b"}}`},
+		{name: "unicode length", config: `{` + valid + `, "name": "çğüşö"}`},
+		{
+			name:   "out of range",
+			config: `{"port": 0, "ratio": 1.5, "workers": 65, "timeout": "2m", "name": "abcdef", "code": "ab", "tags": ["a", "b", "c"], "labels": {"a": "b", "c": "d"}, "replicas": 2}`,
+			wantErr: map[string]string{
+				"port":     "value 0 is less than the minimum 1",
+				"ratio":    "value 1.5 is greater than the maximum 1",
+				"workers":  "value 65 is greater than the maximum 64",
+				"timeout":  "value 2m0s is greater than the maximum 1m0s",
+				"name":     "length 6 is greater than the maximum 5",
+				"code":     "length 2 is not 3",
+				"tags":     "length 3 is greater than the maximum 2",
+				"servers":  "length 0 is less than the minimum 1",
+				"labels":   "length 2 is greater than the maximum 1",
+				"replicas": "value 2 is not 3",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := Load(&cfg, String(tc.config, DecoderJSON))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(fieldErrors)
+			if !ok || len(fieldErrs) != len(tc.wantErr) {
+				t.Fatalf("want errors for %v, got %v", tc.wantErr, err)
+			}
+			for path, want := range tc.wantErr {
+				if err := fieldErrs[path]; err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("want error %q for %s, got %v", want, path, err)
+				}
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		var cfg struct {
+			Port int `validate:"maxlen=5"`
+		}
+		err := Load(&cfg, String(`{"port": 80}`, DecoderJSON))
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Rule != "maxlen" || !strings.Contains(err.Error(), "not supported for int") {
+			t.Fatalf("want unsupported maxlen error, got %v", err)
+		}
+	})
+
+	t.Run("invalid parameter", func(t *testing.T) {
+		var cfg struct {
+			Port int `validate:"min=one"`
+		}
+		err := Load(&cfg, String(`{"port": 80}`, DecoderJSON))
+		if err == nil || !strings.Contains(err.Error(), `invalid number "one"`) {
+			t.Fatalf("want invalid number error, got %v", err)
+		}
+	})
+}
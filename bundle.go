@@ -0,0 +1,95 @@
+package confucius
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Bundle packages config files into a single zip archive written to dst,
+// so that a deploy artifact can carry its entire config tree. The files
+// are typically the config file and its profile files.
+//
+//   err := confucius.Bundle(w, "config/config.yaml", "config/config.prod.yaml")
+//
+// The files are stored by their base name, which is how they are found
+// when the bundle is loaded with `LoadBundle`, so base names must be
+// unique.
+func Bundle(dst io.Writer, files ...string) error {
+	zw := zip.NewWriter(dst)
+	seen := make(map[string]string, len(files))
+	for _, file := range files {
+		name := filepath.Base(file)
+		if other, ok := seen[name]; ok {
+			return fmt.Errorf("bundle: %s and %s have the same name", other, file)
+		}
+		seen[name] = file
+
+		if err := addToBundle(zw, name, file); err != nil {
+			return fmt.Errorf("bundle: %v", err)
+		}
+	}
+	return zw.Close()
+}
+
+// addToBundle adds the contents of file to zw under name.
+func addToBundle(zw *zip.Writer, name, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// LoadBundle loads a config from a bundle created by `Bundle` into cfg, as
+// `Load` does from files. The parameter `cfg` must be a pointer to a
+// struct.
+//
+//   f, _ := os.Open("config.zip")
+//   err := confucius.LoadBundle(&cfg, f, confucius.Profiles("prod"))
+//
+// The config file and profile files are only looked up in the bundle, not
+// in the directories set by `Dirs`. Options that relate to the environment
+// and validation work as with `Load`.
+func LoadBundle(cfg interface{}, src io.Reader, options ...Option) error {
+	c := defaultConfucius()
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("bundle: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return fmt.Errorf("bundle: %v", err)
+	}
+	c.useEmbedFS = true
+	c.embedFS = zr
+	c.dirs = nil
+
+	return c.Load(cfg)
+}
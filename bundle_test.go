@@ -0,0 +1,70 @@
+package confucius
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Bundle(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":      "name: app\nport: 80\n",
+		"config.prod.yaml": "port: 443\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := Bundle(&buf, filepath.Join(dir, "config.yaml"), filepath.Join(dir, "config.prod.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	type Config struct {
+		Name string `conf:"name" validate:"required"`
+		Port int    `conf:"port"`
+	}
+
+	var cfg Config
+	if err := LoadBundle(&cfg, bytes.NewReader(buf.Bytes()), Profiles("prod")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Name != "app" || cfg.Port != 443 {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	err = LoadBundle(&Config{}, bytes.NewReader(buf.Bytes()), File("other.yaml"))
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected ErrFileNotFound, got %v", err)
+	}
+}
+
+func Test_Bundle_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0700); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	for _, name := range []string{"config.yaml", "a/config.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("name: app\n"), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Bundle(&buf, filepath.Join(dir, "config.yaml"), filepath.Join(dir, "a/config.yaml")); err == nil {
+		t.Errorf("expected err for duplicate names")
+	}
+	if err := Bundle(&buf, filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("expected err for missing file")
+	}
+
+	var cfg struct{}
+	if err := LoadBundle(&cfg, bytes.NewReader([]byte("not a zip"))); err == nil {
+		t.Errorf("expected err for invalid bundle")
+	}
+}
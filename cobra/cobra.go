@@ -0,0 +1,104 @@
+// Package cobra binds the fields of a confucius config to the flags of a
+// cobra command, so that flags can be layered over the config files and the
+// environment as with viper's BindPFlags.
+//
+//   var cfg Config
+//   cmd := &cobra.Command{Use: "myapp"}
+//   flags, err := confcobra.BindPFlags(cmd, &cfg, confucius.UseEnv("myapp"))
+//   cmd.RunE = func(cmd *cobra.Command, args []string) error {
+//     return confucius.Load(&cfg, confucius.UseEnv("myapp"), flags)
+//   }
+package cobra
+
+import (
+	"strings"
+
+	"github.com/hasanozgan/confucius"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// BindPFlags defines a flag on cmd for every field of cfg that holds a
+// single value, named after the path of the field, e.g. --server.host. The
+// parameter `cfg` must be a pointer to a struct. Flags that cmd already
+// defines are left untouched.
+//
+// The returned option passes the flags that were set on the command line
+// to `confucius.Load`, where they take precedence over the environment, the
+// files and the defaults. It must be applied after the flags were parsed,
+// i.e. within the Run function of cmd. The options are used to describe
+// the flags, e.g. `UseEnv` adds the environment variable of each field to
+// its usage.
+func BindPFlags(cmd *cobra.Command, cfg interface{}, options ...confucius.Option) (confucius.Option, error) {
+	defs, err := confucius.FlagDefs(cfg, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := cmd.Flags()
+	var names []string
+	for _, def := range defs {
+		if fs.Lookup(def.Name) != nil {
+			continue
+		}
+		f := fs.VarPF(&flagValue{value: def.Default, isBool: def.Bool}, def.Name, "", def.Usage)
+		if def.Bool {
+			f.NoOptDefVal = "true"
+		}
+		names = append(names, def.Name)
+	}
+
+	return confucius.FlagValuesFunc(func() map[string]string {
+		values := make(map[string]string)
+		for _, name := range names {
+			if f := fs.Lookup(name); f != nil && f.Changed {
+				values[name] = f.Value.String()
+			}
+		}
+		return values
+	}), nil
+}
+
+// FileFromFlag defines the flag name on cmd, e.g. --config, unless cmd
+// already defines it, and returns an option that takes the path of the
+// config file from the flag if it was set on the command line, as with
+// `confucius.FileFromFlag`. Like the option of BindPFlags, it must be
+// applied after the flags were parsed.
+func FileFromFlag(cmd *cobra.Command, name string) confucius.Option {
+	fs := cmd.Flags()
+	if fs.Lookup(name) == nil {
+		fs.String(name, "", "the path of the config file")
+	}
+	return confucius.FileFromFlagFunc(func() string {
+		if f := fs.Lookup(name); f != nil && f.Changed {
+			return f.Value.String()
+		}
+		return ""
+	})
+}
+
+// flagValue is the pflag.Value of the flags defined by BindPFlags. It holds
+// the flag's value as a string, which is then set like a value from the
+// environment.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+var _ pflag.Value = (*flagValue)(nil)
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	v.value = strings.TrimSpace(s)
+	return nil
+}
+
+func (v *flagValue) Type() string {
+	if v.isBool {
+		return "bool"
+	}
+	return "string"
+}
@@ -0,0 +1,89 @@
+package cobra
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hasanozgan/confucius"
+	"github.com/spf13/cobra"
+)
+
+func Test_BindPFlags(t *testing.T) {
+	type Config struct {
+		Name    string        `conf:"name" validate:"required"`
+		Verbose bool          `conf:"verbose"`
+		Timeout time.Duration `conf:"timeout" default:"5s" desc:"The request timeout."`
+		Server  struct {
+			Host string `conf:"host" default:"localhost"`
+			Port int    `conf:"port" default:"80"`
+		} `conf:"server"`
+	}
+
+	os.Setenv("MYAPP_SERVER_HOST", "env-host")
+	os.Setenv("MYAPP_SERVER_PORT", "8080")
+	defer os.Unsetenv("MYAPP_SERVER_HOST")
+	defer os.Unsetenv("MYAPP_SERVER_PORT")
+
+	var cfg Config
+	cmd := &cobra.Command{Use: "myapp"}
+	cmd.Flags().String("name", "", "an existing flag")
+	flags, err := BindPFlags(cmd, &cfg, confucius.UseEnv("myapp"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return confucius.Load(&cfg, confucius.String("name: file-name\n", confucius.DecoderYaml), confucius.UseEnv("myapp"), flags)
+	}
+
+	f := cmd.Flags().Lookup("timeout")
+	if f == nil || f.DefValue != "5s" || f.Usage != "The request timeout. (env MYAPP_TIMEOUT)" {
+		t.Fatalf("unexpected timeout flag: %+v", f)
+	}
+	if f := cmd.Flags().Lookup("name"); f.Usage != "an existing flag" {
+		t.Errorf("existing flag was redefined: %+v", f)
+	}
+
+	cmd.SetArgs([]string{"--verbose", "--server.port", "9090"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Name != "file-name" || !cfg.Verbose || cfg.Timeout != 5*time.Second {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+	if cfg.Server.Host != "env-host" || cfg.Server.Port != 9090 {
+		t.Errorf("unexpected cfg.Server: %+v", cfg.Server)
+	}
+}
+
+func Test_BindPFlags_NotStructPtr(t *testing.T) {
+	if _, err := BindPFlags(&cobra.Command{}, struct{}{}); err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func Test_FileFromFlag(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "myapp.yaml")
+	if err := os.WriteFile(path, []byte("name: from-flag\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var cfg struct {
+		Name string `conf:"name"`
+	}
+	cmd := &cobra.Command{Use: "myapp"}
+	file := FileFromFlag(cmd, "config")
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return confucius.Load(&cfg, confucius.File("missing.yaml"), file)
+	}
+
+	cmd.SetArgs([]string{"--config", path})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Name != "from-flag" {
+		t.Errorf("cfg.Name == %q, expected from-flag", cfg.Name)
+	}
+}
@@ -0,0 +1,53 @@
+package confucius
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// decompressors decompress the files with the extension of a compression
+// format, e.g. `config.yaml.gz`.
+var decompressors = map[string]func(r io.Reader) (io.ReadCloser, error){
+	".gz": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	".zst": func(r io.Reader) (io.ReadCloser, error) {
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	},
+}
+
+// fileDecoder returns the decoder of file by its extension, or by the
+// extension before that of the compression format if file is compressed,
+// e.g. ".yaml" for `config.yaml.gz`.
+func fileDecoder(file string) Decoder {
+	ext := filepath.Ext(file)
+	if _, ok := decompressors[ext]; ok {
+		ext = filepath.Ext(strings.TrimSuffix(file, ext))
+	}
+	return Decoder(ext)
+}
+
+// decompress returns the contents of b, which were read from the file name,
+// decompressed if name has the extension of a compression format.
+func decompress(name string, b []byte) ([]byte, error) {
+	newReader, ok := decompressors[filepath.Ext(name)]
+	if !ok {
+		return b, nil
+	}
+	r, err := newReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,109 @@
+package confucius
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func gzipped(t *testing.T, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zstdCompressed(t *testing.T, content string) []byte {
+	t.Helper()
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer enc.Close()
+	return enc.EncodeAll([]byte(content), nil)
+}
+
+func Test_fileDecoder(t *testing.T) {
+	for file, want := range map[string]Decoder{
+		"config.yaml":          DecoderYaml,
+		"config.yaml.gz":       DecoderYaml,
+		"/etc/config.json.zst": DecoderJSON,
+		"config.gz":            "",
+	} {
+		if got := fileDecoder(file); got != want {
+			t.Errorf("fileDecoder(%q) == %q, expected %q", file, got, want)
+		}
+	}
+}
+
+func Test_confucius_Load_Compressed(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string][]byte{
+		"config.yaml.gz":      gzipped(t, "host: example.com\nport: 80\n"),
+		"config.prod.yaml.gz": gzipped(t, "port: 443\n"),
+		"config.json.zst":     zstdCompressed(t, `{"host": "example.org", "port": 8080}`),
+		"broken.yaml.gz":      []byte("host: example.com\n"),
+		"invalid.json.zst":    zstdCompressed(t, "{\n  \"host\": ]\n}"),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		options []Option
+		want    Config
+	}{
+		{name: "gzip", options: []Option{File("config.yaml.gz")}, want: Config{Host: "example.com", Port: 80}},
+		{name: "gzip profile", options: []Option{File("config.yaml.gz"), Profiles("prod")}, want: Config{Host: "example.com", Port: 443}},
+		{name: "zstd", options: []Option{File("config.json.zst")}, want: Config{Host: "example.org", Port: 8080}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			if err := Load(&cfg, append(tc.options, Dirs(dir))...); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if cfg != tc.want {
+				t.Errorf("cfg == %+v, expected %+v", cfg, tc.want)
+			}
+		})
+	}
+
+	t.Run("not compressed", func(t *testing.T) {
+		var cfg Config
+		err := Load(&cfg, File("broken.yaml.gz"), Dirs(dir))
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) || !errors.Is(err, gzip.ErrHeader) {
+			t.Fatalf("expected a DecodeError wrapping gzip.ErrHeader, got %v", err)
+		}
+	})
+
+	t.Run("error position", func(t *testing.T) {
+		var cfg Config
+		err := Load(&cfg, File("invalid.json.zst"), Dirs(dir))
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("expected a DecodeError, got %v", err)
+		}
+		if decodeErr.Line != 2 {
+			t.Errorf("line == %d, expected 2", decodeErr.Line)
+		}
+	})
+}
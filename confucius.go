@@ -1,10 +1,13 @@
 package confucius
 
 import (
-	"embed"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -16,8 +19,6 @@ import (
 
 	"github.com/imdario/mergo"
 	"github.com/mitchellh/mapstructure"
-	"github.com/pelletier/go-toml"
-	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -30,6 +31,9 @@ const (
 	DefaultTag = "conf"
 	// DefaultTimeLayout is the default time layout that confucius uses to parse times.
 	DefaultTimeLayout = time.RFC3339
+	// DefaultEnvSeparator is the default separator that confucius uses between the
+	// names of the fields in environment variable keys.
+	DefaultEnvSeparator = "_"
 	// DefaultProfileLayout represents default profile file layout.
 	// You should use `config` for filename, `test` for profile, `yaml` for extension.
 	// Example; config-test.yaml
@@ -42,6 +46,21 @@ const (
 	EmbedLocationIndicator = "#embed"
 	// FileEmbedLocationIndicator is config file location indicator
 	LocalLocationIndicator = "#local"
+	// ProfilePlaceholder is replaced by the active profile in the prefix of
+	// environment variables, see `UseEnv`.
+	ProfilePlaceholder = "{profile}"
+)
+
+const (
+	// EnvModeReplace replaces a populated slice with the slice set from the
+	// environment. This is the default envmode.
+	EnvModeReplace = "replace"
+	// EnvModeAppend appends the elements set from the environment to a
+	// populated slice.
+	EnvModeAppend = "append"
+	// EnvModeMerge appends the elements set from the environment to a
+	// populated slice, skipping the elements the slice already contains.
+	EnvModeMerge = "merge"
 )
 
 type decodedObject map[string]interface{}
@@ -53,6 +72,7 @@ func defaultConfucius() *confucius {
 		tag:           DefaultTag,
 		timeLayout:    DefaultTimeLayout,
 		profileLayout: DefaultProfileLayout,
+		envSeparator:  DefaultEnvSeparator,
 		logger:        defaultLogger(),
 	}
 }
@@ -61,6 +81,17 @@ type confucius struct {
 	useEnv              bool
 	useReader           bool
 	useEmbedFS          bool
+	detectEnv           bool
+	lenient             bool
+	fillNilStructs      bool
+	merge               bool
+	atomic              bool
+	legacyTags          bool
+	rootList            bool // the config is a list at the root of the documents.
+	disableExpansion    bool
+	flags               map[string]string // values of the command line flags by field path.
+	decodeHooks         []mapstructure.DecodeHookFunc
+	keyMatcher          keyMatcher // matches keys in config files to field names, if set.
 	dirs                []string
 	profiles            []string
 	expectedConfigFiles []string
@@ -68,15 +99,57 @@ type confucius struct {
 	tag                 string
 	timeLayout          string
 	envPrefix           string
+	envSeparator        string
 	profileLayout       string
+	environment         string
+	detectedProfile     string
 	readerConfig        io.Reader
 	readerDecoder       Decoder
-	embedFS             embed.FS
+	embedFS             fs.FS
 	logger              *logger
+	errs                []error
+	present             map[string]bool          // paths of the fields that received a value from a source.
+	metadata            mapstructure.Metadata    // keys decoded from the files and the reader.
+	userMetadata        *mapstructure.Metadata   // filled with metadata, if set by the caller.
+	timings             map[string]time.Duration // time spent in each stage of loading.
+	sources             map[string]Source        // the sources of the fields by path, if tracked.
+	sourceVals          []sourceValues           // the values of each source, if sources are tracked.
+	onEvent             func(Event)              // receives the events of loading, if set.
+	tags                *tagCache                // the cache of parsed tags and layouts shared by the loads of a Loader.
+	subKey              string                   // the path of the section of the config to load, if set.
+	optionalProfiles    bool                     // skip missing profile files instead of failing.
+	profileDocuments    bool                     // merge the documents of YAML files by the profiles they declare.
+	filesSet            bool                     // the config files were set with File, FileFromEnv, FileFromFlag, Dirs or EmbedFS.
+	merged              []Source                 // the reader and the files in the order they were merged.
+	defaultProfile      string                   // the profile used when no profile is set, if set.
+	usedDefaultProfile  bool                     // no profile was set and the default profile is used.
+	overrides           map[string]interface{}   // the values forced by Override, by field path.
+	providers           []MapProvider            // merged over the files, in order.
+	spans               SpanStarter              // starts the spans of loading, if set.
+	spanCtx             context.Context          // the context of the current span.
+	decrypters          map[string]decryptFunc   // decrypt the inline encrypted values by scheme.
+	fileMode            *os.FileMode             // the permissions config files may have at most, if set.
+	fileModeWarn        bool                     // warn about files with more permissions instead of failing.
+	warnings            []*FieldError            // the failures of warning-level rules of the last load.
+	reportUnusedEnv     bool                     // report the environment variables that match no field.
+	usedEnv             map[string]bool          // the environment variables that set a field.
+	unusedEnv           []string                 // the environment variables that matched no field.
+	precedence          []string                 // the kinds of sources from the lowest precedence, if set.
+	readers             int                      // the number of Reader options used.
+	aboveEnv            []decodedObject          // the values of the sources that take precedence over the environment.
+	envShadowed         map[string]bool          // the paths of the fields that the environment must not override.
+	lenientJSON         bool                     // accept comments and trailing commas in .json files.
+	decoders            map[Decoder]decodeFunc   // decode the files of other formats by extension.
+	detectFormat        bool                     // pick the decoder of files without an extension by their content.
+	fileDecoder         Decoder                  // the decoder of the config and profile files, if set with File.
+	fileEnv             string                   // the environment variable that holds the path of the config file, if set.
+	fileFlag            func() string            // returns the path of the config file set by a flag, if any.
+	decodeErrs          fieldErrors              // the decode errors of the fields in lenient mode.
 }
 
 // Load reads a configuration file and loads it into the given struct. The
-// parameter `cfg` must be a pointer to a struct.
+// parameter `cfg` must be a pointer to a struct, or a pointer to a slice
+// for config documents whose root is a list.
 //
 // By default confucius looks for a file `config.yaml` in the current directory and
 // uses the struct field tag `fig` for matching field names and validation.
@@ -108,35 +181,217 @@ func Load(cfg interface{}, options ...Option) error {
 	return c.Load(cfg)
 }
 
+// Validate runs the default and validation pipeline of `Load` on an already
+// populated struct, without reading config files or the environment. The
+// parameter `cfg` must be a pointer to a struct.
+//
+//   cfg := Config{Host: "example.com"}
+//   err := confucius.Validate(&cfg)
+//
+// Fields that are not set are filled with their default values, so cfg may
+// be modified. Options that relate to finding or reading config files and
+// to the environment (e.g. `UseEnv`) are ignored.
+func Validate(cfg interface{}, options ...Option) error {
+	c := defaultConfucius()
+
+	for _, opt := range options {
+		opt(c)
+	}
+	c.useEnv = false
+
+	return c.validate(cfg)
+}
+
+// validate processes cfg without consulting the environment.
+func (c *confucius) validate(cfg interface{}) error {
+	if !isStructPtr(cfg) {
+		return fmt.Errorf("cfg must be a pointer to a struct")
+	}
+	return c.processCfg(cfg)
+}
+
 func (c *confucius) Load(cfg interface{}) (err error) {
 	c.logger.Debug("confucius starting")
+	end := c.startSpan(SpanLoad, nil)
+	defer func() { end(err) }()
 
+	if isSlicePtr(cfg) && !c.rootList {
+		return c.loadList(cfg)
+	}
 	if !isStructPtr(cfg) {
-		return fmt.Errorf("cfg must be a pointer to a struct")
+		return fmt.Errorf("cfg must be a pointer to a struct or a slice")
+	}
+	return c.atomically(cfg, c.load)
+}
+
+// load loads the config into cfg, which must be a pointer to a struct.
+func (c *confucius) load(cfg interface{}) error {
+	vals, err := c.fetchValues()
+	if err != nil {
+		return err
+	}
+	return c.decodeValues(vals, cfg)
+}
+
+// fetchValues finds, reads and merges the values of the config files and
+// the reader.
+func (c *confucius) fetchValues() (decodedObject, error) {
+	discoverStart := time.Now()
+	if c.detectEnv {
+		c.applyEnvironment(detectEnvironment())
+	}
+	c.applyDefaultProfile()
+	c.applyFilePath()
+	c.track(StageDiscover, discoverStart)
+	if c.readers > 1 {
+		return nil, fmt.Errorf("%d readers are set, only one of Reader, String, Bytes or Stdin can be used", c.readers)
+	}
+	if c.precedence != nil {
+		if err := c.checkPrecedence(); err != nil {
+			return nil, err
+		}
 	}
 
 	vals := make(decodedObject)
+	var readerVals decodedObject
 	if c.useReader {
-		vals, err = c.decodeReader(c.readerConfig, c.readerDecoder)
+		c.emit(Event{Kind: EventDecode, Source: Source{Kind: SourceReader}, Value: string(c.readerDecoder)})
+		end := c.startSpan(SpanDecode, map[string]string{"source": SourceReader})
+		var err error
+		readerVals, err = c.decodeSource("", c.readerConfig, c.readerDecoder)
+		end(err)
+		if err := c.fail(err); err != nil {
+			return nil, err
+		}
+		if readerVals != nil && c.precedence == nil {
+			vals = readerVals
+			c.merged = append(c.merged, Source{Kind: SourceReader})
+			c.recordSource(Source{Kind: SourceReader}, readerVals)
+		}
+	}
+
+	var files []string
+	if c.usesFiles() {
+		discoverStart = time.Now()
+		end := c.startSpan(SpanDiscover, nil)
+		var err error
+		files, err = c.findFiles()
+		end(err)
+		c.track(StageDiscover, discoverStart)
 		if err != nil {
+			if c.lenient {
+				c.errs = append(c.errs, err)
+			} else if !(c.useReader || c.useEnv || len(c.providers) > 0) {
+				return nil, err
+			} else {
+				files = nil
+			}
+		}
+	}
+	if c.precedence != nil {
+		return c.decodeByPrecedence(readerVals, files)
+	}
+
+	vals, err := c.decodeFiles(files, vals)
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeProviders(vals)
+}
+
+// decodeValues decodes the fetched values into cfg and processes it.
+func (c *confucius) decodeValues(vals decodedObject, cfg interface{}) error {
+	decodeStart := time.Now()
+	if c.subKey != "" {
+		sub, err := c.selectSubKey(vals)
+		if err := c.fail(err); err != nil {
+			return err
+		}
+		if sub != nil {
+			vals = sub
+		}
+	}
+	c.envShadowed = c.envShadowedPaths(reflect.TypeOf(cfg))
+	if c.sources != nil {
+		c.attributeSources(reflect.TypeOf(cfg))
+	}
+	if c.keyMatcher != nil {
+		if err := c.fail(c.matchKeys(vals, reflect.TypeOf(cfg))); err != nil {
+			return err
+		}
+	}
+
+	if !c.disableExpansion && c.subKey == "" {
+		if err := c.fail(resolveReferences(vals)); err != nil {
 			return err
 		}
 	}
 
-	files, err := c.findFiles()
-	if err != nil && !(c.useReader || c.useEnv) {
+	if err := c.fail(c.transformValues(vals, reflect.TypeOf(cfg), chainTransforms(unitTransform, netTransform))); err != nil {
 		return err
 	}
 
-	if vals, err = c.decodeFiles(files, vals); err != nil {
+	if c.merge {
+		c.resetSlices(reflect.ValueOf(cfg), vals)
+	}
+
+	decodeErr := c.decodeMap(vals, cfg)
+	if errs, ok := decodeErr.(fieldErrors); ok && c.lenient {
+		// reported with the errors of the validation by processCfg.
+		c.decodeErrs, decodeErr = errs, nil
+	}
+	if err := c.fail(decodeErr); err != nil {
 		return err
 	}
+	c.track(StageDecode, decodeStart)
 
-	if err := c.decodeMap(vals, cfg); err != nil {
+	if err := c.fail(c.processCfg(cfg)); err != nil {
 		return err
 	}
 
-	return c.processCfg(cfg)
+	if len(c.errs) > 0 {
+		return &LoadError{Config: cfg, Errors: c.errs}
+	}
+	return nil
+}
+
+// fail returns err, unless confucius is lenient in which case err is
+// collected and nil is returned so that loading continues.
+func (c *confucius) fail(err error) error {
+	if err != nil && c.lenient {
+		c.errs = append(c.errs, err)
+		return nil
+	}
+	return err
+}
+
+// applyEnvironment activates the profile of the detected environment. The
+// profile is optional, so a missing profile file does not fail the load.
+func (c *confucius) applyEnvironment(env string) {
+	c.environment = env
+	c.logger.Debug("detected environment: %q", env)
+	if env == "" {
+		return
+	}
+
+	for _, profile := range c.profiles {
+		if profile == env {
+			return
+		}
+	}
+	c.profiles = append(c.profiles, env)
+	c.detectedProfile = env
+}
+
+// applyDefaultProfile activates the default profile when no profile was
+// set, neither by `Profiles` nor by the detected environment.
+func (c *confucius) applyDefaultProfile() {
+	if c.defaultProfile == "" || len(c.profiles) > 0 {
+		return
+	}
+	c.logger.Debug("no profile set, using default profile: %q", c.defaultProfile)
+	c.profiles = []string{c.defaultProfile}
+	c.usedDefaultProfile = true
 }
 
 func (c *confucius) findFiles() ([]string, error) {
@@ -150,20 +405,47 @@ func (c *confucius) findFiles() ([]string, error) {
 	result = append(result, files...)
 	result = append(result, c.findLocalFiles()...)
 
+	for _, file := range result {
+		c.emit(Event{Kind: EventFileFound, Source: fileSource(file)})
+	}
+	for _, file := range c.expectedConfigFiles {
+		c.emit(Event{Kind: EventFileNotFound, Source: Source{Kind: SourceFile, Name: file}})
+	}
+	if c.optionalProfiles || c.profileDocuments {
+		c.skipMissingProfiles()
+	}
+
 	if len(c.expectedConfigFiles) > 0 {
-		return nil, fmt.Errorf("\"%s\" file(s) not found: %w",
-			strings.Join(c.expectedConfigFiles, "\", \""),
-			ErrFileNotFound,
-		)
+		sort.StringSlice(result).Sort()
+		return result, c.notFoundError()
 	}
 
 	sort.StringSlice(result).Sort()
 	return result, nil
 }
 
+// notFoundError returns the error for the expected config files that were
+// not found.
+func (c *confucius) notFoundError() *NotFoundError {
+	err := &NotFoundError{
+		Files:    append([]string(nil), c.expectedConfigFiles...),
+		Embedded: c.useEmbedFS,
+	}
+	for _, dir := range c.searchDirs() {
+		if abs, absErr := filepath.Abs(dir); absErr == nil {
+			dir = abs
+		}
+		err.Dirs = append(err.Dirs, dir)
+	}
+	if len(c.profiles) > 0 {
+		err.ProfileLayout = c.profileLayout
+	}
+	return err
+}
+
 func (c *confucius) findLocalFiles() (acc []string) {
 	found := map[string]bool{}
-	for _, dir := range c.dirs {
+	for _, dir := range c.searchDirs() {
 		path := filepath.Join(dir, c.filename)
 		if fileExists(path) && !found[c.filename] {
 			found[c.filename] = true
@@ -216,7 +498,7 @@ func (c confucius) fileExists(filename string) string {
 }
 
 func (c *confucius) walkEmbedDir(accumulator *[]string, found map[string]bool, path string) error {
-	entries, err := c.embedFS.ReadDir(path)
+	entries, err := fs.ReadDir(c.embedFS, path)
 	if err != nil {
 		return err
 	}
@@ -245,10 +527,24 @@ func (c *confucius) initExpectedConfigFiles() {
 	c.expectedConfigFiles = []string{c.filename}
 
 	for _, profile := range c.profiles {
+		if profile == c.detectedProfile {
+			continue
+		}
 		c.expectedConfigFiles = append(c.expectedConfigFiles, c.profileFileName(profile))
 	}
 }
 
+// skipMissingProfiles removes the profile files from the expected files
+// that were not found, so that only a missing main file fails the load.
+func (c *confucius) skipMissingProfiles() {
+	for _, file := range c.expectedConfigFiles {
+		if file != c.filename {
+			c.logger.Debug("profile file not found, skipping: %s", file)
+			c.removeFromExpectedList(file)
+		}
+	}
+}
+
 func (c *confucius) removeFromExpectedList(file string) {
 	result := []string{}
 	for _, expected := range c.expectedConfigFiles {
@@ -267,7 +563,7 @@ func (c *confucius) decodeEmbedFile(file string) (vals decodedObject, err error)
 	}
 	defer fd.Close()
 
-	return c.decodeReader(fd, Decoder(filepath.Ext(file)))
+	return c.decodeSource(file, fd, c.decoderOf(file))
 }
 
 func (c *confucius) decodeFiles(files []string, origin decodedObject) (vals decodedObject, err error) {
@@ -275,24 +571,45 @@ func (c *confucius) decodeFiles(files []string, origin decodedObject) (vals deco
 	for _, file := range files {
 		fileVals := decodedObject{}
 		sections := strings.Split(file, "=")
+		c.emit(Event{Kind: EventDecode, Source: fileSource(file), Value: string(c.decoderOf(sections[1]))})
+		end := c.startSpan(SpanDecode, map[string]string{"source": fileSource(file).String()})
 
 		if strings.Contains(file, EmbedLocationIndicator) {
 			fileVals, err = c.decodeEmbedFile(sections[1])
 			if err != nil {
-				return nil, err
+				end(err)
+				if err = c.fail(err); err != nil {
+					return nil, err
+				}
+				continue
 			}
 		}
 
 		if strings.Contains(file, LocalLocationIndicator) {
 			fileVals, err = c.decodeFile(sections[1])
 			if err != nil {
-				return nil, err
+				end(err)
+				if err = c.fail(err); err != nil {
+					return nil, err
+				}
+				continue
 			}
 		}
 
-		if err := mergo.Merge(&vals, fileVals, mergo.WithOverride, mergo.WithTypeCheck); err != nil {
-			return nil, err
+		end(nil)
+		c.recordSource(fileSource(file), fileVals)
+
+		mergeStart := time.Now()
+		err := mergo.Merge(&vals, fileVals, mergo.WithOverride, mergo.WithTypeCheck)
+		c.track(StageMerge, mergeStart)
+		if err != nil {
+			if err = c.fail(err); err != nil {
+				return nil, err
+			}
+			continue
 		}
+		c.merged = append(c.merged, fileSource(file))
+		c.emit(Event{Kind: EventMerge, Source: fileSource(file)})
 	}
 	return vals, nil
 }
@@ -302,10 +619,26 @@ func (c *confucius) profileFileName(profile string) string {
 	parts := strings.Split(c.filename, ".")
 	filename = strings.ReplaceAll(filename, "config", parts[0])
 	filename = strings.ReplaceAll(filename, "test", profile)
+	if len(parts) < 2 {
+		// a file without an extension, see DetectFormat.
+		return strings.TrimSuffix(strings.ReplaceAll(filename, "yaml", ""), ".")
+	}
 	filename = strings.ReplaceAll(filename, "yaml", parts[1])
+	if ext := filepath.Ext(c.filename); decompressors[ext] != nil {
+		filename += ext
+	}
 	return filename
 }
 
+// decoderOf returns the decoder of the config or profile file: the decoder
+// set with File, if any, or else the decoder picked by its extension.
+func (c *confucius) decoderOf(file string) Decoder {
+	if c.fileDecoder != "" {
+		return c.fileDecoder
+	}
+	return fileDecoder(file)
+}
+
 // decodeFile reads the file and unmarshalls // it using a decoder based on the file extension.
 func (c *confucius) decodeFile(file string) (decodedObject, error) {
 	fd, err := os.Open(file)
@@ -314,79 +647,286 @@ func (c *confucius) decodeFile(file string) (decodedObject, error) {
 	}
 	defer fd.Close()
 
-	return c.decodeReader(fd, Decoder(filepath.Ext(file)))
+	if err := c.checkFileMode(fd); err != nil {
+		return nil, err
+	}
+	return c.decodeSource(file, fd, c.decoderOf(file))
+}
+
+// checkFileMode checks that the permissions of fd are within the mode set
+// with RequireFileMode, if any. Violations are logged as warnings instead
+// if only a warning is wanted.
+func (c *confucius) checkFileMode(fd *os.File) error {
+	if c.fileMode == nil {
+		return nil
+	}
+	info, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+
+	perm := info.Mode().Perm()
+	if perm&^*c.fileMode == 0 {
+		return nil
+	}
+	err = fmt.Errorf("%w: %s has mode %04o, expected at most %04o", ErrInsecureFileMode, fd.Name(), perm, *c.fileMode)
+	if c.fileModeWarn {
+		c.logger.Warn("%v", err)
+		return nil
+	}
+	return err
+}
+
+// decodeSource reads all of reader before decoding it with decodeReader, so
+// that the time spent fetching and decoding can be told apart. Compressed
+// files, e.g. `config.yaml.gz`, are decompressed first. Decoding errors are
+// returned as a *DecodeError with name, the path of the file being decoded,
+// or "" for the reader.
+func (c *confucius) decodeSource(name string, reader io.Reader, decoder Decoder) (decodedObject, error) {
+	fetchStart := time.Now()
+	b, err := ioutil.ReadAll(reader)
+	c.track(StageFetch, fetchStart)
+	if err != nil {
+		return nil, err
+	}
+	if b, err = decompress(name, b); err != nil {
+		return nil, &DecodeError{File: name, Err: err}
+	}
+
+	defer c.track(StageDecode, time.Now())
+	vals, err := c.decodeReader(bytes.NewReader(b), decoder)
+	if err != nil {
+		return nil, newDecodeError(name, b, err)
+	}
+	return vals, nil
 }
 
 func (c *confucius) decodeReader(reader io.Reader, decoder Decoder) (decodedObject, error) {
+	if c.detectFormat && !c.hasDecoder(decoder) {
+		b, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		decoder = detectDecoder(b)
+		c.logger.Debug("detected format: %q", decoder)
+		reader = bytes.NewReader(b)
+	}
+
+	if decode, ok := c.decoders[decoder]; ok {
+		return c.decodeRoot(reader, decode)
+	}
+
 	vals := make(decodedObject)
 
 	switch decoder {
 	case ".yaml", ".yml":
-		if err := yaml.NewDecoder(reader).Decode(&vals); err != nil {
-			return nil, err
+		if c.rootList {
+			return decodeYamlList(reader)
+		}
+		return c.decodeYamlDocuments(reader)
+	case ".json", ".jsonc":
+		if decoder == DecoderJSONC || c.lenientJSON {
+			var err error
+			if reader, err = jsoncReader(reader); err != nil {
+				return nil, err
+			}
+		}
+		if c.rootList {
+			var root interface{}
+			if err := json.NewDecoder(reader).Decode(&root); err != nil {
+				return nil, err
+			}
+			return wrapRootList(root)
 		}
-	case ".json":
 		if err := json.NewDecoder(reader).Decode(&vals); err != nil {
 			return nil, err
 		}
 	case ".toml":
-		tree, err := toml.LoadReader(reader)
-		if err != nil {
-			return nil, err
-		}
-		for field, val := range tree.ToMap() {
-			vals[field] = val
-		}
+		return decodeToml(reader)
+	case ".msgpack":
+		return c.decodeRoot(reader, decodeMsgpack)
 	default:
-		return nil, fmt.Errorf("unsupported file extension %s", filepath.Ext(c.filename))
+		return nil, fmt.Errorf("%w %s", ErrUnsupportedExtension, decoder)
 	}
 
 	return vals, nil
 }
 
+// decodeRoot decodes reader with decode, which returns the root of the
+// document, e.g. a decoder set with WithDecoder. A list at the root of the
+// document is only accepted with RootList.
+func (c *confucius) decodeRoot(reader io.Reader, decode decodeFunc) (decodedObject, error) {
+	root, err := decode(reader)
+	if err != nil {
+		return nil, err
+	}
+	root = normalizeValue(root)
+	if _, ok := root.([]interface{}); ok && !c.rootList {
+		return nil, fmt.Errorf("unexpected list at the root of the document")
+	}
+	return wrapRootList(root)
+}
+
 // decodeMap decodes a map of va// lues into result using the mapstructure library.
+// The paths of the fields that received a value are recorded, so defaults
+// are only applied to fields that are absent from every source.
 func (c *confucius) decodeMap(m decodedObject, result interface{}) error {
+	dec, md, err := c.newMetadataDecoder(result, !c.disableExpansion)
+	if err != nil {
+		return err
+	}
+	renames := make(map[string]string)
+	c.nestSquashed(m, reflect.TypeOf(result), "", renames)
+	err = typeErrors(dec.Decode(m), renames)
+	if errs, ok := err.(fieldErrors); ok {
+		redactSecrets(errs, c.flatten(result))
+	}
+
+	c.metadata = mapstructure.Metadata{
+		Keys:   normalizeKeys(md.Keys, renames),
+		Unused: normalizeKeys(md.Unused, renames),
+		Unset:  normalizeKeys(md.Unset, renames),
+	}
+	sort.Strings(c.metadata.Unused)
+	sort.Strings(c.metadata.Unset)
+	if c.userMetadata != nil {
+		*c.userMetadata = c.metadata
+	}
+
+	c.present = make(map[string]bool, len(c.metadata.Keys))
+	for _, key := range c.metadata.Keys {
+		c.present[key] = true
+	}
+	return err
+}
+
+// normalizeKeys maps keys decoded by mapstructure back to field paths, see
+// unsquashKey.
+func normalizeKeys(keys []string, renames map[string]string) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key, ok := unsquashKey(key, renames); ok {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// newDecoder returns a mapstructure decoder that decodes into result. If
+// expand is true then environment variables in strings are expanded.
+func (c *confucius) newDecoder(result interface{}, expand bool) (*mapstructure.Decoder, error) {
+	dec, _, err := c.newMetadataDecoder(result, expand)
+	return dec, err
+}
+
+// newMetadataDecoder is like newDecoder but also returns the metadata that
+// the decoder fills while decoding.
+func (c *confucius) newMetadataDecoder(result interface{}, expand bool) (*mapstructure.Decoder, *mapstructure.Metadata, error) {
+	hooks := []mapstructure.DecodeHookFunc{
+		optionalHookFunc(),
+		parserHookFunc(),
+		textUnmarshalerHookFunc(),
+		netHookFunc(),
+		headersHookFunc(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToTimeHookFunc(c.timeLayout),
+	}
+	hooks = append(hooks, c.decodeHooks...)
+	if expand {
+		hooks = append([]mapstructure.DecodeHookFunc{fromEnvironmentHookFunc(c.lookupEnv)}, hooks...)
+	}
+	hooks = append([]mapstructure.DecodeHookFunc{decryptHookFunc(c.decrypters)}, hooks...)
+
+	md := &mapstructure.Metadata{}
 	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		WeaklyTypedInput: true,
 		Result:           result,
-		TagName:          c.tag,
-		DecodeHook: mapstructure.ComposeDecodeHookFunc(
-			fromEnvironmentHookFunc(),
-			mapstructure.StringToTimeDurationHookFunc(),
-			mapstructure.StringToTimeHookFunc(c.timeLayout),
-		),
+		TagName:          c.decodeTag(),
+		Metadata:         md,
+		DecodeHook:       mapstructure.ComposeDecodeHookFunc(hooks...),
 	})
-	if err != nil {
-		return err
+	return dec, md, err
+}
+
+// decodeTag returns the tag key that mapstructure uses for the names of
+// fields, which is the first of the configured tag keys. Values of fields
+// named by the other keys are moved to that name by nestSquashed.
+func (c *confucius) decodeTag() string {
+	if i := strings.Index(c.tag, ","); i != -1 {
+		return c.tag[:i]
 	}
-	return dec.Decode(m)
+	return c.tag
 }
 
-func replaceEnvironments(str string) (result string, err error) {
-	re := regexp.MustCompile(`\$\{(.*?|)\}`)
-	result = str
-	for _, match := range re.FindAllStringSubmatch(str, -1) {
-		whole, value := match[0], match[1]
+// lookupEnv looks up an environment variable. When the environment is
+// detected, the pseudo-variable `env` resolves to the detected environment.
+func (c *confucius) lookupEnv(key string) (string, bool) {
+	if c.detectEnv && key == "env" {
+		return c.environment, true
+	}
+	return os.LookupEnv(key)
+}
+
+// environmentPattern matches references to environment variables, and their
+// escaped form `$${...}`.
+var environmentPattern = regexp.MustCompile(`\$?\$\{(.*?|)\}`)
+
+// replaceEnvironments replaces references to environment variables in str.
+// The following forms are supported:
+//
+//   ${NAME}           the value of NAME, or an empty string if NAME is not set
+//   ${NAME:default}   default if NAME is not set
+//   ${NAME:-default}  default if NAME is not set or empty
+//   ${NAME:+alt}      alt if NAME is set and not empty, otherwise an empty string
+//   ${NAME:?message}  an error with the message if NAME is not set or empty
+//
+// References escaped as `$${NAME}` are replaced by the literal `${NAME}`.
+func replaceEnvironments(str string, lookup func(string) (string, bool)) (result string, err error) {
+	result = environmentPattern.ReplaceAllStringFunc(str, func(whole string) string {
+		if strings.HasPrefix(whole, "$$") {
+			return whole[1:]
+		}
+
+		value := whole[2 : len(whole)-1]
 		if value == "" {
-			return result, fmt.Errorf("environment name is missing")
+			err = fmt.Errorf("environment name is missing")
+			return whole
 		}
 
-		s := strings.Split(value, ":")
-		envName := s[0]
-		if envValue, ok := os.LookupEnv(envName); ok {
-			result = strings.ReplaceAll(result, whole, envValue)
-		} else {
-			defaultVal := ""
-			if len(s) > 1 {
-				defaultVal = s[1]
+		name, arg := value, ""
+		hasArg := false
+		if i := strings.Index(value, ":"); i != -1 {
+			name, arg, hasArg = value[:i], value[i+1:], true
+		}
+		envValue, ok := lookup(name)
+
+		switch {
+		case strings.HasPrefix(arg, "-"):
+			if envValue == "" {
+				return arg[1:]
+			}
+		case strings.HasPrefix(arg, "+"):
+			if envValue != "" {
+				return arg[1:]
 			}
-			result = strings.ReplaceAll(result, whole, defaultVal)
+			return ""
+		case strings.HasPrefix(arg, "?"):
+			if envValue == "" && err == nil {
+				msg := arg[1:]
+				if msg == "" {
+					msg = "is not set"
+				}
+				err = fmt.Errorf("environment variable %s: %s", name, msg)
+			}
+		case hasArg && !ok:
+			return arg
 		}
-	}
+		return envValue
+	})
 	return result, err
 }
 
-func fromEnvironmentHookFunc() mapstructure.DecodeHookFunc {
+func fromEnvironmentHookFunc(lookup func(string) (string, bool)) mapstructure.DecodeHookFunc {
 	return func(
 		f reflect.Type,
 		t reflect.Type,
@@ -395,22 +935,74 @@ func fromEnvironmentHookFunc() mapstructure.DecodeHookFunc {
 			return data, nil
 		}
 
-		return replaceEnvironments(data.(string))
+		return replaceEnvironments(data.(string), lookup)
 	}
 }
 
 // processCfg processes a cfg struct after it has been loaded from
 // the config file, by validating required fields and setting defaults
-// where applicable.
-func (c *confucius) processCfg(cfg interface{}) error {
-	fields := flattenCfg(cfg, c.tag)
+// where applicable. The rules of the validate tags are checked once all
+// fields are processed, followed by structs implementing Validator.
+func (c *confucius) processCfg(cfg interface{}) (err error) {
+	end := c.startSpan(SpanProcess, nil)
+	defer func() { end(err) }()
+
+	defaultsStart := time.Now()
+	if c.fillNilStructs {
+		fillNilStructs(reflect.ValueOf(cfg), c.tag, c.tags)
+	}
+	c.applyBaseDefaults(reflect.ValueOf(cfg), "")
+
+	c.usedEnv = nil
+	fields := c.flatten(cfg)
 	errs := make(fieldErrors)
+	for c.growSlices(fields, errs) {
+		commitMapValues(fields)
+		fields = c.flatten(cfg)
+	}
+	c.track(StageDefaults, defaultsStart)
 
 	for _, field := range fields {
 		if err := c.processField(field); err != nil {
 			errs[field.path()] = err
 		}
 	}
+	commitMapValues(fields)
+	errs.merge("", c.decodeErrs)
+	c.unknownOverrides(fields, errs)
+	c.checkUnusedEnv()
+
+	validateStart := time.Now()
+	for _, field := range fields {
+		if _, ok := errs[field.path()]; ok {
+			continue
+		}
+		if err := checkRules(field); err != nil {
+			errs[field.path()] = err
+		}
+	}
+	c.checkWarnings(fields, errs)
+
+	c.validateStructs(&field{
+		v:        reflect.ValueOf(cfg).Elem(),
+		t:        reflect.ValueOf(cfg).Elem().Type(),
+		sliceIdx: -1,
+		tagKey:   c.tag,
+		tags:     c.tags,
+	}, errs)
+	c.track(StageValidate, validateStart)
+	redactSecrets(errs, fields)
+
+	if c.onEvent != nil {
+		paths := make([]string, 0, len(errs))
+		for path := range errs {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			c.emit(Event{Kind: EventInvalid, Path: path, Err: errs[path]})
+		}
+	}
 
 	if len(errs) > 0 {
 		return errs
@@ -419,6 +1011,106 @@ func (c *confucius) processCfg(cfg interface{}) error {
 	return nil
 }
 
+// flatten calls flattenCfg with the configured tag key and applies the
+// legacy tag syntax, if enabled.
+func (c *confucius) flatten(cfg interface{}) []*field {
+	fields := flattenCfgTags(cfg, c.tag, c.tags)
+	if c.legacyTags {
+		for _, f := range fields {
+			f.structTag.applyLegacy()
+		}
+	}
+	return fields
+}
+
+// growSlices instantiates the elements of the empty slices that have a
+// length hint and are absent from all sources, and grows the slices whose
+// elements are set by indexed environment variables, so that the defaults
+// and environment variables of their elements are applied. It reports
+// whether any slice was grown, in which case cfg has to be flattened again.
+func (c *confucius) growSlices(fields []*field, errs fieldErrors) (grown bool) {
+	for _, field := range fields {
+		if field.v.Kind() != reflect.Slice {
+			continue
+		}
+		if _, ok := errs[field.path()]; ok {
+			continue
+		}
+
+		n := field.v.Len()
+		if hint, ok := field.lenHint(); ok && n == 0 && !c.present[field.path()] {
+			hintLen, err := strconv.Atoi(hint)
+			if err != nil || hintLen < 0 {
+				errs[field.path()] = fmt.Errorf("invalid default length %q", hint)
+				continue
+			}
+			n = hintLen
+		}
+		if c.useEnv {
+			if envLen := c.envSliceLen(field.path()); envLen > n {
+				n = envLen
+			}
+		}
+		if n <= field.v.Len() {
+			continue
+		}
+
+		sv := reflect.MakeSlice(field.v.Type(), n, n)
+		reflect.Copy(sv, field.v)
+		if field.v.Type().Elem().Kind() == reflect.Ptr {
+			for i := field.v.Len(); i < n; i++ {
+				sv.Index(i).Set(reflect.New(field.v.Type().Elem().Elem()))
+			}
+		}
+		field.v.Set(sv)
+		grown = true
+	}
+	return grown
+}
+
+// envSliceLen returns the length of the slice with the given path that is
+// needed to hold the elements set by indexed environment variables in the
+// form PREFIX_PATH_IDX or PREFIX_PATH_IDX_FIELD.
+func (c *confucius) envSliceLen(path string) int {
+	prefix := c.formatEnvKey(path) + c.envSeparator
+	n := 0
+	for _, env := range os.Environ() {
+		key := env[:strings.Index(env, "=")]
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		idx := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(idx, c.envSeparator); i != -1 {
+			idx = idx[:i]
+		}
+		if i, err := strconv.Atoi(idx); err == nil && i >= 0 && i+1 > n {
+			n = i + 1
+		}
+	}
+	return n
+}
+
+// checkWarnings checks the warning-level rules of the fields that have no
+// errors. Failures are logged, emitted and kept for the report, but do not
+// fail loading.
+func (c *confucius) checkWarnings(fields []*field, errs fieldErrors) {
+	c.warnings = nil
+	for _, field := range fields {
+		path := field.path()
+		if _, ok := errs[path]; ok {
+			continue
+		}
+		warning := checkWarnRules(field)
+		if warning == nil {
+			continue
+		}
+		warning.Path = path
+		c.warnings = append(c.warnings, warning)
+		c.logger.Warn("%v", warning)
+		c.emit(Event{Kind: EventWarning, Path: path, Err: warning})
+	}
+}
+
 // processField processes a single field and is called by processCfg
 // for each field in cfg.
 func (c *confucius) processField(field *field) error {
@@ -426,47 +1118,260 @@ func (c *confucius) processField(field *field) error {
 		return fmt.Errorf("field cannot have both a required validation and a default value")
 	}
 
-	if c.useEnv {
-		if err := c.setFromEnv(field.v, field.path()); err != nil {
+	if err := c.setFromEnvAndFlags(field); err != nil {
+		return err
+	}
+
+	if err := c.setFromOverride(field); err != nil {
+		return err
+	}
+
+	if field.required && isZero(field.v) {
+		return &FieldError{Rule: "required", Err: ErrRequired}
+	}
+
+	if _, ok := field.lenHint(); ok {
+		return nil
+	}
+
+	if field.setDefault && !c.present[field.path()] && isZero(field.v) {
+		defer c.track(StageDefaults, time.Now())
+		if err := c.setDefaultValue(field.v, field.defaultVal, field.structTag); err != nil {
+			return &FieldError{
+				Rule:  "default",
+				Value: displayValue(field.defaultVal, field.structTag),
+				Err:   fmt.Errorf("%w: %v", ErrBadDefault, err),
+			}
+		}
+		c.setSource(field.path(), Source{Kind: SourceDefault}, displayValue(field.defaultVal, field.structTag))
+	}
+
+	return nil
+}
+
+// setFromEnvAndFlags sets a field from the environment, if enabled, and from
+// the command line flags.
+func (c *confucius) setFromEnvAndFlags(field *field) error {
+	defer c.track(StageEnv, time.Now())
+
+	if c.useEnv && !c.envShadowed[field.path()] {
+		if err := c.setFromEnv(field.v, field.path(), field.structTag); err != nil {
 			return fmt.Errorf("unable to set from env: %v", err)
 		}
 	}
 
-	if field.required && isZero(field.v) {
-		return fmt.Errorf("required validation failed")
+	if val, ok := c.flags[field.path()]; ok {
+		c.markPresent(field.path())
+		c.setSource(field.path(), Source{Kind: SourceFlag, Name: "-" + field.path()}, displayValue(val, field.structTag))
+		if err := c.setTaggedValue(field.v, val, field.structTag); err != nil {
+			return fmt.Errorf("unable to set from flag: %v", err)
+		}
+	}
+	return nil
+}
+
+func (c *confucius) setFromEnv(fv reflect.Value, key string, st structTag) error {
+	envKey := c.formatEnvKey(key)
+	if val, ok := os.LookupEnv(envKey); ok {
+		c.markEnvUsed(envKey)
+		c.markPresent(key)
+		c.setSource(key, Source{Kind: SourceEnv, Name: envKey}, displayValue(val, st))
+		return c.setTaggedValue(fv, val, st)
 	}
 
-	if field.setDefault && isZero(field.v) {
-		if err := c.setDefaultValue(field.v, field.defaultVal); err != nil {
-			return fmt.Errorf("unable to set default: %v", err)
+	for _, alias := range st.aliases {
+		aliasKey := c.formatEnvKey(alias)
+		if i := strings.LastIndex(key, "."); i != -1 {
+			aliasKey = c.formatEnvKey(key[:i+1] + alias)
+		}
+		if val, ok := os.LookupEnv(aliasKey); ok {
+			c.logger.Warn("%s: environment variable %s is deprecated, use %s instead", key, aliasKey, envKey)
+			c.markEnvUsed(aliasKey)
+			c.markPresent(key)
+			c.setSource(key, Source{Kind: SourceEnv, Name: aliasKey}, displayValue(val, st))
+			return c.setTaggedValue(fv, val, st)
 		}
 	}
 
+	switch fv.Kind() {
+	case reflect.Map:
+		return c.setMapFromEnv(fv, key, st)
+	case reflect.Slice:
+		switch fv.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+			// the elements are fields of their own.
+		default:
+			for i := 0; i < fv.Len(); i++ {
+				idxKey := fmt.Sprintf("%s[%d]", key, i)
+				if val, ok := os.LookupEnv(c.formatEnvKey(idxKey)); ok {
+					c.markEnvUsed(c.formatEnvKey(idxKey))
+					c.markPresent(key)
+					c.setSource(idxKey, Source{Kind: SourceEnv, Name: c.formatEnvKey(idxKey)}, displayValue(val, st))
+					if err := c.setTaggedValue(fv.Index(i), val, structTag{unit: st.unit, secret: st.secret}); err != nil {
+						return fmt.Errorf("%s: %v", idxKey, err)
+					}
+				}
+			}
+		}
+	}
 	return nil
 }
 
-func (c *confucius) setFromEnv(fv reflect.Value, key string) error {
-	key = c.formatEnvKey(key)
-	if val, ok := os.LookupEnv(key); ok {
-		return c.setValue(fv, val)
+// setMapFromEnv sets the entries of a map with string keys from environment
+// variables in the form PREFIX_PATH_KEY. Keys are lower-cased.
+func (c *confucius) setMapFromEnv(fv reflect.Value, key string, st structTag) error {
+	if fv.Type().Key().Kind() != reflect.String {
+		return nil
+	}
+	switch fv.Type().Elem().Kind() {
+	case reflect.Struct, reflect.Map, reflect.Interface:
+		return nil
+	}
+
+	prefix := c.formatEnvKey(key) + c.envSeparator
+	for _, env := range os.Environ() {
+		i := strings.Index(env, "=")
+		envKey, val := env[:i], env[i+1:]
+		if !strings.HasPrefix(envKey, prefix) || len(envKey) == len(prefix) {
+			continue
+		}
+
+		mapKey := strings.ToLower(strings.TrimPrefix(envKey, prefix))
+		elem := reflect.New(fv.Type().Elem()).Elem()
+		if err := c.setTaggedValue(elem, val, structTag{unit: st.unit, secret: st.secret}); err != nil {
+			return fmt.Errorf("%s: %v", envKey, err)
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.MakeMap(fv.Type()))
+		}
+		fv.SetMapIndex(reflect.ValueOf(mapKey).Convert(fv.Type().Key()), elem)
+		c.markEnvUsed(envKey)
+		c.markPresent(key)
+		c.setSource(fmt.Sprintf("%s[%s]", key, mapKey), Source{Kind: SourceEnv, Name: envKey}, displayValue(val, st))
 	}
 	return nil
 }
 
+// markEnvUsed records that the environment variable key set a field.
+func (c *confucius) markEnvUsed(key string) {
+	if c.usedEnv == nil {
+		c.usedEnv = make(map[string]bool)
+	}
+	c.usedEnv[key] = true
+}
+
+// checkUnusedEnv logs the environment variables with the prefix of UseEnv
+// that set no field and keeps them for the report, if ReportUnusedEnv is
+// set. The variables of DetectEnvironment are not reported.
+func (c *confucius) checkUnusedEnv() {
+	c.unusedEnv = nil
+	prefix := c.formatEnvKey("")
+	if !c.useEnv || !c.reportUnusedEnv || prefix == "" {
+		return
+	}
+
+	for _, env := range os.Environ() {
+		key := env[:strings.Index(env, "=")]
+		if !strings.HasPrefix(key, prefix) || c.usedEnv[key] || containsString(environmentVariables, key) {
+			continue
+		}
+		c.unusedEnv = append(c.unusedEnv, key)
+	}
+	sort.Strings(c.unusedEnv)
+	for _, key := range c.unusedEnv {
+		c.logger.Warn("environment variable %s matches no field", key)
+	}
+}
+
+// markPresent records that the field with the given path received a value
+// from a source.
+func (c *confucius) markPresent(path string) {
+	if c.present == nil {
+		c.present = make(map[string]bool)
+	}
+	c.present[path] = true
+}
+
 func (c *confucius) formatEnvKey(key string) string {
+	key = joinPath(c.subKey, key)
 	// loggers[0].level --> loggers_0_level
-	key = strings.NewReplacer(".", "_", "[", "_", "]", "").Replace(key)
-	if c.envPrefix != "" {
-		key = fmt.Sprintf("%s_%s", c.envPrefix, key)
+	key = strings.NewReplacer(".", c.envSeparator, "[", c.envSeparator, "]", "").Replace(key)
+	if prefix := c.resolveEnvPrefix(); prefix != "" {
+		key = prefix + c.envSeparator + key
 	}
 	return strings.ToUpper(key)
 }
 
-// setDefaultValue calls setValue but disallows booleans from
-// being set.
-func (c *confucius) setDefaultValue(fv reflect.Value, val string) error {
-	if fv.Kind() == reflect.Bool {
-		return fmt.Errorf("unsupported type: %v", fv.Kind())
+// resolveEnvPrefix returns the prefix of environment variables with the
+// `{profile}` placeholder replaced by the active profile, which is the last
+// profile. Without profiles the placeholder and the separators around it
+// are removed, so `myapp_{profile}` becomes `myapp`.
+func (c *confucius) resolveEnvPrefix() string {
+	if !strings.Contains(c.envPrefix, ProfilePlaceholder) {
+		return c.envPrefix
+	}
+	if len(c.profiles) > 0 {
+		return strings.ReplaceAll(c.envPrefix, ProfilePlaceholder, c.profiles[len(c.profiles)-1])
+	}
+
+	var parts []string
+	for _, part := range strings.Split(strings.ReplaceAll(c.envPrefix, ProfilePlaceholder, ""), c.envSeparator) {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, c.envSeparator)
+}
+
+// setDefaultValue calls setTaggedValue to set the default value of a field.
+// Defaults in the form `fn:name` are computed by the registered function.
+func (c *confucius) setDefaultValue(fv reflect.Value, val string, st structTag) error {
+	val, err := resolveDefault(val)
+	if err != nil {
+		return err
+	}
+	return c.setTaggedValue(fv, val, st)
+}
+
+// setTaggedValue calls setValue after interpreting val according to
+// the options of the field's tag (e.g. its unit). val is masked in the
+// errors of secret fields.
+func (c *confucius) setTaggedValue(fv reflect.Value, val string, st structTag) (err error) {
+	if st.secret {
+		raw := val
+		defer func() {
+			if err != nil {
+				err = &redactedError{err: err, values: []string{raw}, quoted: true}
+			}
+		}()
+	}
+
+	if isOptionalType(fv.Type()) && fv.CanAddr() {
+		fv = fv.Addr().Interface().(optionalTarget).target()
+	}
+
+	if st.unit != "" {
+		converted, err := convertUnit(val, st.unit, fv.Type())
+		if err != nil {
+			return err
+		}
+		val = converted.(string)
+	}
+
+	switch st.envMode {
+	case "", EnvModeReplace:
+	case EnvModeAppend, EnvModeMerge:
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Slice {
+			return c.growSlice(fv, val, st.envMode == EnvModeMerge)
+		}
+	default:
+		return fmt.Errorf("unknown envmode %q", st.envMode)
 	}
 	return c.setValue(fv, val)
 }
@@ -476,6 +1381,10 @@ func (c *confucius) setDefaultValue(fv reflect.Value, val string) error {
 // returned.
 // fv must be settable else this panics.
 func (c *confucius) setValue(fv reflect.Value, val string) error {
+	if ok, err := parseValue(fv, val); ok {
+		return err
+	}
+
 	switch fv.Kind() {
 	case reflect.Ptr:
 		if fv.IsNil() {
@@ -520,7 +1429,10 @@ func (c *confucius) setValue(fv reflect.Value, val string) error {
 		fv.SetFloat(f)
 	case reflect.String:
 		fv.SetString(val)
-	case reflect.Struct: // struct is only allowed a default in the special case where it's a time.Time
+	case reflect.Struct: // struct is only allowed a default if it is a url.URL, a net.IPNet or a time.Time
+		if ok, err := setNetValue(fv, val); ok {
+			return err
+		}
 		if _, ok := fv.Interface().(time.Time); ok {
 			t, err := time.Parse(c.timeLayout, val)
 			if err != nil {
@@ -551,3 +1463,34 @@ func (c *confucius) setSlice(sv reflect.Value, val string) error {
 	sv.Set(slice)
 	return nil
 }
+
+// growSlice appends the elements of val to sv instead of replacing sv.
+// If unique is true, elements that sv already contains are skipped.
+// sv must be settable else this panics.
+func (c *confucius) growSlice(sv reflect.Value, val string, unique bool) error {
+	elems := reflect.New(sv.Type()).Elem()
+	if err := c.setSlice(elems, val); err != nil {
+		return err
+	}
+
+	result := sv
+	for i := 0; i < elems.Len(); i++ {
+		elem := elems.Index(i)
+		if unique && containsValue(result, elem) {
+			continue
+		}
+		result = reflect.Append(result, elem)
+	}
+	sv.Set(result)
+	return nil
+}
+
+// containsValue reports whether the slice sv contains v.
+func containsValue(sv, v reflect.Value) bool {
+	for i := 0; i < sv.Len(); i++ {
+		if reflect.DeepEqual(sv.Index(i).Interface(), v.Interface()) {
+			return true
+		}
+	}
+	return false
+}
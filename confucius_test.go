@@ -5,6 +5,7 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -371,6 +372,8 @@ func Test_confucius_decodeFiles(t *testing.T) {
 func Test_confucius_replaceEnvironments(t *testing.T) {
 	os.Setenv("FOO", "XXX")
 	os.Setenv("BAR", "YYY")
+	os.Setenv("EMPTY", "")
+	defer os.Unsetenv("EMPTY")
 
 	tests := []struct {
 		name     string
@@ -385,11 +388,23 @@ func Test_confucius_replaceEnvironments(t *testing.T) {
 		{name: "environment when is not set and default value is missing", text: "/x/y/${BAZ:}", want: "/x/y/"},
 		{name: "environment name is missing", text: "/x/y/${}", hasError: true},
 		{name: "multiple environment names", text: "/x/y/${FOO}/z/${BAR}", want: "/x/y/XXX/z/YYY"},
+		{name: "escaped environment name", text: "/x/y/$${FOO}", want: "/x/y/${FOO}"},
+		{name: "default value containing a colon", text: "${BAZ:http://localhost:8080}", want: "http://localhost:8080"},
+		{name: "bash-style default when not set", text: "${BAZ:-a}", want: "a"},
+		{name: "bash-style default when empty", text: "${EMPTY:-a}", want: "a"},
+		{name: "bash-style default when set", text: "${FOO:-a}", want: "XXX"},
+		{name: "plain default when empty", text: "${EMPTY:a}", want: ""},
+		{name: "alternative when set", text: "${FOO:+alt}", want: "alt"},
+		{name: "alternative when empty", text: "${EMPTY:+alt}", want: ""},
+		{name: "alternative when not set", text: "${BAZ:+alt}", want: ""},
+		{name: "required when set", text: "${FOO:?FOO is required}", want: "XXX"},
+		{name: "escaped and replaced environment names", text: "{{ $${FOO} }} ${FOO}", want: "{{ ${FOO} }} XXX"},
+		{name: "escaped empty environment name", text: "$${}", want: "${}"},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			if result, err := replaceEnvironments(test.text); err != nil {
+			if result, err := replaceEnvironments(test.text, os.LookupEnv); err != nil {
 				if test.hasError && err == nil {
 					t.Error("not expected")
 				}
@@ -853,6 +868,40 @@ func Test_confucius_Load_Server_With_Profile_When_Config_Is_Invalid(t *testing.T
 	}
 }
 
+func Test_confucius_Load_OptionalProfiles(t *testing.T) {
+	type Server struct {
+		Host   string `conf:"host"`
+		Logger struct {
+			LogLevel string `conf:"log_level" default:"info"`
+		}
+	}
+
+	var cfg Server
+	err := Load(&cfg,
+		File("server.yaml"),
+		Dirs(filepath.Join("testdata", "valid")),
+		Profiles("test", "local"),
+		OptionalProfiles(),
+	)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "192.168.0.256" || cfg.Logger.LogLevel != "error" {
+		t.Errorf("cfg == %+v, expected the test profile to be merged", cfg)
+	}
+
+	err = Load(&cfg,
+		File("missing.yaml"),
+		Dirs(filepath.Join("testdata", "valid")),
+		Profiles("local"),
+		OptionalProfiles(),
+	)
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) || !reflect.DeepEqual(notFound.Files, []string{"missing.yaml"}) {
+		t.Errorf("err == %v, expected only the main file to be missing", err)
+	}
+}
+
 func Test_confucius_decodeFile(t *testing.T) {
 	confucius := defaultConfucius()
 
@@ -1189,7 +1238,7 @@ func Test_confucius_setFromEnv(t *testing.T) {
 	fv := reflect.ValueOf(&s)
 
 	os.Clearenv()
-	err := confucius.setFromEnv(fv, "config.string")
+	err := confucius.setFromEnv(fv, "config.string", structTag{})
 	if err != nil {
 		t.Fatalf("setFromEnv() unexpected error: %v", err)
 	}
@@ -1198,7 +1247,7 @@ func Test_confucius_setFromEnv(t *testing.T) {
 	}
 
 	setenv(t, "CONFUCIUS_CONFIG_STRING", "goroutine")
-	err = confucius.setFromEnv(fv, "config.string")
+	err = confucius.setFromEnv(fv, "config.string", structTag{})
 	if err != nil {
 		t.Fatalf("setFromEnv() unexpected error: %v", err)
 	}
@@ -1211,9 +1260,11 @@ func Test_confucius_formatEnvKey(t *testing.T) {
 	confucius := defaultConfucius()
 
 	for _, tc := range []struct {
-		key    string
-		prefix string
-		want   string
+		key       string
+		prefix    string
+		separator string
+		profiles  []string
+		want      string
 	}{
 		{
 			key:  "port",
@@ -1237,9 +1288,42 @@ func Test_confucius_formatEnvKey(t *testing.T) {
 			prefix: "auth_s",
 			want:   "AUTH_S_CLIENT_HTTP_TIMEOUT",
 		},
+		{
+			key:       "server.log_level",
+			prefix:    "myapp",
+			separator: "__",
+			want:      "MYAPP__SERVER__LOG_LEVEL",
+		},
+		{
+			key:       "loggers[0].level",
+			separator: "__",
+			want:      "LOGGERS__0__LEVEL",
+		},
+		{
+			key:      "port",
+			prefix:   "myapp_{profile}",
+			profiles: []string{"test", "canary"},
+			want:     "MYAPP_CANARY_PORT",
+		},
+		{
+			key:    "port",
+			prefix: "myapp_{profile}",
+			want:   "MYAPP_PORT",
+		},
+		{
+			key:       "server.port",
+			prefix:    "{profile}__myapp",
+			separator: "__",
+			want:      "MYAPP__SERVER__PORT",
+		},
 	} {
-		t.Run(fmt.Sprintf("%s/%s", tc.prefix, tc.key), func(t *testing.T) {
+		t.Run(fmt.Sprintf("%s/%s%s", tc.prefix, tc.separator, tc.key), func(t *testing.T) {
 			confucius.envPrefix = tc.prefix
+			confucius.profiles = tc.profiles
+			confucius.envSeparator = DefaultEnvSeparator
+			if tc.separator != "" {
+				confucius.envSeparator = tc.separator
+			}
 			got := confucius.formatEnvKey(tc.key)
 			if got != tc.want {
 				t.Errorf("formatEnvKey() == %s, expected %s", got, tc.want)
@@ -1253,9 +1337,12 @@ func Test_confucius_setDefaultValue(t *testing.T) {
 	var b bool
 	fv := reflect.ValueOf(&b).Elem()
 
-	err := confucius.setDefaultValue(fv, "true")
-	if err == nil {
-		t.Fatalf("expected err")
+	err := confucius.setDefaultValue(fv, "true", structTag{})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !b {
+		t.Errorf("b == false, expected true")
 	}
 }
 
@@ -1582,3 +1669,570 @@ func setenv(t *testing.T, key, value string) {
 		t.Fatalf("os.Setenv() unexpected error: %v", err)
 	}
 }
+
+func Test_confucius_Load_EnvMode(t *testing.T) {
+	type Config struct {
+		Hosts   []string `conf:"hosts"`
+		Appends []string `conf:"appends,envmode=append"`
+		Merges  *[]int   `conf:"merges,envmode=merge"`
+		Empty   []string `conf:"empty,envmode=append" default:"[a,b]"`
+		Bad     []string `conf:"bad,envmode=prepend"`
+	}
+
+	os.Clearenv()
+	setenv(t, "APP_HOSTS", "[c,d]")
+	setenv(t, "APP_APPENDS", "[b,c]")
+	setenv(t, "APP_MERGES", "[2,3]")
+	defer os.Clearenv()
+
+	var cfg Config
+	err := Load(&cfg, UseEnv("app"), String(`{"hosts": ["a", "b"], "appends": ["a", "b"], "merges": [1, 2]}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if want := []string{"c", "d"}; !reflect.DeepEqual(want, cfg.Hosts) {
+		t.Errorf("cfg.Hosts == %v, expected %v", cfg.Hosts, want)
+	}
+	if want := []string{"a", "b", "b", "c"}; !reflect.DeepEqual(want, cfg.Appends) {
+		t.Errorf("cfg.Appends == %v, expected %v", cfg.Appends, want)
+	}
+	if want := []int{1, 2, 3}; cfg.Merges == nil || !reflect.DeepEqual(want, *cfg.Merges) {
+		t.Errorf("cfg.Merges == %v, expected %v", cfg.Merges, want)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(want, cfg.Empty) {
+		t.Errorf("cfg.Empty == %v, expected %v", cfg.Empty, want)
+	}
+
+	setenv(t, "APP_BAD", "[x]")
+	err = Load(&cfg, UseEnv("app"), String(`{}`, DecoderJSON))
+	if err == nil || !strings.Contains(err.Error(), "unknown envmode") {
+		t.Fatalf("expected unknown envmode err, got %v", err)
+	}
+}
+
+func Test_confucius_Load_Lenient(t *testing.T) {
+	type Config struct {
+		Host    string        `conf:"host" validate:"required"`
+		Port    int           `conf:"port"`
+		Timeout time.Duration `conf:"timeout"`
+		Level   string        `conf:"level" default:"info"`
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("port: 8080\ntimeout: soon\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.bad.yaml"), []byte("port: [\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var cfg Config
+	err := Load(&cfg, Dirs(dir), Profiles("bad", "missing"), Lenient())
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected *LoadError, got %T", err)
+	}
+	if loadErr.Config != &cfg {
+		t.Errorf("loadErr.Config == %p, expected %p", loadErr.Config, &cfg)
+	}
+	if len(loadErr.Errors) != 3 {
+		t.Errorf("expected 3 errors, got %d: %v", len(loadErr.Errors), loadErr)
+	}
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected err to wrap %v", ErrFileNotFound)
+	}
+	var fieldErrs fieldErrors
+	if !errors.As(err, &fieldErrs) || fieldErrs["host"] == nil || fieldErrs["timeout"] == nil {
+		t.Errorf("expected host and timeout field errors, got %v", err)
+	}
+
+	if cfg.Port != 8080 || cfg.Level != "info" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	cfg = Config{}
+	err = Load(&cfg, Dirs(dir), Profiles("bad"))
+	if err == nil || errors.As(err, &loadErr) {
+		t.Fatalf("expected plain err, got %v", err)
+	}
+}
+
+func Test_confucius_Load_DefaultsOnlyForAbsentFields(t *testing.T) {
+	type Config struct {
+		Enabled  bool   `conf:"enabled" default:"true"`
+		Verbose  bool   `conf:"verbose" default:"true"`
+		Retries  int    `conf:"retries" default:"3"`
+		Workers  int    `conf:"workers" default:"4"`
+		Name     string `conf:"name" default:"app"`
+		Replicas int    `conf:"replicas" default:"2"`
+		Items    []struct {
+			Weight int `conf:"weight" default:"10"`
+		} `conf:"items"`
+	}
+
+	setenv(t, "APP_WORKERS", "0")
+	defer os.Unsetenv("APP_WORKERS")
+
+	cfg := Config{Replicas: 5}
+	err := Load(&cfg, UseEnv("app"), String(`
+verbose: false
+retries: 0
+name: null
+items:
+  - weight: 0
+  - {}
+`, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if !cfg.Enabled {
+		t.Errorf("cfg.Enabled == false, expected default true")
+	}
+	if cfg.Verbose {
+		t.Errorf("cfg.Verbose == true, expected explicit false")
+	}
+	if cfg.Retries != 0 {
+		t.Errorf("cfg.Retries == %d, expected explicit 0", cfg.Retries)
+	}
+	if cfg.Workers != 0 {
+		t.Errorf("cfg.Workers == %d, expected 0 from env", cfg.Workers)
+	}
+	if cfg.Name != "app" {
+		t.Errorf("cfg.Name == %q, expected default for null value", cfg.Name)
+	}
+	if cfg.Replicas != 5 {
+		t.Errorf("cfg.Replicas == %d, expected pre-populated 5", cfg.Replicas)
+	}
+	if len(cfg.Items) != 2 || cfg.Items[0].Weight != 0 || cfg.Items[1].Weight != 10 {
+		t.Errorf("cfg.Items == %+v, expected [{0} {10}]", cfg.Items)
+	}
+}
+
+func Test_confucius_Load_DefaultLen(t *testing.T) {
+	type Worker struct {
+		Queue       string `conf:"queue" default:"jobs"`
+		Concurrency int    `conf:"concurrency" default:"4"`
+		Tags        []struct {
+			Name string `conf:"name" default:"tag"`
+		} `conf:"tags" defaultLen:"1"`
+	}
+	type Config struct {
+		Workers  []Worker  `conf:"workers" defaultLen:"2"`
+		Pointers []*Worker `conf:"pointers" default:"1"`
+		Empty    []Worker  `conf:"empty" defaultLen:"2"`
+	}
+
+	setenv(t, "APP_WORKERS_1_QUEUE", "emails")
+	defer os.Unsetenv("APP_WORKERS_1_QUEUE")
+
+	var cfg Config
+	if err := Load(&cfg, String(`{"empty": []}`, DecoderJSON), UseEnv("app")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if len(cfg.Workers) != 2 {
+		t.Fatalf("expected 2 workers, got %+v", cfg.Workers)
+	}
+	if cfg.Workers[0].Queue != "jobs" || cfg.Workers[1].Queue != "emails" || cfg.Workers[1].Concurrency != 4 {
+		t.Errorf("unexpected workers: %+v", cfg.Workers)
+	}
+	if len(cfg.Workers[0].Tags) != 1 || cfg.Workers[0].Tags[0].Name != "tag" {
+		t.Errorf("unexpected nested slice: %+v", cfg.Workers[0].Tags)
+	}
+	if len(cfg.Pointers) != 1 || cfg.Pointers[0].Queue != "jobs" {
+		t.Errorf("unexpected pointers: %+v", cfg.Pointers)
+	}
+	if len(cfg.Empty) != 0 {
+		t.Errorf("expected slice set in the file to be kept, got %+v", cfg.Empty)
+	}
+
+	var bad struct {
+		Workers []Worker `conf:"workers" defaultLen:"two"`
+	}
+	err := Load(&bad, String(`{}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["workers"] == nil {
+		t.Errorf("expected workers err, got %v", err)
+	}
+}
+
+func Test_confucius_Load_EnvSeparator(t *testing.T) {
+	type Config struct {
+		LogLevel string `conf:"log_level"`
+		Log      struct {
+			Level string `conf:"level"`
+		} `conf:"log"`
+	}
+
+	setenv(t, "MYAPP__LOG_LEVEL", "debug")
+	setenv(t, "MYAPP__LOG__LEVEL", "warn")
+	defer os.Unsetenv("MYAPP__LOG_LEVEL")
+	defer os.Unsetenv("MYAPP__LOG__LEVEL")
+
+	var cfg Config
+	if err := Load(&cfg, String(`{}`, DecoderJSON), UseEnv("myapp"), EnvSeparator("__")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.LogLevel != "debug" || cfg.Log.Level != "warn" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+}
+
+func Test_confucius_Load_EnvMapsAndSlices(t *testing.T) {
+	type Worker struct {
+		Queue       string `conf:"queue" default:"jobs"`
+		Concurrency int    `conf:"concurrency" default:"4"`
+	}
+	type Config struct {
+		Labels   map[string]string        `conf:"labels"`
+		Limits   map[string]int           `conf:"limits"`
+		Timeouts map[string]time.Duration `conf:"timeouts"`
+		Hosts    []string                 `conf:"hosts"`
+		Workers  []Worker                 `conf:"workers"`
+		Pointers []*Worker                `conf:"pointers"`
+	}
+
+	for key, val := range map[string]string{
+		"APP_LABELS_TEAM":         "payments",
+		"APP_LABELS_COST_CENTER":  "42",
+		"APP_LIMITS_CPU":          "2",
+		"APP_TIMEOUTS_READ":       "5s",
+		"APP_HOSTS_1":             "b",
+		"APP_WORKERS_1_QUEUE":     "emails",
+		"APP_POINTERS_0_QUEUE":    "reports",
+		"APP_WORKERS_X_QUEUE":     "ignored",
+		"APP_WORKERSS_3_QUEUE":    "ignored",
+		"APP_POINTERS_0_PRIORITY": "ignored",
+	} {
+		setenv(t, key, val)
+		defer os.Unsetenv(key)
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"labels": {"env": "prod", "team": "core"}, "hosts": ["a"]}`, DecoderJSON), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := Config{
+		Labels:   map[string]string{"env": "prod", "team": "payments", "cost_center": "42"},
+		Limits:   map[string]int{"cpu": 2},
+		Timeouts: map[string]time.Duration{"read": 5 * time.Second},
+		Hosts:    []string{"a", "b"},
+		Workers:  []Worker{{Queue: "jobs", Concurrency: 4}, {Queue: "emails", Concurrency: 4}},
+		Pointers: []*Worker{{Queue: "reports", Concurrency: 4}},
+	}
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+}
+
+func Test_confucius_Load_DisableExpansion(t *testing.T) {
+	type Config struct {
+		Template string `conf:"template"`
+		Script   string `conf:"script"`
+	}
+
+	setenv(t, "HOST", "example.com")
+	defer os.Unsetenv("HOST")
+
+	content := `{"template": "{{ $${HOST} }} on ${HOST}", "script": "echo ${HOST}"}`
+
+	var cfg Config
+	if err := Load(&cfg, String(content, DecoderJSON)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Template != "{{ ${HOST} }} on example.com" || cfg.Script != "echo example.com" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	cfg = Config{}
+	if err := Load(&cfg, String(content, DecoderJSON), DisableExpansion()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Template != "{{ $${HOST} }} on ${HOST}" || cfg.Script != "echo ${HOST}" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+}
+
+func Test_confucius_Load_Tags(t *testing.T) {
+	type Server struct {
+		Host string `fig:"host"`
+		Port int    `fig:"port" default:"8080"`
+	}
+	type Config struct {
+		Name    string   `conf:"name" fig:"ignored"`
+		Servers []Server `fig:"servers"`
+		Log     struct {
+			Level string `fig:"log_level" default:"info"`
+		} `fig:"logging"`
+	}
+
+	setenv(t, "APP_LOGGING_LOG_LEVEL", "debug")
+	defer os.Unsetenv("APP_LOGGING_LOG_LEVEL")
+
+	content := `{"name": "app", "servers": [{"host": "a", "port": 0}, {"host": "b"}]}`
+
+	var cfg Config
+	if err := Load(&cfg, String(content, DecoderJSON), Tags("conf", "fig"), UseEnv("app")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := Config{Name: "app", Servers: []Server{{Host: "a"}, {Host: "b", Port: 8080}}}
+	want.Log.Level = "debug"
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+}
+
+func Test_confucius_Load_LegacyFigTags(t *testing.T) {
+	type Config struct {
+		Host    string   `fig:"host,required"`
+		Port    int      `fig:"port,default=8080"`
+		Tags    []string `fig:"tags,default=[a,b]"`
+		Workers int      `fig:"workers,default=2" default:"4"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"host": "localhost"}`, DecoderJSON), Tag("fig"), LegacyFigTags())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := Config{Host: "localhost", Port: 8080, Tags: []string{"a", "b"}, Workers: 4}
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+
+	err = Load(&Config{}, String(`{}`, DecoderJSON), Tag("fig"), LegacyFigTags())
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["host"] == nil {
+		t.Errorf("expected required error for host, got %v", err)
+	}
+
+	cfg = Config{}
+	if err := Load(&cfg, String(`{}`, DecoderJSON), Tag("fig")); err != nil {
+		t.Fatalf("unexpected err without LegacyFigTags: %v", err)
+	}
+	if cfg.Port != 0 {
+		t.Errorf("expected legacy default to be ignored, got %d", cfg.Port)
+	}
+}
+
+func Test_confucius_Load_Maps(t *testing.T) {
+	type Tenant struct {
+		Name    string        `conf:"name" validate:"required"`
+		Timeout time.Duration `conf:"timeout,unit=s" default:"30s"`
+		Workers int           `conf:"workers" default:"2"`
+	}
+	type Config struct {
+		Tenants  map[string]Tenant      `conf:"tenants"`
+		Pointers map[string]*Tenant     `conf:"pointers"`
+		Limits   map[string]int         `conf:"limits"`
+		Extra    map[string]interface{} `conf:"extra"`
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":      "tenants:\n  acme:\n    name: Acme\n    workers: 0\n  globex:\n    name: Globex\n    timeout: 5\nlimits:\n  acme: 10\nextra:\n  nested:\n    key: value\n",
+		"config.prod.yaml": "tenants:\n  globex:\n    workers: 8\n  initech:\n    name: Initech\npointers:\n  acme:\n    name: Acme\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	setenv(t, "APP_TENANTS_ACME_TIMEOUT", "1m")
+	defer os.Unsetenv("APP_TENANTS_ACME_TIMEOUT")
+	setenv(t, "APP_LIMITS_GLOBEX", "20")
+	defer os.Unsetenv("APP_LIMITS_GLOBEX")
+
+	var cfg Config
+	if err := Load(&cfg, Dirs(dir), Profiles("prod"), UseEnv("app")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	wantTenants := map[string]Tenant{
+		"acme":    {Name: "Acme", Timeout: time.Minute, Workers: 0},
+		"globex":  {Name: "Globex", Timeout: 5 * time.Second, Workers: 8},
+		"initech": {Name: "Initech", Timeout: 30 * time.Second, Workers: 2},
+	}
+	if !reflect.DeepEqual(wantTenants, cfg.Tenants) {
+		t.Errorf("\nwant %+v\ngot  %+v", wantTenants, cfg.Tenants)
+	}
+	if p := cfg.Pointers["acme"]; p == nil || p.Timeout != 30*time.Second || p.Workers != 2 {
+		t.Errorf("unexpected pointers: %+v", p)
+	}
+	if want := map[string]int{"acme": 10, "globex": 20}; !reflect.DeepEqual(want, cfg.Limits) {
+		t.Errorf("want limits %v, got %v", want, cfg.Limits)
+	}
+	if nested, ok := cfg.Extra["nested"].(map[string]interface{}); !ok || nested["key"] != "value" {
+		t.Errorf("unexpected extra: %#v", cfg.Extra)
+	}
+
+	err := Load(&Config{}, String(`{"tenants": {"acme": {"workers": 1}}}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["tenants[acme].name"] == nil {
+		t.Errorf("expected required error for tenants[acme].name, got %v", err)
+	}
+}
+
+func Test_confucius_Load_WithDecodeHook(t *testing.T) {
+	type Color struct {
+		R, G, B uint8
+	}
+	type Config struct {
+		Color Color  `conf:"color"`
+		Name  string `conf:"name"`
+	}
+
+	hook := func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(Color{}) {
+			return data, nil
+		}
+		var c Color
+		if _, err := fmt.Sscanf(data.(string), "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	upper := func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if s, ok := data.(string); ok && t.Kind() == reflect.String {
+			return strings.ToUpper(s), nil
+		}
+		return data, nil
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"color": "#ff8000", "name": "app"}`, DecoderJSON), WithDecodeHook(hook), WithDecodeHook(upper))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Color != (Color{255, 128, 0}) || cfg.Name != "APP" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 1
+	case "info":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func Test_confucius_Load_TextUnmarshaler(t *testing.T) {
+	type Config struct {
+		Level   testLevel   `conf:"level" default:"info"`
+		Levels  []testLevel `conf:"levels"`
+		IP      net.IP      `conf:"ip"`
+		Gateway *net.IP     `conf:"gateway"`
+		Bind    net.IP      `conf:"bind" default:"0.0.0.0"`
+	}
+
+	setenv(t, "APP_GATEWAY", "10.0.0.1")
+	defer os.Unsetenv("APP_GATEWAY")
+
+	var cfg Config
+	err := Load(&cfg, String(`{"levels": ["debug", "info"], "ip": "192.168.1.1"}`, DecoderJSON), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Level != 2 || !reflect.DeepEqual(cfg.Levels, []testLevel{1, 2}) {
+		t.Errorf("unexpected levels: %v %v", cfg.Level, cfg.Levels)
+	}
+	if cfg.IP.String() != "192.168.1.1" || cfg.Gateway == nil || cfg.Gateway.String() != "10.0.0.1" || cfg.Bind.String() != "0.0.0.0" {
+		t.Errorf("unexpected ips: %v %v %v", cfg.IP, cfg.Gateway, cfg.Bind)
+	}
+
+	err = Load(&Config{}, String(`{"level": "verbose"}`, DecoderJSON))
+	if err == nil || !strings.Contains(err.Error(), `unknown level "verbose"`) {
+		t.Errorf("expected unknown level error, got %v", err)
+	}
+}
+
+func Test_confucius_replaceEnvironments_Required(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		if key == "EMPTY" {
+			return "", true
+		}
+		return "", false
+	}
+
+	for text, want := range map[string]string{
+		"${DB_PASSWORD:?must be set for production}": "environment variable DB_PASSWORD: must be set for production",
+		"${EMPTY:?}": "environment variable EMPTY: is not set",
+	} {
+		_, err := replaceEnvironments(text, lookup)
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: expected err %q, got %v", text, want, err)
+		}
+	}
+}
+
+func Test_confucius_Load_RequiredEnvironment(t *testing.T) {
+	type Config struct {
+		Password string `conf:"password"`
+	}
+
+	os.Unsetenv("CONFUCIUS_TEST_PASSWORD")
+	err := Load(&Config{}, String(`{"password": "${CONFUCIUS_TEST_PASSWORD:?is required}"}`, DecoderJSON))
+	if err == nil || !strings.Contains(err.Error(), "CONFUCIUS_TEST_PASSWORD: is required") {
+		t.Errorf("unexpected err: %v", err)
+	}
+}
+
+func Test_confucius_Load_RequireFileMode(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, []byte("name: app\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	type Config struct {
+		Name string `conf:"name"`
+	}
+
+	for _, tc := range []struct {
+		mode    os.FileMode
+		wantErr bool
+	}{
+		{mode: 0600},
+		{mode: 0400},
+		{mode: 0640, wantErr: true},
+		{mode: 0644, wantErr: true},
+	} {
+		if err := os.Chmod(file, tc.mode); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		var cfg Config
+		err := Load(&cfg, Dirs(dir), RequireFileMode(0600))
+		if tc.wantErr != errors.Is(err, ErrInsecureFileMode) {
+			t.Errorf("%04o: err == %v, expected ErrInsecureFileMode %v", tc.mode, err, tc.wantErr)
+		}
+
+		var warnings []string
+		cfg = Config{}
+		err = Load(&cfg, Dirs(dir), WarnFileMode(0600), Logger(
+			SetLevel(WarningLevel),
+			Callback(func(level LogLevel, message, file string, line int) {
+				warnings = append(warnings, message)
+			}),
+		))
+		if err != nil || cfg.Name != "app" {
+			t.Errorf("%04o: err == %v, cfg == %+v, expected the file to load with a warning", tc.mode, err, cfg)
+		}
+		if tc.wantErr != (len(warnings) == 1) {
+			t.Errorf("%04o: warnings == %q", tc.mode, warnings)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package confucius
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule interface {
+	// Next returns the next activation time, later than the given time.
+	Next(time.Time) time.Time
+}
+
+// CronParser parses cron expressions into schedules.
+type CronParser interface {
+	Parse(spec string) (Schedule, error)
+}
+
+// DefaultCronParser is the parser used for CronSpec fields and the `cron`
+// validation rule. It accepts the standard five field expressions as well
+// as descriptors such as `@daily` and `@every 1h`. It may be replaced to
+// support other cron dialects.
+var DefaultCronParser CronParser = standardCronParser{}
+
+type standardCronParser struct{}
+
+func (standardCronParser) Parse(spec string) (Schedule, error) {
+	return cron.ParseStandard(spec)
+}
+
+// CronSpec is a cron expression that is parsed when the config is loaded,
+// so that typos in schedules fail at startup.
+//
+//   type Config struct {
+//     Cleanup confucius.CronSpec `conf:"cleanup" default:"*/5 * * * *"`
+//   }
+type CronSpec struct {
+	Spec     string
+	Schedule Schedule
+}
+
+// Next returns the next activation time of the schedule, later than t. It
+// returns the zero time if the spec was not set.
+func (c CronSpec) Next(t time.Time) time.Time {
+	if c.Schedule == nil {
+		return time.Time{}
+	}
+	return c.Schedule.Next(t)
+}
+
+// String returns the cron expression.
+func (c CronSpec) String() string {
+	return c.Spec
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c CronSpec) MarshalText() ([]byte, error) {
+	return []byte(c.Spec), nil
+}
+
+func (c *CronSpec) parse(s string) error {
+	s = strings.TrimSpace(s)
+	schedule, err := DefaultCronParser.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression %q: %v", s, err)
+	}
+	*c = CronSpec{Spec: s, Schedule: schedule}
+	return nil
+}
+
+// cronRule fails if a string field does not hold a valid cron expression.
+// Empty strings are left to the required rule.
+func cronRule(f *field, _ string) error {
+	s := formatValue(f.v)
+	if s == "" {
+		return nil
+	}
+	if _, err := DefaultCronParser.Parse(s); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %v", s, err)
+	}
+	return nil
+}
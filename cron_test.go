@@ -0,0 +1,126 @@
+package confucius
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCronSpec_parse(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		wantErr bool
+	}{
+		{in: "*/5 * * * *"},
+		{in: "0 3 * * MON-FRI"},
+		{in: "@daily"},
+		{in: "@every 90s"},
+		{in: "*/5 * * *", wantErr: true},
+		{in: "61 * * * *", wantErr: true},
+		{in: "", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			var c CronSpec
+			err := c.parse(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if c.Spec != tc.in || c.Schedule == nil {
+				t.Errorf("unexpected spec: %+v", c)
+			}
+		})
+	}
+}
+
+func TestCronSpec_Next(t *testing.T) {
+	if next := (CronSpec{}).Next(time.Now()); !next.IsZero() {
+		t.Errorf("Next() == %v, expected the zero time for an unset spec", next)
+	}
+
+	var c CronSpec
+	if err := c.parse("0 3 * * *"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	now := time.Date(2020, 1, 9, 12, 0, 0, 0, time.UTC)
+	if next, want := c.Next(now), time.Date(2020, 1, 10, 3, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("Next() == %v, expected %v", next, want)
+	}
+}
+
+func Test_confucius_Load_Cron(t *testing.T) {
+	type Config struct {
+		Cleanup CronSpec  `conf:"cleanup" default:"*/5 * * * *"`
+		Backup  *CronSpec `conf:"backup"`
+		Report  CronSpec  `conf:"report"`
+		Raw     string    `conf:"raw" validate:"cron"`
+	}
+
+	setenv(t, "APP_REPORT", "0 9 * * 1")
+	defer os.Unsetenv("APP_REPORT")
+
+	var cfg Config
+	err := Load(&cfg, UseEnv("app"), String(`{"backup": "@daily", "raw": "0 0 1 * *"}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	now := time.Date(2020, 1, 1, 10, 1, 0, 0, time.UTC)
+	if got, want := cfg.Cleanup.Next(now), time.Date(2020, 1, 1, 10, 5, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("cfg.Cleanup.Next() == %v, expected %v", got, want)
+	}
+	if cfg.Backup == nil || cfg.Backup.Spec != "@daily" {
+		t.Errorf("cfg.Backup == %+v", cfg.Backup)
+	}
+	if cfg.Report.Spec != "0 9 * * 1" {
+		t.Errorf("cfg.Report == %+v", cfg.Report)
+	}
+
+	err = Load(&cfg, String(`{"backup": "@sometimes"}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+
+	err = Load(&cfg, String(`{"raw": "* * *"}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["raw"] == nil {
+		t.Fatalf("expected raw err, got %v", err)
+	}
+}
+
+func Test_confucius_Load_CronEnv(t *testing.T) {
+	type Config struct {
+		Cleanup CronSpec `conf:"cleanup" default:"*/5 * * * *"`
+	}
+
+	setenv(t, "APP_CLEANUP_SPEC", "@daily")
+	defer os.Unsetenv("APP_CLEANUP_SPEC")
+	setenv(t, "APP_CLEANUP_SCHEDULE_SECOND", "1")
+	defer os.Unsetenv("APP_CLEANUP_SCHEDULE_SECOND")
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg, String(`{}`, DecoderJSON), UseEnv("app"), ReportUnusedEnv())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Cleanup.Spec != "*/5 * * * *" {
+		t.Errorf("cfg.Cleanup == %+v", cfg.Cleanup)
+	}
+	for _, key := range []string{"APP_CLEANUP_SPEC", "APP_CLEANUP_SCHEDULE_SECOND"} {
+		if !containsString(report.UnusedEnv, key) {
+			t.Errorf("report.UnusedEnv == %v, expected %s", report.UnusedEnv, key)
+		}
+	}
+
+	defs, err := FlagDefs(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(defs) != 1 || defs[0].Name != "cleanup" {
+		t.Errorf("defs == %+v, expected only cleanup", defs)
+	}
+}
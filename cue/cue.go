@@ -0,0 +1,66 @@
+// Package cue decodes confucius config files written in CUE
+// (https://cuelang.org). The files are evaluated and their constraints
+// enforced before they are decoded into the config struct:
+//
+//   port: int & >1024
+//   port: 8080
+//   host: *"localhost" | string
+//
+//   err := confucius.Load(&cfg, confucius.File("config.cue"), confcue.WithCUE())
+//
+// Fields that are left without a concrete value, other than the defaults
+// marked with `*`, fail to load.
+package cue
+
+import (
+	"io"
+	"io/ioutil"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"github.com/hasanozgan/confucius"
+)
+
+// Decoder is the decoder of CUE files and readers.
+const Decoder = confucius.Decoder(".cue")
+
+// WithCUE returns an option that decodes the files with the .cue extension,
+// and the readers set with Decoder, as CUE.
+func WithCUE() confucius.Option {
+	return confucius.WithDecoder(Decoder, decode)
+}
+
+// decode evaluates the CUE document read from r and returns its concrete
+// value. Errors are reported at the position of their first cause.
+func decode(r io.Reader) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	v := cuecontext.New().CompileBytes(b)
+	if err := v.Validate(cue.Concrete(true)); err != nil {
+		return nil, decodeError(err)
+	}
+	var root interface{}
+	if err := v.Decode(&root); err != nil {
+		return nil, decodeError(err)
+	}
+	return root, nil
+}
+
+// decodeError wraps err in a *confucius.DecodeError with the line and
+// column of its first position, if any.
+func decodeError(err error) error {
+	de := &confucius.DecodeError{Err: err}
+	for _, e := range errors.Errors(err) {
+		for _, pos := range errors.Positions(e) {
+			if pos.IsValid() {
+				de.Line, de.Column = pos.Line(), pos.Column()
+				return de
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package cue
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hasanozgan/confucius"
+)
+
+func Test_WithCUE(t *testing.T) {
+	type Config struct {
+		Host string         `conf:"host"`
+		Port int            `conf:"port"`
+		Tags []string       `conf:"tags"`
+		DB   map[string]int `conf:"db"`
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.cue":     "port: int & >1024\nport: 8080\nhost: *\"localhost\" | string\ntags: [\"a\", \"b\"]\ndb: pool: 4\n",
+		"invalid.cue":    "port: int & >1024\nport: 80\n",
+		"incomplete.cue": "port: int\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	var cfg Config
+	if err := confucius.Load(&cfg, confucius.File("config.cue"), confucius.Dirs(dir), WithCUE()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "localhost" || cfg.Port != 8080 || len(cfg.Tags) != 2 || cfg.DB["pool"] != 4 {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	cfg = Config{}
+	if err := confucius.Load(&cfg, confucius.String(`host: "example.com"`, Decoder), WithCUE()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "example.com" {
+		t.Errorf("cfg.Host == %q, expected example.com", cfg.Host)
+	}
+
+	for _, name := range []string{"invalid.cue", "incomplete.cue"} {
+		err := confucius.Load(&cfg, confucius.File(name), confucius.Dirs(dir), WithCUE())
+		var decodeErr *confucius.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("%s: expected a DecodeError, got %v", name, err)
+		}
+		if decodeErr.Line != 1 {
+			t.Errorf("%s: line == %d, expected 1", name, decodeErr.Line)
+		}
+	}
+}
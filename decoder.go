@@ -1,10 +1,18 @@
 package confucius
 
+import "io"
+
 type Decoder string
 
 const (
-	DecoderYaml Decoder = Decoder(".yaml")
-	DecoderYml          = Decoder(".yml")
-	DecoderJSON         = Decoder(".json")
-	DecoderToml         = Decoder(".toml")
+	DecoderYaml    Decoder = Decoder(".yaml")
+	DecoderYml             = Decoder(".yml")
+	DecoderJSON            = Decoder(".json")
+	DecoderJSONC           = Decoder(".jsonc") // JSON with comments and trailing commas.
+	DecoderToml            = Decoder(".toml")
+	DecoderMsgpack         = Decoder(".msgpack") // MessagePack, e.g. for Bytes.
 )
+
+// decodeFunc decodes a document and returns its root as maps, lists and
+// scalars, e.g. a decoder set with WithDecoder.
+type decodeFunc func(r io.Reader) (interface{}, error)
@@ -0,0 +1,101 @@
+package confucius
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// decodeLines decodes lines of key=value pairs, failing on other lines.
+func decodeLines(r io.Reader) (interface{}, error) {
+	vals := make(map[string]interface{})
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		key, val, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			return nil, &DecodeError{Line: line, Column: 1, Err: errors.New("expected key=value")}
+		}
+		vals[key] = val
+	}
+	return vals, scanner.Err()
+}
+
+func Test_confucius_Load_WithDecoder(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.ini"), []byte("host=example.com\nport=8080\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.ini"), []byte("host=example.com\nport\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var cfg Config
+	err := Load(&cfg, File("config.ini"), Dirs(dir))
+	if !errors.Is(err, ErrUnsupportedExtension) {
+		t.Fatalf("err == %v, expected ErrUnsupportedExtension", err)
+	}
+
+	ini := WithDecoder(".ini", decodeLines)
+	if err := Load(&cfg, File("config.ini"), Dirs(dir), ini); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "example.com" || cfg.Port != 8080 {
+		t.Errorf("cfg == %+v", cfg)
+	}
+
+	cfg = Config{}
+	if err := Load(&cfg, String("host=localhost", ".ini"), ini); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "localhost" {
+		t.Errorf("cfg.Host == %q, expected localhost", cfg.Host)
+	}
+
+	err = Load(&cfg, File("broken.ini"), Dirs(dir), ini)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a DecodeError, got %v", err)
+	}
+	if want := filepath.Join(dir, "broken.ini") + ":2:1: expected key=value"; err.Error() != want {
+		t.Errorf("err == %q, expected %q", err.Error(), want)
+	}
+}
+
+func Test_confucius_Load_FileDecoder(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"settings.conf":      "host: example.com\nport: 80\n",
+		"settings.prod.conf": "port: 443\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	var cfg Config
+	err := Load(&cfg, File("settings.conf"), Dirs(dir))
+	if !errors.Is(err, ErrUnsupportedExtension) {
+		t.Fatalf("err == %v, expected ErrUnsupportedExtension", err)
+	}
+
+	if err := Load(&cfg, File("settings.conf", DecoderYaml), Dirs(dir), Profiles("prod")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if want := (Config{Host: "example.com", Port: 443}); cfg != want {
+		t.Errorf("cfg == %+v, expected %+v", cfg, want)
+	}
+}
@@ -0,0 +1,39 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// encryptedValue matches inline encrypted values, e.g. `ENC[age:...]`.
+var encryptedValue = regexp.MustCompile(`^ENC\[([a-z0-9_-]+):(.*)\]$`)
+
+// decryptFunc decrypts the data of an inline encrypted value.
+type decryptFunc func(data string) (string, error)
+
+// decryptHookFunc decrypts the string values in the form ENC[scheme:data]
+// with the decrypter of the scheme.
+func decryptHookFunc(decrypters map[string]decryptFunc) mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String {
+			return data, nil
+		}
+		match := encryptedValue.FindStringSubmatch(data.(string))
+		if match == nil {
+			return data, nil
+		}
+
+		decrypt, ok := decrypters[match[1]]
+		if !ok {
+			return nil, fmt.Errorf("no decrypter for encrypted value of scheme %q", match[1])
+		}
+		val, err := decrypt(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("unable to decrypt value of scheme %q: %v", match[1], err)
+		}
+		return val, nil
+	}
+}
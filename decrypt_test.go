@@ -0,0 +1,48 @@
+package confucius
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func Test_WithDecrypter(t *testing.T) {
+	type Config struct {
+		Password string            `conf:"password"`
+		Port     int               `conf:"port"`
+		Tokens   map[string]string `conf:"tokens"`
+		Plain    string            `conf:"plain"`
+	}
+
+	b64 := WithDecrypter("b64", func(data string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(data)
+		return string(b), err
+	})
+	enc := func(s string) string {
+		return "ENC[b64:" + base64.StdEncoding.EncodeToString([]byte(s)) + "]"
+	}
+
+	var cfg Config
+	content := `{"password": "` + enc("hunter2") + `", "port": "` + enc("8080") + `", "tokens": {"a": "` + enc("t0k3n") + `"}, "plain": "ENC is not a prefix"}`
+	if err := Load(&cfg, String(content, DecoderJSON), b64); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Password != "hunter2" || cfg.Port != 8080 || cfg.Tokens["a"] != "t0k3n" || cfg.Plain != "ENC is not a prefix" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	for name, content := range map[string]string{
+		"unknown scheme": `{"password": "ENC[pgp:abc]"}`,
+		"bad data":       `{"password": "ENC[b64:!!]"}`,
+	} {
+		err := Load(&Config{}, String(content, DecoderJSON), b64)
+		fieldErrs, ok := err.(fieldErrors)
+		if !ok || fieldErrs["password"] == nil {
+			t.Errorf("%s: err == %v, expected an err for password", name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "decrypt") {
+			t.Errorf("%s: err == %v, expected a decryption err", name, err)
+		}
+	}
+}
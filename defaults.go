@@ -0,0 +1,127 @@
+package confucius
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFuncPrefix is the prefix of default values that are computed by a
+// registered function.
+const defaultFuncPrefix = "fn:"
+
+var (
+	defaultFuncsMu sync.RWMutex
+	defaultFuncs   = map[string]func() string{
+		"hostname": func() string {
+			hostname, _ := os.Hostname()
+			return hostname
+		},
+		"num_cpu": func() string {
+			return strconv.Itoa(runtime.NumCPU())
+		},
+		"now": func() string {
+			return time.Now().UTC().Format(time.RFC3339)
+		},
+	}
+)
+
+// RegisterDefaultFunc registers a function that computes a default value
+// when the config is loaded. Fields refer to the function by its name with
+// the `fn:` prefix in their default tag:
+//
+//   confucius.RegisterDefaultFunc("region", func() string {
+//     return os.Getenv("AWS_REGION")
+//   })
+//
+//   type Config struct {
+//     Host    string `conf:"host" default:"fn:hostname"`
+//     Workers int    `conf:"workers" default:"fn:num_cpu"`
+//     Region  string `conf:"region" default:"fn:region"`
+//   }
+//
+// The functions `hostname`, `num_cpu` and `now` (the current time in
+// RFC3339 layout) are registered by default. Registering a function with
+// an existing name replaces it. It is safe to call RegisterDefaultFunc
+// concurrently with Load.
+func RegisterDefaultFunc(name string, fn func() string) {
+	defaultFuncsMu.Lock()
+	defer defaultFuncsMu.Unlock()
+	defaultFuncs[name] = fn
+}
+
+// resolveDefault returns the default value val, calling the registered
+// function if val refers to one.
+func resolveDefault(val string) (string, error) {
+	if !strings.HasPrefix(val, defaultFuncPrefix) {
+		return val, nil
+	}
+
+	name := strings.TrimPrefix(val, defaultFuncPrefix)
+	defaultFuncsMu.RLock()
+	fn, ok := defaultFuncs[name]
+	defaultFuncsMu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown default func %q", name)
+	}
+	return fn(), nil
+}
+
+// fillNilStructs allocates the nil struct pointers in v whose struct declares
+// defaults, so that the defaults are applied even if the section is missing
+// from all sources. The tags of the fields are looked up in tags.
+func fillNilStructs(v reflect.Value, tagKey string, tags *tagCache) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if v.CanSet() && hasDefaults(v.Type(), tagKey, tags, map[reflect.Type]bool{}) {
+				v.Set(reflect.New(v.Type().Elem()))
+			} else {
+				return
+			}
+		}
+		fillNilStructs(v.Elem(), tagKey, tags)
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			fillNilStructs(v.Field(i), tagKey, tags)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fillNilStructs(v.Index(i), tagKey, tags)
+		}
+	}
+}
+
+// hasDefaults reports whether the struct type t, or the structs nested in
+// it, has fields with a default value. t may be a pointer to a struct.
+func hasDefaults(t reflect.Type, tagKey string, tags *tagCache, seen map[reflect.Type]bool) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if tags.parse(sf.Tag, tagKey).setDefault || hasDefaults(sf.Type, tagKey, tags, seen) {
+			return true
+		}
+	}
+	return false
+}
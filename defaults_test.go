@@ -0,0 +1,103 @@
+package confucius
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_confucius_Load_DefaultFunc(t *testing.T) {
+	RegisterDefaultFunc("test_region", func() string { return "eu-west-1" })
+
+	type Config struct {
+		Host    string    `conf:"host" default:"fn:hostname"`
+		Workers int       `conf:"workers" default:"fn:num_cpu"`
+		Started time.Time `conf:"started" default:"fn:now"`
+		Region  string    `conf:"region" default:"fn:test_region"`
+		Zone    string    `conf:"zone" default:"fn:test_region"`
+	}
+
+	var cfg Config
+	if err := Load(&cfg, String(`{"zone": "us-east-1a"}`, DecoderJSON)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	if cfg.Host != hostname {
+		t.Errorf("cfg.Host == %q, expected %q", cfg.Host, hostname)
+	}
+	if cfg.Workers != runtime.NumCPU() {
+		t.Errorf("cfg.Workers == %d, expected %d", cfg.Workers, runtime.NumCPU())
+	}
+	if time.Since(cfg.Started) > time.Minute {
+		t.Errorf("cfg.Started == %v, expected now", cfg.Started)
+	}
+	if cfg.Region != "eu-west-1" || cfg.Zone != "us-east-1a" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	var bad struct {
+		X string `default:"fn:unknown"`
+	}
+	err := Load(&bad, String(`{}`, DecoderJSON))
+	if err == nil || !strings.Contains(err.Error(), "unknown default func") {
+		t.Fatalf("expected unknown default func err, got %v", err)
+	}
+}
+
+func Test_confucius_Load_FillNilStructs(t *testing.T) {
+	type Cache struct {
+		TTL  time.Duration `conf:"ttl" default:"5m"`
+		Size int           `conf:"size"`
+	}
+	type Config struct {
+		Cache *Cache `conf:"cache"`
+		Plain *struct {
+			Name string `conf:"name"`
+		} `conf:"plain"`
+		Nested *struct {
+			Inner *Cache `conf:"inner"`
+		} `conf:"nested"`
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		var cfg Config
+		if err := Load(&cfg, String(`{}`, DecoderJSON)); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Cache != nil || cfg.Nested != nil {
+			t.Errorf("expected nil structs, got %+v", cfg)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		var cfg Config
+		if err := Load(&cfg, String(`{}`, DecoderJSON), FillNilStructs()); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Cache == nil || cfg.Cache.TTL != 5*time.Minute {
+			t.Errorf("unexpected cfg.Cache: %+v", cfg.Cache)
+		}
+		if cfg.Nested == nil || cfg.Nested.Inner == nil || cfg.Nested.Inner.TTL != 5*time.Minute {
+			t.Errorf("unexpected cfg.Nested: %+v", cfg.Nested)
+		}
+		if cfg.Plain != nil {
+			t.Errorf("expected cfg.Plain to be nil, got %+v", cfg.Plain)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		setenv(t, "APP_CACHE_SIZE", "42")
+		defer os.Unsetenv("APP_CACHE_SIZE")
+
+		var cfg Config
+		if err := Load(&cfg, String(`{}`, DecoderJSON), UseEnv("app"), FillNilStructs()); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Cache == nil || cfg.Cache.Size != 42 {
+			t.Errorf("unexpected cfg.Cache: %+v", cfg.Cache)
+		}
+	})
+}
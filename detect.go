@@ -0,0 +1,56 @@
+package confucius
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"unicode/utf8"
+)
+
+// tomlLine matches the lines that only TOML documents start with: a table
+// header, e.g. `[server]`, or a key set with `=`, e.g. `port = 80`.
+var tomlLine = regexp.MustCompile(`^(\[\[?[A-Za-z0-9_."' -]+\]\]?|[A-Za-z0-9_."'-]+\s*=.*)\s*$`)
+
+// builtinDecoders are the decoders that need no WithDecoder.
+var builtinDecoders = []Decoder{DecoderYaml, DecoderYml, DecoderJSON, DecoderJSONC, DecoderToml, DecoderMsgpack}
+
+// hasDecoder reports whether there is a decoder for the extension decoder.
+func (c *confucius) hasDecoder(decoder Decoder) bool {
+	if _, ok := c.decoders[decoder]; ok {
+		return true
+	}
+	for _, d := range builtinDecoders {
+		if d == decoder {
+			return true
+		}
+	}
+	return false
+}
+
+// detectDecoder returns the decoder of the document b by its content: JSON
+// if b is valid JSON, TOML if its first line that is not blank or a comment
+// is a table header or a key set with `=`, MessagePack if b is not valid
+// UTF-8, and YAML otherwise.
+func detectDecoder(b []byte) Decoder {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	switch {
+	case !utf8.Valid(b):
+		return DecoderMsgpack
+	case json.Valid(b):
+		return DecoderJSON
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if tomlLine.Match(line) {
+			return DecoderToml
+		}
+		break
+	}
+	return DecoderYaml
+}
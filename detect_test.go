@@ -0,0 +1,78 @@
+package confucius
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func Test_detectDecoder(t *testing.T) {
+	packed, err := msgpack.Marshal(map[string]interface{}{"port": 80})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    Decoder
+	}{
+		{name: "json object", content: `{"host": "example.com"}`, want: DecoderJSON},
+		{name: "json with bom", content: "\xef\xbb\xbf[1, 2]", want: DecoderJSON},
+		{name: "yaml flow", content: "{host: example.com}", want: DecoderYaml},
+		{name: "yaml", content: "host: example.com\nport: 80\n", want: DecoderYaml},
+		{name: "yaml comment", content: "# port = 80\nport: 80\n", want: DecoderYaml},
+		{name: "yaml list", content: "- a\n- b\n", want: DecoderYaml},
+		{name: "toml key", content: "# server\n\nport = 80\n", want: DecoderToml},
+		{name: "toml table", content: "[server]\nport = 80\n", want: DecoderToml},
+		{name: "toml array of tables", content: "[[servers]]\nport = 80\n", want: DecoderToml},
+		{name: "msgpack", content: string(packed), want: DecoderMsgpack},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectDecoder([]byte(tc.content)); got != tc.want {
+				t.Errorf("detectDecoder() == %q, expected %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_DetectFormat(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"application":      "host = \"example.com\"\nport = 80\n",
+		"application.prod": `{"port": 443}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	var cfg Config
+	err := Load(&cfg, File("application"), Dirs(dir))
+	if !errors.Is(err, ErrUnsupportedExtension) {
+		t.Fatalf("err == %v, expected ErrUnsupportedExtension", err)
+	}
+
+	if err := Load(&cfg, File("application"), Dirs(dir), Profiles("prod"), DetectFormat()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if want := (Config{Host: "example.com", Port: 443}); cfg != want {
+		t.Errorf("cfg == %+v, expected %+v", cfg, want)
+	}
+
+	cfg = Config{}
+	if err := Load(&cfg, String("host: localhost", ""), DetectFormat()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "localhost" {
+		t.Errorf("cfg.Host == %q, expected localhost", cfg.Host)
+	}
+}
@@ -0,0 +1,83 @@
+package confucius
+
+import "reflect"
+
+// Change is a difference between two configs.
+type Change struct {
+	Path string // the path of the field, e.g. "server.port".
+	Old  string // the value in the first config, or "" if the field is absent from it.
+	New  string // the value in the second config, or "" if the field is absent from it.
+}
+
+// Diff returns the fields whose values differ between the configs a and b,
+// e.g. to log what changed when a config is reloaded.
+//
+//   for _, change := range confucius.Diff(&old, &cfg) {
+//     log.Printf("%s: %s -> %s", change.Path, change.Old, change.New)
+//   }
+//
+// The parameters `a` and `b` must be pointers to structs of the same type,
+// otherwise Diff returns nil. The changes are in the order of the fields of
+// the struct. Elements of lists and maps that are present in only one of
+// the configs are reported as changes from or to "". The values of secret
+// fields are redacted, but changes to them are still reported.
+func Diff(a, b interface{}, options ...Option) []Change {
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if !isStructPtr(a) || !isStructPtr(b) || reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return nil
+	}
+
+	aAll, bAll := c.flatten(a), c.flatten(b)
+	// a list or map that is empty in one config is a leaf there, but the
+	// parent of its elements in the other, which are the changes.
+	aParents, bParents := parentPaths(aAll), parentPaths(bAll)
+
+	bFields := make(map[string]*field)
+	var bPaths []string
+	for _, f := range valueFields(bAll) {
+		if aParents[f.path()] {
+			continue
+		}
+		bFields[f.path()] = f
+		bPaths = append(bPaths, f.path())
+	}
+
+	var changes []Change
+	seen := make(map[string]bool)
+	for _, f := range valueFields(aAll) {
+		path := f.path()
+		if bParents[path] {
+			continue
+		}
+		seen[path] = true
+		other, ok := bFields[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Path: path, Old: explainValue(f)})
+		case !reflect.DeepEqual(f.v.Interface(), other.v.Interface()):
+			changes = append(changes, Change{Path: path, Old: explainValue(f), New: explainValue(other)})
+		}
+	}
+	for _, path := range bPaths {
+		if !seen[path] {
+			changes = append(changes, Change{Path: path, New: explainValue(bFields[path])})
+		}
+	}
+	return changes
+}
+
+// parentPaths returns the paths of the ancestors of fields, except the
+// root.
+func parentPaths(fields []*field) map[string]bool {
+	paths := make(map[string]bool)
+	for _, f := range fields {
+		for p := f.parent; p != nil && p.parent != nil; p = p.parent {
+			paths[p.path()] = true
+		}
+	}
+	return paths
+}
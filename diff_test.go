@@ -0,0 +1,69 @@
+package confucius
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Diff(t *testing.T) {
+	type Backend struct {
+		Name string `conf:"name"`
+	}
+	type Config struct {
+		Port     int            `conf:"port"`
+		Host     string         `conf:"host"`
+		Password string         `conf:"password" secret:"true"`
+		Tags     []string       `conf:"tags"`
+		Limits   map[string]int `conf:"limits"`
+		Backends []Backend      `conf:"backends"`
+	}
+
+	a := Config{
+		Port:     80,
+		Host:     "localhost",
+		Password: "old",
+		Tags:     []string{"a"},
+		Limits:   map[string]int{"x": 1},
+		Backends: []Backend{{Name: "b1"}, {Name: "b2"}},
+	}
+	b := a
+	b.Port = 443
+	b.Password = "new"
+	b.Tags = []string{"a", "b"}
+	b.Limits = map[string]int{"x": 1}
+	b.Backends = []Backend{{Name: "b1"}}
+
+	want := []Change{
+		{Path: "port", Old: "80", New: "443"},
+		{Path: "password", Old: RedactedValue, New: RedactedValue},
+		{Path: "tags", Old: "[a]", New: "[a b]"},
+		{Path: "backends[1].name", Old: "b2"},
+	}
+	if got := Diff(&a, &b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() == %+v, expected %+v", got, want)
+	}
+
+	c := a
+	c.Backends = nil
+	want = []Change{
+		{Path: "backends[0].name", Old: "b1"},
+		{Path: "backends[1].name", Old: "b2"},
+	}
+	if got := Diff(&a, &c); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() == %+v, expected %+v", got, want)
+	}
+	want = []Change{
+		{Path: "backends[0].name", New: "b1"},
+		{Path: "backends[1].name", New: "b2"},
+	}
+	if got := Diff(&c, &a); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() == %+v, expected %+v", got, want)
+	}
+
+	if got := Diff(&a, &a); len(got) != 0 {
+		t.Errorf("Diff() == %+v, expected no changes", got)
+	}
+	if got := Diff(&a, &Backend{}); got != nil {
+		t.Errorf("Diff() == %+v, expected nil for different types", got)
+	}
+}
@@ -0,0 +1,119 @@
+package confucius
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// searchDirs returns the directories set with Dirs and StandardDirs, with
+// `~` and environment variables such as `$HOME` and `${VAR}` expanded. A
+// directory that refers to a variable that is not set, or to the home
+// directory if it is not known, is left out.
+func (c *confucius) searchDirs() []string {
+	dirs := make([]string, 0, len(c.dirs))
+	for _, dir := range c.dirs {
+		expanded, ok := expandDir(dir, os.UserHomeDir, os.LookupEnv)
+		if !ok {
+			c.logger.Debug("skipping directory %q: variable not set", dir)
+			continue
+		}
+		dirs = append(dirs, expanded)
+	}
+	return dirs
+}
+
+// expandDir expands a leading `~` in dir to the home directory and the
+// environment variables in dir. It reports false if the home directory
+// or a variable is missing.
+func expandDir(dir string, homeDir func() (string, error), lookupEnv func(string) (string, bool)) (string, bool) {
+	if dir == "~" || strings.HasPrefix(dir, "~/") || strings.HasPrefix(dir, "~"+string(filepath.Separator)) {
+		home, err := homeDir()
+		if err != nil || home == "" {
+			return "", false
+		}
+		dir = home + dir[1:]
+	}
+
+	ok := true
+	dir = os.Expand(dir, func(key string) string {
+		val, set := lookupEnv(key)
+		if !set {
+			ok = false
+		}
+		return val
+	})
+	return dir, ok
+}
+
+// StandardDirs returns an option that appends the standard config
+// directories of the platform for the application app to the directories
+// that are searched, e.g. after those set with `Dirs`:
+//
+//   confucius.Load(&cfg, confucius.Dirs("."), confucius.StandardDirs("myapp"))
+//
+// On Linux and other Unix systems these are `$XDG_CONFIG_HOME/myapp`,
+// `~/.config/myapp` and `/etc/myapp`. On macOS
+// `~/Library/Application Support/myapp` is searched first and
+// `/Library/Application Support/myapp` last, and on Windows these are
+// `%AppData%\myapp` and `%ProgramData%\myapp`. Directories whose variable
+// is not set are left out.
+func StandardDirs(app string) Option {
+	return func(c *confucius) {
+		home, _ := os.UserHomeDir()
+		c.dirs = append(c.dirs, standardDirs(runtime.GOOS, app, home, os.Getenv)...)
+	}
+}
+
+// standardDirs returns the standard config directories of app on the
+// platform goos, for the user with the home directory home, or "" if it is
+// not known.
+func standardDirs(goos, app, home string, getenv func(string) string) []string {
+	var dirs []string
+	add := func(base string, elem ...string) {
+		if base == "" {
+			return
+		}
+		dir := filepath.Join(append(append([]string{base}, elem...), app)...)
+		if !containsString(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	switch goos {
+	case "windows":
+		add(getenv("AppData"))
+		add(getenv("ProgramData"))
+		return dirs
+	case "darwin", "ios":
+		add(home, "Library", "Application Support")
+	}
+	add(getenv("XDG_CONFIG_HOME"))
+	add(home, ".config")
+	add("/etc")
+	if goos == "darwin" || goos == "ios" {
+		add("/Library", "Application Support")
+	}
+	return dirs
+}
+
+// applyFilePath makes the path of the config file set by the flag of
+// FileFromFlag, or else by the environment variable of FileFromEnv, the
+// only file that is searched for, with its profile files next to it.
+func (c *confucius) applyFilePath() {
+	var path, from string
+	if c.fileFlag != nil {
+		path, from = c.fileFlag(), "flag"
+	}
+	if path == "" && c.fileEnv != "" {
+		path, from = os.Getenv(c.fileEnv), "$"+c.fileEnv
+	}
+	if path == "" {
+		return
+	}
+
+	c.logger.Debug("config file set by %s: %q", from, path)
+	c.filename = filepath.Base(path)
+	c.dirs = []string{filepath.Dir(path)}
+}
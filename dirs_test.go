@@ -0,0 +1,182 @@
+package confucius
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func Test_standardDirs(t *testing.T) {
+	env := map[string]string{
+		"XDG_CONFIG_HOME": "/home/user/.xdg",
+		"AppData":         `C:\Users\user\AppData\Roaming`,
+		"ProgramData":     `C:\ProgramData`,
+	}
+	getenv := func(key string) string { return env[key] }
+	noenv := func(string) string { return "" }
+	j := filepath.Join
+
+	for _, tc := range []struct {
+		name   string
+		goos   string
+		home   string
+		getenv func(string) string
+		want   []string
+	}{
+		{name: "linux", goos: "linux", home: "/home/user", getenv: getenv, want: []string{j("/home/user/.xdg", "myapp"), j("/home/user/.config", "myapp"), j("/etc", "myapp")}},
+		{name: "linux without xdg", goos: "linux", home: "/home/user", getenv: noenv, want: []string{j("/home/user/.config", "myapp"), j("/etc", "myapp")}},
+		{name: "linux without home", goos: "linux", getenv: noenv, want: []string{j("/etc", "myapp")}},
+		{name: "darwin", goos: "darwin", home: "/Users/user", getenv: noenv, want: []string{j("/Users/user", "Library", "Application Support", "myapp"), j("/Users/user/.config", "myapp"), j("/etc", "myapp"), j("/Library", "Application Support", "myapp")}},
+		{name: "windows", goos: "windows", home: `C:\Users\user`, getenv: getenv, want: []string{j(env["AppData"], "myapp"), j(env["ProgramData"], "myapp")}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := standardDirs(tc.goos, "myapp", tc.home, tc.getenv); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("standardDirs() == %q, expected %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_StandardDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("XDG_CONFIG_HOME is not searched on windows")
+	}
+	xdg := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(xdg, "myapp"), 0700); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "myapp", "config.yaml"), []byte("host: example.com\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	setenv(t, "XDG_CONFIG_HOME", xdg)
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	var cfg struct {
+		Host string `conf:"host"`
+	}
+	if err := Load(&cfg, Dirs(t.TempDir()), StandardDirs("myapp")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "example.com" {
+		t.Errorf("cfg.Host == %q, expected example.com", cfg.Host)
+	}
+}
+
+func Test_expandDir(t *testing.T) {
+	env := map[string]string{"APP_DIR": "/opt/app", "EMPTY": ""}
+	lookupEnv := func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+	homeDir := func() (string, error) { return "/home/user", nil }
+	noHome := func() (string, error) { return "", errors.New("$HOME is not defined") }
+
+	for _, tc := range []struct {
+		dir     string
+		homeDir func() (string, error)
+		want    string
+		wantOK  bool
+	}{
+		{dir: "/etc/myapp", homeDir: homeDir, want: "/etc/myapp", wantOK: true},
+		{dir: "~", homeDir: homeDir, want: "/home/user", wantOK: true},
+		{dir: "~/.myapp", homeDir: homeDir, want: "/home/user/.myapp", wantOK: true},
+		{dir: "~user/.myapp", homeDir: homeDir, want: "~user/.myapp", wantOK: true},
+		{dir: "${APP_DIR}/conf", homeDir: homeDir, want: "/opt/app/conf", wantOK: true},
+		{dir: "$APP_DIR", homeDir: homeDir, want: "/opt/app", wantOK: true},
+		{dir: "$EMPTY/conf", homeDir: homeDir, want: "/conf", wantOK: true},
+		{dir: "${MISSING}/conf", homeDir: homeDir, wantOK: false},
+		{dir: "~/.myapp", homeDir: noHome, wantOK: false},
+	} {
+		got, ok := expandDir(tc.dir, tc.homeDir, lookupEnv)
+		if ok != tc.wantOK || (ok && got != tc.want) {
+			t.Errorf("expandDir(%q) == %q, %t, expected %q, %t", tc.dir, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func Test_confucius_Load_ExpandedDirs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("host: example.com\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	setenv(t, "APP_CONFIG_DIR", dir)
+	defer os.Unsetenv("APP_CONFIG_DIR")
+
+	var cfg struct {
+		Host string `conf:"host"`
+	}
+	if err := Load(&cfg, Dirs("${APP_MISSING_DIR}", "$APP_CONFIG_DIR")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "example.com" {
+		t.Errorf("cfg.Host == %q, expected example.com", cfg.Host)
+	}
+}
+
+func Test_confucius_Load_FileFromEnvAndFlag(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":    "name: default\n",
+		"env.yaml":       "name: env\n",
+		"env.prod.yaml":  "port: 443\n",
+		"flag.json":      `{"name": "flag"}`,
+		"other/app.yaml": "name: other\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0700); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Config struct {
+		Name string `conf:"name"`
+		Port int    `conf:"port"`
+	}
+
+	newFlagSet := func(args ...string) *flag.FlagSet {
+		fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+		fs.String("config", "", "the config file")
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		return fs
+	}
+
+	for _, tc := range []struct {
+		name    string
+		env     string
+		args    []string
+		options []Option
+		want    Config
+	}{
+		{name: "default", want: Config{Name: "default"}},
+		{name: "env", env: filepath.Join(dir, "env.yaml"), want: Config{Name: "env"}},
+		{name: "env with profile", env: filepath.Join(dir, "env.yaml"), options: []Option{Profiles("prod")}, want: Config{Name: "env", Port: 443}},
+		{name: "flag over env", env: filepath.Join(dir, "env.yaml"), args: []string{"-config", filepath.Join(dir, "flag.json")}, want: Config{Name: "flag"}},
+		{name: "flag not set", env: filepath.Join(dir, "other", "app.yaml"), want: Config{Name: "other"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.env != "" {
+				setenv(t, "MYAPP_CONFIG", tc.env)
+				defer os.Unsetenv("MYAPP_CONFIG")
+			}
+			options := append([]Option{
+				Dirs(dir), FileFromEnv("MYAPP_CONFIG"), FileFromFlag(newFlagSet(tc.args...), "config"),
+			}, tc.options...)
+
+			var cfg Config
+			if err := Load(&cfg, options...); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if cfg != tc.want {
+				t.Errorf("cfg == %+v, expected %+v", cfg, tc.want)
+			}
+		})
+	}
+}
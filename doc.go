@@ -63,6 +63,10 @@ Define your struct and load it:
 By default confucius searches for a file named `config.yaml` in the directory it is run from.
 It can be configured to look elsewhere.
 
+`LoadAs()` returns the loaded config instead of filling a pointer. On error it returns the zero value, never a partially loaded config.
+
+  cfg, err := confucius.LoadAs[Config](confucius.File("config.yaml"))
+
 Configuration
 
 Pass options as additional parameters to `Load()` to configure fig's behaviour.
@@ -76,9 +80,73 @@ Change the file and directories confucius searches in with `File()`.
     confucius.Dirs(".", "home/user/myapp", "/opt/myapp"),
   )
 
-Fig searches for the file in dirs sequentially and uses the first matching file.
+Fig searches for the file in dirs sequentially and uses the first matching file. A leading `~` and environment variables such as `$HOME` and `${VAR}` are expanded in dirs, and dirs that refer to a variable that is not set are skipped.
+
+`StandardDirs()` appends the standard config directories of the platform for an application: `$XDG_CONFIG_HOME/myapp`, `~/.config/myapp` and `/etc/myapp` on Unix systems, `~/Library/Application Support/myapp` on macOS and `%AppData%\myapp` and `%ProgramData%\myapp` on Windows.
+
+  confucius.Load(&cfg, confucius.Dirs("."), confucius.StandardDirs("myapp"))
+
+The path of the config file can be set at runtime with `FileFromEnv()`, which takes it from an environment variable, and `FileFromFlag()`, which takes it from a command line flag and takes precedence over the environment. The file set with `File()` in the directories set with `Dirs()` is only searched for if neither is set, and profile files are searched for next to the file. The package github.com/hasanozgan/confucius/cobra provides `FileFromFlag` for cobra commands.
+
+  fs.String("config", "", "the config file")
+  confucius.Load(&cfg, confucius.FileFromEnv("MYAPP_CONFIG"), confucius.FileFromFlag(fs, "config"))
+
+The decoder (yaml/json/toml) used is picked based on the file's extension, unless a decoder is passed to `File()`, e.g. for deployments that mandate a `.conf` suffix. The profile files are then decoded with the same decoder.
+
+  confucius.Load(&cfg, confucius.File("settings.conf", confucius.DecoderYaml))
+
+Compressed files are decompressed before they are decoded, gzip for files ending in `.gz` and zstd for files ending in `.zst`, and the decoder is picked by the extension before, e.g. yaml for `config.yaml.gz`. The profile files of a compressed config file are compressed the same way, e.g. `config.prod.yaml.gz`.
+
+  confucius.Load(&cfg, confucius.File("config.yaml.gz"))
+
+Files without an extension, such as the keys of Kubernetes ConfigMaps mounted as files, fail with `ErrUnsupportedExtension` unless `DetectFormat()` is used, which picks the decoder by the content: valid JSON is decoded as JSON, documents that start with a TOML table header or `key = value` line as TOML, and other documents as YAML.
+
+  confucius.Load(&cfg, confucius.File("application"), confucius.DetectFormat())
+
+TOML files are decoded as TOML 1.0, including dotted keys, arrays of tables and mixed arrays. Integers and floats keep their types, and date-times are decoded as time.Time; local date-times and dates are in the local time zone, while local times are strings.
+
+Files with the `.jsonc` extension, and readers set with `String(s, DecoderJSONC)`, are decoded as JSON with comments: line and block comments and trailing commas in objects and arrays are ignored. With `LenientJSON()` `.json` files are decoded the same way, e.g. to load editor settings that are edited by hand. Other JSON5 extensions, such as unquoted keys, are not supported.
+
+  confucius.Load(&cfg, confucius.File("settings.json"), confucius.LenientJSON())
+
+Files of other formats are decoded by the function registered for their extension with `WithDecoder()`, which returns the document as maps, lists and scalars. The package github.com/hasanozgan/confucius/cue decodes CUE files: they are evaluated and their constraints enforced before they are decoded, so that a value that violates the schema fails to load at the line it was set.
+
+  err := confucius.Load(&cfg, confucius.File("config.cue"), confcue.WithCUE())
+
+MessagePack documents, e.g. config snapshots distributed by a control plane, are decoded from files with the `.msgpack` extension and from readers set with `DecoderMsgpack`. `Bytes()` sets a reader like `String()` does, but keeps binary data as it is.
 
-The decoder (yaml/json/toml) used is picked based on the file's extension.
+  err := confucius.Load(&cfg, confucius.Bytes(snapshot, confucius.DecoderMsgpack))
+
+With `Stdin()` the configuration is read from the standard input, e.g. for `cat config.yaml | myapp -`. It is decoded like a reader set with `Reader()` and merged with the other sources in the same order. The input is read once, and later loads of a `Loader` decode the same input.
+
+  confucius.Load(&cfg, confucius.Stdin(confucius.DecoderYaml))
+
+YAML anchors, aliases and merge keys (`<<: *defaults`) are resolved, and maps with keys that are not strings, e.g. `404: not found`, are normalized to string keys. Nested maps of YAML files are so merged with those of the profile files key by key, like maps of JSON and TOML files.
+
+Config files that hold secrets should not be readable by everyone. With `RequireFileMode()` loading fails with `ErrInsecureFileMode` if a file has more permissions than the given ones, like ssh does for its keys, and `WarnFileMode()` logs a warning instead.
+
+  confucius.Load(&cfg, confucius.RequireFileMode(0600))
+
+Profiles
+
+Profile files are merged over the config file, e.g. `config.prod.yaml` with `Profiles("prod")`. The names of the profile files follow `ProfileLayout()`, which defaults to `config.test.yaml`. By default every profile file must exist; with `OptionalProfiles()` missing profile files are skipped, e.g. for local overrides.
+
+  confucius.Load(&cfg, confucius.Profiles("prod", "local"), confucius.OptionalProfiles())
+
+With `DefaultProfile()` a fallback profile is used when no profile is set, neither with `Profiles()` nor by `DetectEnvironment()`. Whether the fallback was used is logged at debug level and reported in `Report.DefaultProfile`.
+
+  confucius.Load(&cfg, confucius.DetectEnvironment(), confucius.DefaultProfile("dev"))
+
+Small services may keep all profiles in a single YAML file instead, with `ProfileDocuments()`. In a file with multiple documents, the documents that declare no `profile` are always merged, in order, and those that declare a profile, or a list of profiles, only when one of them is active, in the order of `Profiles()`. Profile files are then optional, as if `OptionalProfiles()` was used, and are merged over the config file if they exist. Without the option only the first document of a YAML file is loaded.
+
+  host: localhost
+  ---
+  profile: prod
+  host: example.com
+
+  confucius.Load(&cfg, confucius.Profiles("prod"), confucius.ProfileDocuments())
+
+The sources are merged in a fixed order and later ones take precedence: the reader set with `Reader()`, `String()`, `Bytes()` or `Stdin()`, of which only one may be used, the config file, the profile files in the order passed to `Profiles()` and then the providers passed to `FromProviders()`. Environment variables and flags override all files, overrides set with `Override()` come last, and defaults only fill fields that are still unset. The order in which the files were merged is listed in `Report.Merged`.
 
 Tag
 
@@ -94,6 +162,16 @@ The struct tag key tag confucius looks for to find the field's alt name can be c
 
 By default confucius uses the tag key `fig`.
 
+Several tag keys can be used at once with `Tags()`. For each field the first key present in its tags is used, so structs annotated for fig or for other packages with the same tag syntax load unchanged.
+
+  confucius.Load(&cfg, confucius.Tags("conf", "fig"))
+
+Structs written for older fig versions, which declare required fields and defaults in the name tag, e.g. `fig:"host,required"` or `fig:"port,default=8080"`, load unchanged with the `LegacyFigTags()` option.
+
+Keys in config files are matched to field names exactly or, failing that, regardless of case. `NormalizedKeys()` additionally ignores dashes and underscores, so `logLevel`, `log-level` and `log_level` all set the same field, and `CaseInsensitiveKeys()` only ignores case. With either option it is an error for several keys to match the same field.
+
+  confucius.Load(&cfg, confucius.NormalizedKeys())
+
 Environment
 
 Fig can be configured to additionally set fields using the environment. This will happen after the struct is loaded from a config file and thus any values found in the environment will overwrite existing values in the struct.
@@ -123,7 +201,17 @@ environment variables:
   MYAPP_LOG_LEVEL
   MYAPP_SERVER_HOST
 
-Fields contained in struct slices whose elements already exists can be also be set via the environment in the form PARENT_IDX_FIELD, where idx is the index of the field in the slice.
+The option `EnvSeparator(sep)` changes the separator, so that nested fields can be told apart from fields whose names contain an underscore. With `EnvSeparator("__")` the keys above become MYAPP__BUILD, MYAPP__LOG_LEVEL and MYAPP__SERVER__HOST.
+
+The prefix may contain the placeholder `{profile}`, which is replaced by the active profile, i.e. the last one passed to `Profiles()`. Canary and stable deployments on the same host can so be configured independently:
+
+  err := confucius.Load(&cfg, confucius.UseEnv("myapp_{profile}"), confucius.Profiles("canary")) // MYAPP_CANARY_BUILD
+
+Without profiles the placeholder is dropped along with its separator, so the keys are MYAPP_BUILD and so on.
+
+Variables with the prefix that match no field are ignored. With `ReportUnusedEnv()` they are logged as warnings and listed in `Report.UnusedEnv`, so that a typo such as MYAPP_SERVERR_HOST is noticed.
+
+Fields contained in struct slices can be also be set via the environment in the form PARENT_IDX_FIELD, where idx is the index of the field in the slice.
 
   type Config struct {
     Server []struct {
@@ -137,7 +225,64 @@ With the config above individual servers may be configured with the following en
   MYAPP_SERVER_1_HOST
   ...
 
-Note: the Server slice must already have members inside it (i.e. from loading of the configuration file) for the containing fields to be altered via the environment. Fig will not instantiate and insert elements into the slice.
+The slice is grown to hold the highest index set in the environment, so MYAPP_SERVER_1_HOST configures a second server even if the file lists only one, or none. Elements can also be instantiated with a `defaultLen` tag when the slice is absent from every source:
+
+  type Config struct {
+    Workers []struct {
+      Queue       string `conf:"queue" default:"jobs"`
+      Concurrency int    `conf:"concurrency" default:"4"`
+    } `conf:"workers" defaultLen:"2"`
+  }
+
+The elements are filled with their defaults and may be configured with MYAPP_WORKERS_0_QUEUE, MYAPP_WORKERS_1_CONCURRENCY etc. On a slice of structs a `default` tag such as `default:"2"` has the same effect.
+
+Elements of slices of basic types are set in the same way, e.g. MYAPP_HOSTS_0. Maps with string keys are filled from variables in the form PARENT_KEY, where the key is lower-cased:
+
+  type Config struct {
+    Labels map[string]string `conf:"labels"` // MYAPP_LABELS_TEAM=payments  --->  labels["team"] = "payments"
+  }
+
+Maps of structs, e.g. per-tenant settings, are supported end to end: their entries are merged across profile files, the fields of each entry get their defaults and validations, and the fields of existing entries are set from variables in the form PARENT_KEY_FIELD. In errors such fields are named by their key, e.g. `tenants[acme].name`.
+
+  type Config struct {
+    Tenants map[string]Tenant `conf:"tenants"` // MYAPP_TENANTS_ACME_TIMEOUT=1m  --->  tenants["acme"].Timeout = 1m
+  }
+
+By default a slice set from the environment replaces the slice loaded from the config file. The `envmode` option of the field's tag changes this: `append` appends the elements from the environment and `merge` appends only the elements the slice doesn't already contain.
+
+  type Config struct {
+    Hosts []string `conf:"hosts,envmode=append"` // MYAPP_HOSTS=[c,d] and hosts: [a,b] in the file  --->  [a b c d]
+  }
+
+Expansion
+
+String values in config files may refer to environment variables in the form `${NAME}`, or `${NAME:default}` to fall back to a default value when the variable is not set.
+
+  server:
+    host: "${SERVER_HOST:localhost}"
+
+The bash-style forms are supported as well, so config files can declare which variables are mandatory:
+
+  ${NAME:-default}  default if NAME is not set or empty
+  ${NAME:+alt}      alt if NAME is set and not empty, otherwise an empty string
+  ${NAME:?message}  loading fails with the message if NAME is not set or empty
+
+References may also point to other keys of the config by their path. They are resolved once all config files are merged, and fall back to the environment when the config has no such key:
+
+  server:
+    host: example.com
+    port: 8443
+  url: "https://${server.host}:${server.port}"
+
+A reference can be kept as it is by escaping it as `$${NAME}`, which results in the literal `${NAME}`. The option `DisableExpansion()` turns off the expansion of both kinds of references altogether.
+
+Encrypted values
+
+Individual secrets can live in otherwise plaintext config files as inline encrypted values in the form `ENC[scheme:data]`, which are decrypted while decoding by the function registered for the scheme with `WithDecrypter()`. The package github.com/hasanozgan/confucius/age decrypts values encrypted with age:
+
+  password: ENC[age:YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOS...]
+
+  err := confucius.Load(&cfg, confage.WithIdentityFile("/etc/myapp/key.txt"))
 
 Time
 
@@ -155,6 +300,106 @@ Change the layout confucius uses to parse times using `TimeLayout()`.
 
 By default confucius parses time using the `RFC.3339` layout (`2006-01-02T15:04:05Z07:00`).
 
+Aliases
+
+A field that was renamed can still be populated from its old name during a migration window with the `alias` option of its tag. A warning is logged whenever an alias is used, both for keys in config files and for environment variables.
+
+  type Config struct {
+    Port int `conf:"port,alias=listen_port"`
+  }
+
+Units
+
+The `unit` option of the field's tag declares the unit of bare numbers. For `time.Duration` fields a number is multiplied by the unit, which gives a migration path for configs that stored durations as integers. For integer fields a duration string is converted into a number of units. The unit applies to config files, the environment and defaults alike.
+
+  type Config struct {
+    Timeout time.Duration `conf:"timeout,unit=ms"` // timeout: 1500  --->  1.5s
+    TTL     int64         `conf:"ttl,unit=s"`      // ttl: 1h        --->  3600
+  }
+
+Supported units are `ns`, `us`, `ms`, `s`, `m` and `h`.
+
+Field types
+
+Besides the basic types, confucius provides field types for common config values. They are parsed and validated from config files, the environment and defaults alike.
+
+  confucius.Percent       a ratio in [0, 1] set from "85%", 0.85 or 85
+  confucius.Size          a number of bytes set from "512KiB", "10MB" or 1048576
+  confucius.Quantity      a Kubernetes resource quantity, e.g. "500m" or "2Gi"
+  confucius.MicroTime     a timestamp with microsecond precision, as used by Kubernetes
+  confucius.HostPort      a "host:port" pair, e.g. "[::1]:8080" or ":8080"
+  confucius.HostPortList  a list of pairs set from a list or "a:80,b:80"
+  confucius.CronSpec      a cron expression, e.g. "0 3 * * MON-FRI" or "@daily"
+  confucius.Glob          a glob pattern where "**" matches any number of directories
+  confucius.MediaType     a content type with its parameters, e.g. "text/html; charset=utf-8"
+  confucius.Headers       HTTP headers with canonicalized names and one or more values
+  confucius.Optional[T]   a value of type T that records whether it was set
+  confucius.Secret        a sensitive value that is never printed and can be zeroed
+
+An Optional tells a field that is absent from all sources apart from a field that is explicitly set to its zero value, e.g. `retries: 0`, without using a pointer. `Get()` returns the value and whether it was set. A required Optional passes validation as soon as it is set, even to its zero value.
+
+A Secret is treated like a field tagged as secret and prints as `***`, also with `%+v` and when encoded as JSON, so logging a config does not leak it. `Value()` returns the value, and `Zero()` overwrites it once it is no longer needed.
+
+Fields of type url.URL, *url.URL, net.IP and net.IPNet are parsed from strings, the latter from CIDR notation such as "10.0.0.0/8". Invalid values are reported by the field's path like other validation errors.
+
+  type Config struct {
+    Endpoint *url.URL    `conf:"endpoint" default:"https://api.example.com"`
+    Allowed  []net.IPNet `conf:"allowed"`
+  }
+
+Any other type that implements encoding.TextUnmarshaler, e.g. net.IP or a custom enum type, is set with its UnmarshalText method, from config files, the environment and defaults alike.
+
+  type Config struct {
+    Bind  net.IP `conf:"bind" default:"0.0.0.0"`
+    Level Level  `conf:"level" default:"info"` // *Level implements encoding.TextUnmarshaler
+  }
+
+Other conversions can be added to the mapstructure decode hooks used for config files with `WithDecodeHook()`.
+
+  confucius.Load(&cfg, confucius.WithDecodeHook(mapstructure.StringToIPNetHookFunc()))
+
+Entries of a HostPortList in the form `srv://_service._proto.name` are expanded using DNS SRV records only when `Resolve()` is called.
+
+Cron expressions are parsed by `DefaultCronParser`, which can be replaced to support other dialects. Plain string fields can be checked with the `cron` and `mimetype` validation rules:
+
+  type Config struct {
+    Schedule    string `conf:"schedule" validate:"cron"`
+    ContentType string `conf:"content_type" validate:"mimetype"`
+  }
+
+Section types
+
+Section types group the settings of a commonly configured component and can be embedded anywhere in a config struct.
+
+  confucius.Proxy      outbound HTTP proxies with http, https and no_proxy settings
+  confucius.RateLimit  a token bucket rate limit with rps and burst settings
+  confucius.Backoff    an exponential backoff policy with initial, max, multiplier and jitter settings
+  confucius.Pool       a connection pool with max_open, max_idle, max_lifetime and idle_timeout settings
+  confucius.Telemetry  an OpenTelemetry OTLP exporter with endpoint, protocol, headers, sampling_ratio and resource_attributes settings
+
+Empty Proxy settings fall back to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. `Func()` returns a function that can be used as the Proxy of an http.Transport.
+
+`RateLimit.Limiter()` and `Backoff.Sequence()` create a ready-to-use limiter and sequence of retry delays. `Pool.Apply()` configures a *sql.DB. `Telemetry.Resolved()` falls back to the OTEL_* environment variables of the OpenTelemetry specification and `Telemetry.Environ()` exports the settings as such.
+
+Base structs
+
+Sections shared by many config structs can be declared once in a struct that embeds `confucius.Base`. The fields of an embedded base struct are treated as fields of the struct that embeds it, in config files, in environment variables and when encoding.
+
+  type Common struct {
+    confucius.Base
+    Log       LogConfig           `conf:"log"`
+    Telemetry confucius.Telemetry `conf:"telemetry"`
+  }
+
+  type Config struct {
+    Common
+    DB DBConfig `conf:"db"`
+  }
+
+With `UseEnv("myapp")` the log level of the config above is set from MYAPP_LOG_LEVEL. The embedded type must be exported. Defaults shared by all the config structs that embed a base struct are registered with `RegisterBaseDefaults()`:
+
+  confucius.RegisterBaseDefaults(Common{Log: LogConfig{Level: "info"}})
+
 Required
 
 A validate key with a required value in the field's struct tag makes confucius check if the field has been set after it's been loaded. Required fields that are not set are returned as an error.
@@ -208,6 +453,101 @@ See example below to help understand:
   fmt.Print(err)
   // A: required, B: required, C: required, D: required, E: required, G: required, H.J: required, K: required, M: required
 
+Conditional required
+
+A field can be required only when other fields of the same struct have certain values. `required_if` requires the field when all listed fields match, `required_unless` requires it unless they all match. Fields are referred to by their struct field name or their alt name.
+
+  type Config struct {
+    Mode   string `conf:"mode" default:"dev"`
+    Region string `conf:"region"`
+    Cert   string `conf:"cert" validate:"required_if=Mode production"`
+    Seed   int    `conf:"seed" validate:"required_unless=Mode production Region eu"`
+  }
+
+Fields that must be set together, such as a username and a password or a certificate and its key, use `required_with`, which requires the field as soon as any of the listed fields is set. The error is reported at the path of the missing field.
+
+  type TLS struct {
+    Cert string `conf:"cert" validate:"required_with=Key"`
+    Key  string `conf:"key" validate:"required_with=Cert"`
+  }
+
+Multiple rules in the validate tag are separated by a comma.
+
+Expressions
+
+Constraints that the other rules cannot express, without writing a `Validate()` method, can be checked with the `expr` rule. The expression must be true, where `Value` is the value of the field and other names refer to its sibling fields like above.
+
+  type Config struct {
+    MaxConnections int           `conf:"max_connections"`
+    Connections    int           `conf:"connections" validate:"expr=Value > 0 && Value < MaxConnections"`
+    Timeout        time.Duration `conf:"timeout" validate:"expr=Value >= 1s && Value <= 1m"`
+  }
+
+Expressions support the operators of Go (`|| && == != < <= > >= + - * / % !`), parentheses, numbers, durations such as 5s, quoted strings, true, false and len() of strings, slices and maps. As rules are separated by commas, expressions cannot contain commas.
+
+Enums
+
+The `oneof` rule limits a field to the space separated values of its parameter. Fields of a type that implements `Enum`, by returning its values from an `Enum() []string` method, are checked against those values without a tag. Lists are checked element by element, and the values are listed by `Docs()`, `Skeleton()` and `Explain()`.
+
+  type Level string
+
+  func (Level) Enum() []string { return []string{"debug", "info", "error"} }
+
+  type Config struct {
+    Level  Level  `conf:"level" default:"info"`
+    Format string `conf:"format" validate:"oneof=json text"`
+  }
+
+Ranges
+
+The rules `min`, `max`, `gte`, `lte` and `len` compare numbers by their value, and strings, slices and maps by their length. The rules `minlen` and `maxlen` only compare lengths. The length of a string is its number of characters, and the bounds of durations are written as durations.
+
+  type Config struct {
+    Port    int           `conf:"port" validate:"min=1,max=65535"`
+    Timeout time.Duration `conf:"timeout" validate:"min=1s,max=1m"`
+    Name    string        `conf:"name" validate:"minlen=2,maxlen=32"`
+    Servers []Server      `conf:"servers" validate:"min=1"`
+  }
+
+Unlike other rules these also check zero values, so that a port of 0 fails `min=1`. Nil pointers are left to the required rule.
+
+Uniqueness
+
+The `unique` rule fails if two elements of a list are equal. On lists of structs it names the fields whose values must be unique together, and the error reports the indices of both duplicates.
+
+  type Config struct {
+    Hosts     []string   `conf:"hosts" validate:"unique"`
+    Listeners []Listener `conf:"listeners" validate:"unique=Name"`
+    Backends  []Backend  `conf:"backends" validate:"unique=Host Port"`
+  }
+
+Paths
+
+The `file` and `dir` rules check at load time that a field, or every element of a list, holds the path of a readable file or directory, so that a bad certificate path fails at startup rather than at the first TLS handshake. Empty paths are left to the required rule.
+
+  type Config struct {
+    Cert    string `conf:"cert" validate:"required,file"`
+    DataDir string `conf:"data_dir" validate:"dir"`
+  }
+
+Addresses
+
+The `hostport` rule checks that a string holds a `host:port` pair with a numeric port, as net.SplitHostPort splits it. The `tcp_addr` rule checks that a string holds a TCP address that net.ResolveTCPAddr resolves, which looks up host names.
+
+  type Config struct {
+    Upstream string `conf:"upstream" validate:"hostport"`
+    Listen   string `conf:"listen" default:":8080" validate:"tcp_addr"`
+  }
+
+Warnings
+
+Rules prefixed with `warn:` do not fail loading. Their failures are logged as warnings, emitted as `EventWarning` and listed in `Report.Warnings`, so that a stricter constraint can be rolled out before it is enforced. `warn:required` warns about fields that are not set.
+
+  type Config struct {
+    Workers int    `conf:"workers" validate:"min=1,warn:max=64"`
+    Owner   string `conf:"owner" validate:"warn:required"`
+  }
+
 Default
 
 A default key in the field tag makes confucius fill the field with the value specified when the field is not otherwise set.
@@ -221,18 +561,33 @@ Fig attempts to parse the value based on the field's type. If parsing fails then
 
 A default value can be set for the following types:
 
-  all basic types except bool and complex
+  all basic types except complex
   time.Time
   time.Duration
   slices (of above types)
 
+Defaults that can only be known at load time are computed by functions registered with `RegisterDefaultFunc()` and referred to with the `fn:` prefix. The functions `hostname`, `num_cpu` and `now` are registered by default.
+
+  type Config struct {
+    Host    string `conf:"host" default:"fn:hostname"`
+    Workers int    `conf:"workers" default:"fn:num_cpu"`
+  }
+
 Successive elements of slice defaults should be separated by a comma. The entire slice can optionally be enclosed in square brackets:
 
   type Config struct {
     Durations []time.Duration `default:"[30m,1h,90m,2h]"` // or `default:"30m,1h,90m,2h"`
   }
 
-Note: the default setter knows if it should fill a field or not by comparing if the current value of the field is equal to the corresponding zero value for that field's type. This happens after the configuration is loaded and has the implication that the zero value set explicitly by the user will get overwritten by any default value registered for that field. It's for this reason that defaults on booleans are not permitted, as a boolean field with a default value of `true` would always be true (since if it were set to false it'd be overwritten).
+Note: the default setter only fills a field when it is absent from every source (config files, reader and environment) and still holds its zero value. A zero value set explicitly, such as `retries: 0` or `enabled: false`, is kept, which is why defaults on booleans are supported:
+
+  type Config struct {
+    Enabled bool `conf:"enabled" default:"true"`
+  }
+
+`Validate()` cannot know which fields were set explicitly, so it fills every field that holds its zero value.
+
+Defaults inside a struct pointer are only applied when the struct is set by a source. With the `FillNilStructs()` option, nil struct pointers whose struct declares defaults are allocated, so that optional sections are filled with their defaults even if they are missing from every source.
 
 Mutual exclusion
 
@@ -244,6 +599,200 @@ This is not allowed:
     Level string `validate:"required" default:"warn"` // will result in an error
   }
 
+Validator
+
+Constraints that span several fields can be checked by implementing the `Validator` interface on the config struct or on any nested struct. `Validate()` is called after the environment and defaults have been processed and its error is reported under the path of the struct.
+
+  type TLS struct {
+    Enabled bool   `conf:"enabled"`
+    Cert    string `conf:"cert"`
+  }
+
+  func (t TLS) Validate() error {
+    if t.Enabled && t.Cert == "" {
+      return errors.New("cert is required when tls is enabled")
+    }
+    return nil
+  }
+
+Validate
+
+`Validate()` runs the default and validation pipeline on a struct that was populated programmatically or received over the network, without reading config files or the environment.
+
+  cfg := Config{Host: "example.com"}
+  err := confucius.Validate(&cfg)
+
+Environment and flags only
+
+Small tools that never have config files can use `LoadEnvFlags()`, which defines a flag for each field on a flag.FlagSet and loads the config from the environment and the command line, with the same tags and validations as `Load`. Flags take precedence over the environment.
+
+  err := confucius.LoadEnvFlags(&cfg, "mytool", flag.CommandLine) // mytool -server.host=example.com
+
+Flags
+
+Flags can also be layered over the files and the environment with `Load`. `FlagDefs()` describes a flag for each field, named after its path, and the option `FlagValues()` passes the values of the flags that were set, which take precedence over all other sources. The package github.com/hasanozgan/confucius/cobra does both for cobra commands, similar to viper's BindPFlags:
+
+  cmd := &cobra.Command{Use: "myapp"}
+  flags, err := confcobra.BindPFlags(cmd, &cfg, confucius.UseEnv("myapp"))
+  cmd.RunE = func(cmd *cobra.Command, args []string) error {
+    return confucius.Load(&cfg, confucius.UseEnv("myapp"), flags) // myapp --server.host=example.com
+  }
+
+Overrides
+
+`Override()` and `Overrides()` force fields to the given values after all other sources, including the flags, so tests and feature flag systems need not write files or change the process environment. Strings are parsed like environment variables and an override whose path matches no field fails the load.
+
+  err := confucius.Load(&cfg, confucius.Override("server.port", 9090), confucius.Override("timeout", "5s"))
+
+Providers
+
+Confucius can take part in the koanf ecosystem during a migration. `NewProvider()` returns a koanf provider of the merged values of the config files, and `FromProviders()` merges the values of koanf providers, or of any `MapProvider`, over the config files.
+
+  err := k.Load(confucius.NewProvider(confucius.File("config.yaml")), nil)
+  ...
+  err = confucius.Load(&cfg, confucius.FromProviders(confmap.Provider(defaults, ".")))
+
+Precedence
+
+By default the reader is merged first, then the config file, the profile files and the providers, and the environment, flags and overrides are applied last. `Precedence()` makes the order explicit, from the lowest to the highest precedence:
+
+  err := confucius.Load(&cfg, confucius.String(defaults, confucius.DecoderYaml), confucius.UseEnv("myapp"),
+    confucius.Precedence(confucius.SourceReader, confucius.SourceEnv, confucius.SourceFile))
+
+Config files are then only searched if `SourceFile` or `SourceProfile` is listed, and the environment does not override the fields set by the sources listed after it. An ambiguous configuration fails the load, such as a kind listed twice or a source in use whose kind is not listed.
+
+Bundles
+
+`Bundle()` packages the config file and its profile files into a single zip archive, so immutable deploy artifacts can carry their entire config tree, and `LoadBundle()` loads a config from such an archive.
+
+  err := confucius.Bundle(w, "config/config.yaml", "config/config.prod.yaml")
+  ...
+  err = confucius.LoadBundle(&cfg, r, confucius.Profiles("prod"))
+
+Sections
+
+With the `SubKey()` option only a section of the config is loaded into the struct, so a library can load its own section of a larger application config without knowing the whole schema. Environment variables keep the names of the whole config.
+
+  var serverCfg ServerConfig
+  err := confucius.Load(&serverCfg, confucius.SubKey("server"), confucius.UseEnv("myapp")) // MYAPP_SERVER_PORT
+
+Loaders
+
+A `Loader` holds a set of options and caches the layout of the config types, i.e. the fields of each struct type with their parsed tags, so that loading the same type again, e.g. on every reload, only walks the values of the config. It is safe for concurrent use.
+
+  loader := confucius.NewLoader(confucius.File("config.yaml"), confucius.UseEnv("myapp"))
+  err := loader.Load(&cfg)
+
+A Loader counts its loads and failures, and records the time of the last successful load, the last error and a hash of the loaded config, see `Stats()`. It implements expvar.Var, so dashboards can alert on failing reloads:
+
+  expvar.Publish("config", loader)
+
+Tracing
+
+With `WithSpans()` a span is started around each step of loading a config: the whole load, finding the files, decoding each file and the reader, reading each provider and processing the struct, so slow startups show up in traces. The package github.com/hasanozgan/confucius/otel adapts it to an OpenTelemetry tracer:
+
+  err := confucius.Load(&cfg, confucius.File("config.yaml"), confotel.WithTracer(otel.Tracer("myapp")))
+
+Preloading
+
+`Preload()` starts fetching the config sources in the background during bootstrap, and `Wait()` finishes loading them into a struct when the config is needed. `Ready()` signals when the sources have been fetched.
+
+  pending, err := confucius.Preload(ctx, confucius.File("config.yaml"))
+  ...
+  err = pending.Wait(&cfg)
+
+Single values
+
+`Get()` loads a single value at a path for quick one-off reads, e.g. of a feature flag, without declaring a struct. Files, profiles and the environment are found and merged as by `Load`.
+
+  enabled, err := confucius.Get[bool]("features.new_checkout", confucius.UseEnv("app"))
+
+Lists
+
+Config documents whose root is a list, e.g. a rules file, are loaded into a pointer to a slice. The tags and validations of the elements work as in a struct; in the environment and in errors the list is named `items`.
+
+  // - name: internal
+  // - name: blocked
+  //   action: deny
+  var rules []Rule
+  err := confucius.Load(&rules, confucius.File("rules.yaml"), confucius.UseEnv("rules")) // RULES_ITEMS_0_ACTION
+
+The elements of all documents of a multi-document YAML file are concatenated in order, and a document that is not a list is a single element, so a stream of resources as emitted by kustomize is loaded with an element per resource.
+
+Merge
+
+`Merge()` loads the configuration into a struct that is already populated. Fields that are absent from all sources keep their value, nested structs and maps are merged key by key, and slices set by a source replace the populated slice.
+
+  cfg := Config{Host: "localhost"}
+  err := confucius.Merge(&cfg, confucius.File("override.yaml"))
+
+Report
+
+`LoadWithReport()` loads a config like `Load` and also returns a report that describes how it was loaded. The report holds the duration of the load and the time spent in each stage (discover, fetch, decode, merge, env, defaults and validate), which helps to find out why startup is slow.
+
+  report, err := confucius.LoadWithReport(&cfg)
+  log.Println(report) // loaded in 1.2ms (discover 120µs, fetch 300µs, ...)
+
+The report also lists the paths of the fields decoded from the files and the reader (`Keys`), the keys in those sources that match no field (`Unused`) and the fields absent from them (`Unset`), as reported by mapstructure's Metadata. Callers that already work with mapstructure can pass a `*mapstructure.Metadata` to the `Metadata()` option to have it filled instead.
+
+  var md mapstructure.Metadata
+  err := confucius.Load(&cfg, confucius.Metadata(&md))
+
+To answer where a value came from, the report holds the source of each field that received a value: the default, the config file or a profile file with its path, the reader, an environment variable or a flag.
+
+  log.Println(report.Sources["server.port"]) // env MYAPP_SERVER_PORT
+
+`Explain()` runs the whole pipeline without touching the config and returns the resolved value, the source and the rules of every field, e.g. to power a `myapp config explain` command.
+
+  explanation, err := confucius.Explain(&cfg, confucius.UseEnv("myapp"))
+  fmt.Print(explanation) // server.port = 8080 (env MYAPP_SERVER_PORT) [required]
+
+Events
+
+To trace how a config is loaded, `WithLogger()` passes a structured `Event` to a function for each file found or not found, each decoder selected, each file merged, each field set from the environment, a flag or a default, and each validation failure. The events can be forwarded to any structured logger; the values of secret fields are redacted.
+
+  err := confucius.Load(&cfg, confucius.WithLogger(func(e confucius.Event) {
+    logger.Debug("config", "kind", e.Kind, "path", e.Path, "source", e.Source.String(), "value", e.Value)
+  }))
+
+Dump
+
+`Dump()` writes a loaded config as YAML, JSON or TOML, keyed by the names used in config files, e.g. to log the effective configuration at startup. Secret fields are masked with `***`.
+
+  err := confucius.Dump(&cfg, confucius.DecoderYaml, os.Stderr)
+
+`Handler()` serves the effective configuration as JSON over HTTP, with secrets masked and the source of each field as found by the load, e.g. at a debug endpoint.
+
+  report, err := confucius.LoadWithReport(&cfg, confucius.UseEnv("myapp"))
+  http.Handle("/debug/config", confucius.Handler(&cfg, report))
+
+`Diff()` compares two loaded configs and returns the paths whose values changed, e.g. to log what changed on reload or to compare environments. The values of secret fields are redacted.
+
+  for _, change := range confucius.Diff(&old, &cfg) {
+    log.Printf("%s: %s -> %s", change.Path, change.Old, change.New)
+  }
+
+`Skeleton()` generates an example config from the struct, with the default of each field, or its zero value, and comments that mark required fields. It keeps sample configs in sync with the struct.
+
+  b, err := confucius.Skeleton(&Config{}, confucius.DecoderYaml)
+
+`Docs()` renders a Markdown table of every key with its type, default, required flag, environment variable and the description from the `desc` tag, to generate ops documentation that does not rot.
+
+  type Config struct {
+    Port int `conf:"port" default:"8080" desc:"The port to listen on."`
+  }
+
+  b, err := confucius.Docs(&Config{}, confucius.UseEnv("myapp"))
+
+Snapshots
+
+`Snapshot()` serializes a loaded config into a compact, versioned JSON document that can be attached to crash reports. Fields tagged as secret, with `secret:"true"` or `conf:"name,secret"`, are left out. `RestoreSnapshot()` loads a snapshot back into a struct to reproduce issues locally.
+
+  b, err := confucius.Snapshot(&cfg)
+  ...
+  var restored Config
+  err = confucius.RestoreSnapshot(b, &restored)
+
 Errors
 
 A wrapped error `ErrFileNotFound` is returned when confucius is not able to find a config file to load. This can be useful for instance to fallback to a different configuration loading mechanism.
@@ -253,5 +802,36 @@ A wrapped error `ErrFileNotFound` is returned when confucius is not able to find
   if errors.Is(err, confucius.ErrFileNotFound) {
     // load config from elsewhere
   }
+
+The error is a `*NotFoundError` that lists the missing files, the absolute paths of the directories searched and the profile layout used, so it is clear why discovery failed.
+
+  "config.yaml" file(s) not found in "/srv/app", "/etc/app": file not found
+
+The errors of fields are returned as `*FieldError` values that hold the path of the field, the rule that failed and the offending value. They wrap sentinel errors such as `ErrRequired` and `ErrBadDefault`, so programs can branch on the kind of failure without parsing messages. `ErrUnsupportedExtension` is returned for files without a decoder. Values that do not match the type of their field, e.g. "abc" for an int, are reported with the path of the field like other field errors.
+
+A file that cannot be decoded is reported as a `*DecodeError` that holds the path of the file and, for JSON, the line and column of the error. YAML and TOML errors carry their line in the message.
+
+  config.json:3:11: invalid character ']' looking for beginning of value
+
+The values of secret fields, tagged with `secret:"true"` or `conf:"name,secret"`, are masked with `***` in error messages as they are in `Dump`, `Explain` and events, so errors can be logged safely.
+
+  pin: unable to set from env: strconv.ParseInt: parsing "***": invalid syntax
+
+  var fieldErr *confucius.FieldError
+  if errors.As(err, &fieldErr) && errors.Is(fieldErr, confucius.ErrRequired) {
+    log.Fatalf("missing setting %s", fieldErr.Path)
+  }
+
+With the `Lenient()` option confucius carries on when a stage fails and returns all errors together in a `*LoadError`, while the config holds everything that could be loaded. The errors of fields, whether their values could not be decoded or failed validation, are reported together as one error.
+
+  err := confucius.Load(&cfg, confucius.Lenient())
+  var loadErr *confucius.LoadError
+  if errors.As(err, &loadErr) {
+    // inspect cfg and loadErr.Errors
+  }
+
+By default a failed load may leave the config partially loaded. With the `Atomic()` option confucius loads into a copy of the config and only copies it back once the load succeeded, so the config is never observed half loaded.
+
+  err := confucius.Load(&cfg, confucius.Atomic()) // cfg is unchanged if err != nil
 */
 package confucius
@@ -0,0 +1,162 @@
+package confucius
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// docsRow is a row of the table generated by Docs.
+type docsRow struct {
+	path     string
+	typ      string
+	def      string
+	required bool
+	desc     string
+}
+
+// Docs renders a Markdown table that documents every key of the struct type
+// of cfg: its type, default, whether it is required, the environment
+// variable that sets it and its description, taken from the `desc` tag.
+//
+//   type Config struct {
+//     Port int `conf:"port" default:"8080" desc:"The port to listen on."`
+//   }
+//
+//   b, err := confucius.Docs(&Config{}, confucius.UseEnv("myapp"))
+//
+//   | Key | Type | Default | Required | Env | Description |
+//   | --- | ---- | ------- | -------- | --- | ----------- |
+//   | `port` | `int` | `8080` |  | `MYAPP_PORT` | The port to listen on. |
+//
+// The Env column is only included with the `UseEnv` option. Elements of
+// lists are documented once as `name[N]` and values of maps as `name[KEY]`.
+// The defaults of secret fields are redacted. The allowed values of fields
+// with a `oneof` rule or an `Enum` type are added to their description.
+// The parameter `cfg` must be a pointer to a struct; only its type is used.
+func Docs(cfg interface{}, options ...Option) ([]byte, error) {
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if !isStructPtr(cfg) {
+		return nil, fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	var rows []docsRow
+	c.docsFields(reflect.TypeOf(cfg).Elem(), "", &rows)
+
+	var buf bytes.Buffer
+	header := []string{"Key", "Type", "Default", "Required"}
+	if c.useEnv {
+		header = append(header, "Env")
+	}
+	header = append(header, "Description")
+	writeDocsRow(&buf, header)
+	for i := range header {
+		header[i] = strings.Repeat("-", len(header[i]))
+	}
+	writeDocsRow(&buf, header)
+
+	for _, row := range rows {
+		cells := []string{docsCode(row.path), docsCode(row.typ), docsCode(row.def), ""}
+		if row.required {
+			cells[3] = "yes"
+		}
+		if c.useEnv {
+			cells = append(cells, docsCode(c.formatEnvKey(row.path)))
+		}
+		cells = append(cells, row.desc)
+		writeDocsRow(&buf, cells)
+	}
+	return buf.Bytes(), nil
+}
+
+// docsFields adds a row for each field of the struct type t that holds a
+// value to rows. path is the path of t.
+func (c *confucius) docsFields(t reflect.Type, path string, rows *[]docsRow) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if isSquashed(sf) {
+			c.docsFields(ft, path, rows)
+			continue
+		}
+
+		st := parseTag(sf.Tag, c.tag)
+		if c.legacyTags {
+			st.applyLegacy()
+		}
+		st.secret = st.secret || isSecretType(sf.Type)
+		name := sf.Name
+		if st.altName != "" {
+			name = st.altName
+		}
+		fieldPath := joinPath(path, name)
+
+		elem, suffix := ft, ""
+		switch ft.Kind() {
+		case reflect.Slice, reflect.Array:
+			elem, suffix = ft.Elem(), "[N]"
+		case reflect.Map:
+			elem, suffix = ft.Elem(), "[KEY]"
+		}
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+
+		switch {
+		case ft.Kind() == reflect.Struct && !isLeafStruct(ft):
+			c.docsFields(ft, fieldPath, rows)
+		case suffix != "" && elem.Kind() == reflect.Struct && !isLeafStruct(elem):
+			c.docsFields(elem, fieldPath+suffix, rows)
+		default:
+			row := docsRow{path: fieldPath, typ: sf.Type.String(), required: st.required, desc: st.desc}
+			if values := allowedValues(sf.Type, st); len(values) > 0 {
+				row.desc = strings.TrimSpace(row.desc + " One of " + docsValues(values) + ".")
+			}
+			if st.setDefault {
+				row.def = displayValue(st.defaultVal, st)
+			}
+			*rows = append(*rows, row)
+		}
+	}
+}
+
+// docsValues formats the allowed values of a field as inline code.
+func docsValues(values []string) string {
+	codes := make([]string, len(values))
+	for i, v := range values {
+		codes[i] = docsCode(v)
+	}
+	return strings.Join(codes, ", ")
+}
+
+// docsCode formats s as inline code, unless it is empty.
+func docsCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + s + "`"
+}
+
+// writeDocsRow writes the cells of a row of a Markdown table, escaping the
+// pipes in them.
+func writeDocsRow(buf *bytes.Buffer, cells []string) {
+	buf.WriteString("|")
+	for _, cell := range cells {
+		buf.WriteString(" ")
+		buf.WriteString(strings.ReplaceAll(cell, "|", `\|`))
+		buf.WriteString(" |")
+	}
+	buf.WriteString("\n")
+}
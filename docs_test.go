@@ -0,0 +1,47 @@
+package confucius
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Docs(t *testing.T) {
+	type Config struct {
+		Port    int           `conf:"port" default:"8080" desc:"The port to listen on."`
+		Timeout time.Duration `conf:"timeout" validate:"required" desc:"Timeout of requests, e.g. 5s | 1m."`
+		Token   string        `conf:"token,secret" default:"t0k3n"`
+		Server  struct {
+			Hosts []string `conf:"hosts"`
+		} `conf:"server"`
+		Backends []struct {
+			Name string `conf:"name" validate:"required"`
+		} `conf:"backends"`
+		Tenants map[string]struct {
+			Quota Size `conf:"quota"`
+		} `conf:"tenants"`
+	}
+
+	b, err := Docs(&Config{}, UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := "| Key | Type | Default | Required | Env | Description |\n" +
+		"| --- | ---- | ------- | -------- | --- | ----------- |\n" +
+		"| `port` | `int` | `8080` |  | `APP_PORT` | The port to listen on. |\n" +
+		"| `timeout` | `time.Duration` |  | yes | `APP_TIMEOUT` | Timeout of requests, e.g. 5s \\| 1m. |\n" +
+		"| `token` | `string` | `***` |  | `APP_TOKEN` |  |\n" +
+		"| `server.hosts` | `[]string` |  |  | `APP_SERVER_HOSTS` |  |\n" +
+		"| `backends[N].name` | `string` |  | yes | `APP_BACKENDS_N_NAME` |  |\n" +
+		"| `tenants[KEY].quota` | `confucius.Size` |  |  | `APP_TENANTS_KEY_QUOTA` |  |\n"
+	if string(b) != want {
+		t.Errorf("Docs() == %q, expected %q", b, want)
+	}
+
+	b, err = Docs(&Config{})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if want := "| Key | Type | Default | Required | Description |\n"; string(b[:len(want)]) != want {
+		t.Errorf("Docs() == %q, expected no Env column", b)
+	}
+}
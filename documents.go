@@ -0,0 +1,139 @@
+package confucius
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/imdario/mergo"
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileKey is the key with which the documents of a multi-document YAML
+// file declare the profiles they belong to, see `ProfileDocuments`.
+const ProfileKey = "profile"
+
+// decodeYamlDocuments decodes the documents of a YAML stream. Only the first
+// document is decoded, unless ProfileDocuments is used: then a single
+// document is returned as is and of multiple documents, the documents that
+// declare no profile are merged in order, followed by those that declare an
+// active profile with ProfileKey in the order of the profiles.
+func (c *confucius) decodeYamlDocuments(reader io.Reader) (decodedObject, error) {
+	dec := yaml.NewDecoder(reader)
+	var docs []decodedObject
+	for {
+		doc := make(decodedObject)
+		err := dec.Decode(&doc)
+		if err == io.EOF && len(docs) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, normalizeValue(doc).(decodedObject))
+		if !c.profileDocuments {
+			break
+		}
+	}
+	if len(docs) == 1 {
+		return docs[0], nil
+	}
+
+	// the rank of a document is the index of the last active profile it
+	// declares, or -1 if it declares none.
+	var active []decodedObject
+	var ranks []int
+	for i, doc := range docs {
+		rank, err := c.documentRank(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+		if rank < -1 {
+			continue
+		}
+		delete(doc, ProfileKey)
+		active = append(active, doc)
+		ranks = append(ranks, rank)
+	}
+	order := make([]int, len(active))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return ranks[order[i]] < ranks[order[j]]
+	})
+
+	vals := make(decodedObject)
+	for _, i := range order {
+		if err := mergo.Merge(&vals, active[i], mergo.WithOverride, mergo.WithTypeCheck); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// decodeYamlList decodes the documents of a YAML stream whose root is a
+// list. The elements of all documents are concatenated in order and a
+// document whose root is not a list is a single element, so that a stream
+// of resources, as emitted by kustomize, is loaded as a list with an
+// element per resource. Empty documents are skipped.
+func decodeYamlList(reader io.Reader) (decodedObject, error) {
+	dec := yaml.NewDecoder(reader)
+	var roots []interface{}
+	for {
+		var root interface{}
+		err := dec.Decode(&root)
+		if err == io.EOF && len(roots) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, normalizeValue(root))
+	}
+	if len(roots) == 1 {
+		return wrapRootList(roots[0])
+	}
+
+	items := []interface{}{}
+	for _, root := range roots {
+		switch root := root.(type) {
+		case nil:
+		case []interface{}:
+			items = append(items, root...)
+		default:
+			items = append(items, root)
+		}
+	}
+	return decodedObject{rootListKey: items}, nil
+}
+
+// documentRank returns -1 if doc declares no profile, the index in the
+// active profiles of the last active profile that doc declares, or -2 if
+// doc declares no active profile. A document may declare a single profile
+// or a list.
+func (c *confucius) documentRank(doc decodedObject) (int, error) {
+	var profiles []interface{}
+	switch val := doc[ProfileKey].(type) {
+	case nil:
+		return -1, nil
+	case []interface{}:
+		profiles = val
+	default:
+		profiles = []interface{}{val}
+	}
+
+	rank := -2
+	for _, profile := range profiles {
+		name, ok := profile.(string)
+		if !ok {
+			return 0, fmt.Errorf("%s must be a string or a list of strings", ProfileKey)
+		}
+		for i, active := range c.profiles {
+			if name == active && i > rank {
+				rank = i
+			}
+		}
+	}
+	return rank, nil
+}
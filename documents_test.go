@@ -0,0 +1,184 @@
+package confucius
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_confucius_Load_ProfileDocuments(t *testing.T) {
+	type Config struct {
+		Host    string `conf:"host"`
+		Port    int    `conf:"port"`
+		Debug   bool   `conf:"debug"`
+		Profile string `conf:"profile"`
+	}
+
+	content := `
+host: localhost
+port: 80
+---
+profile: dev
+debug: true
+---
+profile: [prod, staging]
+host: example.com
+---
+profile: prod
+port: 443
+`
+	for _, tc := range []struct {
+		profiles []string
+		want     Config
+	}{
+		{nil, Config{Host: "localhost", Port: 80}},
+		{[]string{"dev"}, Config{Host: "localhost", Port: 80, Debug: true}},
+		{[]string{"staging"}, Config{Host: "example.com", Port: 80}},
+		{[]string{"prod"}, Config{Host: "example.com", Port: 443}},
+	} {
+		c := defaultConfucius()
+		c.profiles = tc.profiles
+		c.profileDocuments = true
+		vals, err := c.decodeReader(strings.NewReader(content), DecoderYaml)
+		if err != nil {
+			t.Fatalf("%v: unexpected err: %v", tc.profiles, err)
+		}
+		var cfg Config
+		if err := c.decodeMap(vals, &cfg); err != nil {
+			t.Fatalf("%v: unexpected err: %v", tc.profiles, err)
+		}
+		if cfg != tc.want {
+			t.Errorf("%v: cfg == %+v, expected %+v", tc.profiles, cfg, tc.want)
+		}
+	}
+
+	var cfg Config
+	if err := Load(&cfg, String(content, DecoderYaml), Profiles("prod")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if want := (Config{Host: "localhost", Port: 80}); cfg != want {
+		t.Errorf("cfg == %+v, expected only the first document without ProfileDocuments", cfg)
+	}
+	cfg = Config{}
+	if err := Load(&cfg, String("host: a\n---\nprofile: prod\n", DecoderYaml)); err != nil || cfg.Profile != "" {
+		t.Errorf("cfg == %+v, err == %v, expected the first document without ProfileDocuments", cfg, err)
+	}
+
+	ordered := "profile: b\nhost: b\n---\nprofile: a\nhost: a\n"
+	for _, tc := range []struct {
+		profiles []string
+		want     string
+	}{
+		{[]string{"a", "b"}, "b"},
+		{[]string{"b", "a"}, "a"},
+	} {
+		cfg = Config{}
+		if err := Load(&cfg, String(ordered, DecoderYaml), Profiles(tc.profiles...), ProfileDocuments()); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Host != tc.want {
+			t.Errorf("%v: host == %q, expected %q from the documents merged in the order of the profiles", tc.profiles, cfg.Host, tc.want)
+		}
+	}
+
+	cfg = Config{}
+	if err := Load(&cfg, String("profile: dev\nhost: localhost\n", DecoderYaml), ProfileDocuments()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Profile != "dev" || cfg.Host != "localhost" {
+		t.Errorf("cfg == %+v, expected a single document to be loaded as is", cfg)
+	}
+
+	if err := Load(&cfg, String("host: a\n---\nprofile: {x: 1}\n", DecoderYaml), ProfileDocuments()); err == nil {
+		t.Errorf("expected err for an invalid profile")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	cfg = Config{}
+	if err := Load(&cfg, Dirs(dir), Profiles("prod"), ProfileDocuments()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if want := (Config{Host: "example.com", Port: 443}); cfg != want {
+		t.Errorf("cfg == %+v, expected %+v", cfg, want)
+	}
+
+	if err := Load(&cfg, Dirs(dir), Profiles("prod")); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected ErrFileNotFound without ProfileDocuments, got %v", err)
+	}
+}
+
+func Test_confucius_Load_YamlAnchors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml": `
+defaults: &defaults
+  host: localhost
+  port: 80
+  labels: {team: core}
+server:
+  <<: *defaults
+  port: 8080
+codes:
+  404: not found
+  500: internal
+`,
+		"config.prod.yaml": `
+server:
+  labels: {tier: gold}
+codes:
+  503: unavailable
+`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Server struct {
+		Host   string            `conf:"host"`
+		Port   int               `conf:"port"`
+		Labels map[string]string `conf:"labels"`
+	}
+	type Config struct {
+		Server Server         `conf:"server"`
+		Codes  map[int]string `conf:"codes"`
+	}
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg, Dirs(dir), Profiles("prod"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := Config{
+		Server: Server{Host: "localhost", Port: 8080, Labels: map[string]string{"team": "core", "tier": "gold"}},
+		Codes:  map[int]string{404: "not found", 500: "internal", 503: "unavailable"},
+	}
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+	for path, kind := range map[string]string{
+		"server.host":         SourceFile,
+		"server.labels[team]": SourceFile,
+		"server.labels[tier]": SourceProfile,
+		"codes[503]":          SourceProfile,
+	} {
+		if got := report.Sources[path].Kind; got != kind {
+			t.Errorf("want source %s of %s, got %q", kind, path, got)
+		}
+	}
+
+	b, err := NewProvider(Dirs(dir), Profiles("prod")).ReadBytes()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !strings.Contains(string(b), `"codes":{"404":"not found","500":"internal","503":"unavailable"}`) {
+		t.Errorf("unexpected provider values: %s", b)
+	}
+}
@@ -0,0 +1,53 @@
+package confucius
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// Dump writes a loaded config to w in the given format, keyed by the names
+// used in config files, e.g. to log the effective configuration at startup.
+// The parameter `cfg` must be a pointer to a struct.
+//
+//   confucius.Load(&cfg, confucius.UseEnv("myapp"))
+//   confucius.Dump(&cfg, confucius.DecoderYaml, os.Stderr)
+//
+// The values of secret fields (see the `secret` tag) are masked with
+// `RedactedValue`. Options that relate to tags (e.g. `Tag` or `TimeLayout`)
+// are applied, so that the output can be loaded back with the same options.
+func Dump(cfg interface{}, format Decoder, w io.Writer, options ...Option) error {
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if !isStructPtr(cfg) {
+		return fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	e := c.newValueEncoder()
+	e.mask = true
+	values, _ := e.encode(reflect.ValueOf(cfg), "")
+	return encodeValues(w, format, values.(map[string]interface{}))
+}
+
+// encodeValues writes the plain values m to w in the given format.
+func encodeValues(w io.Writer, format Decoder, m map[string]interface{}) error {
+	switch format {
+	case DecoderYaml, DecoderYml:
+		return yaml.NewEncoder(w).Encode(m)
+	case DecoderJSON, DecoderJSONC:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(m)
+	case DecoderToml:
+		return toml.NewEncoder(w).Encode(m)
+	default:
+		return fmt.Errorf("%w %s", ErrUnsupportedExtension, format)
+	}
+}
@@ -0,0 +1,52 @@
+package confucius
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Dump(t *testing.T) {
+	type Config struct {
+		Name     string        `conf:"name"`
+		Timeout  time.Duration `conf:"timeout" default:"5s"`
+		Password string        `conf:"password" secret:"true"`
+		Server   struct {
+			Hosts []string `conf:"hosts"`
+		} `conf:"server"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"name": "app", "password": "hunter2", "server": {"hosts": ["a", "b"]}}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for format, want := range map[Decoder]string{
+		DecoderYaml: "name: app\npassword: '***'\nserver:\n  hosts:\n  - a\n  - b\ntimeout: 5s\n",
+		DecoderJSON: "{\n  \"name\": \"app\",\n  \"password\": \"***\",\n  \"server\": {\n    \"hosts\": [\n      \"a\",\n      \"b\"\n    ]\n  },\n  \"timeout\": \"5s\"\n}\n",
+		DecoderToml: "name = 'app'\npassword = '***'\ntimeout = '5s'\n\n[server]\nhosts = ['a', 'b']\n",
+	} {
+		var buf bytes.Buffer
+		if err := Dump(&cfg, format, &buf); err != nil {
+			t.Fatalf("%s: unexpected err: %v", format, err)
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("%s: Dump() == %q, expected %q", format, got, want)
+		}
+
+		var loaded Config
+		if err := Load(&loaded, String(buf.String(), format)); err != nil {
+			t.Fatalf("%s: unexpected err loading the dump: %v", format, err)
+		}
+		if loaded.Name != cfg.Name || loaded.Timeout != cfg.Timeout || strings.Join(loaded.Server.Hosts, ",") != "a,b" {
+			t.Errorf("%s: loaded == %+v, expected %+v", format, loaded, cfg)
+		}
+	}
+
+	if err := Dump(&cfg, Decoder(".ini"), &bytes.Buffer{}); !errors.Is(err, ErrUnsupportedExtension) {
+		t.Errorf("err == %v, expected ErrUnsupportedExtension", err)
+	}
+}
@@ -0,0 +1,141 @@
+package confucius
+
+import (
+	"encoding"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// RedactedValue is the value that secret fields are masked with.
+const RedactedValue = "***"
+
+// valueMarshaler is implemented by the field types of confucius that are
+// written to config files in a different shape than their Go value.
+type valueMarshaler interface {
+	marshalValue() interface{}
+}
+
+// valueEncoder converts a loaded config struct back into plain values
+// (maps, slices and scalars) keyed by the names used in config files.
+type valueEncoder struct {
+	tag        string
+	tags       *tagCache // the cache of parsed tags, if any.
+	timeLayout string
+	mask       bool     // mask secret fields with RedactedValue instead of omitting them.
+	redacted   []string // paths of the secret fields that were redacted.
+}
+
+func (c *confucius) newValueEncoder() *valueEncoder {
+	return &valueEncoder{tag: c.tag, tags: c.tags, timeLayout: c.timeLayout}
+}
+
+// encode converts v into plain values. path is the path of v and is used
+// to record redacted fields. The returned bool is false if v is nil.
+func (e *valueEncoder) encode(v reflect.Value, path string) (interface{}, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return nil, false
+	}
+
+	if v.CanInterface() {
+		switch val := v.Interface().(type) {
+		case time.Time:
+			return val.Format(e.timeLayout), true
+		case time.Duration:
+			return val.String(), true
+		case url.URL:
+			return val.String(), true
+		case net.IPNet:
+			return val.String(), true
+		case optional:
+			if !val.isSet() {
+				return nil, false
+			}
+			return e.encode(val.elem(), path)
+		case valueMarshaler:
+			return val.marshalValue(), true
+		case encoding.TextMarshaler:
+			if text, err := val.MarshalText(); err == nil {
+				return string(text), true
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			sf := v.Type().Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			if isSquashed(sf) {
+				embedded, _ := e.encode(v.Field(i), path)
+				for name, val := range embedded.(map[string]interface{}) {
+					m[name] = val
+				}
+				continue
+			}
+			st := e.tags.parse(sf.Tag, e.tag)
+			name := sf.Name
+			if st.altName != "" {
+				name = st.altName
+			}
+			fieldPath := joinPath(path, name)
+
+			if st.secret || isSecretType(sf.Type) {
+				e.redacted = append(e.redacted, fieldPath)
+				if e.mask {
+					m[name] = RedactedValue
+				}
+				continue
+			}
+			if val, ok := e.encode(v.Field(i), fieldPath); ok {
+				m[name] = val
+			}
+		}
+		return m, true
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			val, _ := e.encode(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+			s = append(s, val)
+		}
+		return s, true
+	case reflect.Map:
+		m := make(map[string]interface{}, v.Len())
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			name := fmt.Sprint(key.Interface())
+			if val, ok := e.encode(v.MapIndex(key), joinPath(path, name)); ok {
+				m[name] = val
+			}
+		}
+		return m, true
+	}
+
+	if !v.CanInterface() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// joinPath joins the path of a struct and the name of one of its fields.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,48 @@
+package confucius
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_valueEncoder_encode(t *testing.T) {
+	type Inner struct {
+		Key    string `conf:"key" secret:"true"`
+		Public string `conf:"public"`
+	}
+	cfg := struct {
+		Inner   Inner
+		Items   []*Inner `conf:"items"`
+		IP      net.IP   `conf:"ip"`
+		Date    time.Time
+		Nil     *Inner
+		private int
+	}{
+		Inner: Inner{Key: "k", Public: "p"},
+		Items: []*Inner{nil, {Key: "x"}},
+		IP:    net.ParseIP("10.0.0.1"),
+		Date:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	e := defaultConfucius().newValueEncoder()
+	e.mask = true
+	got, ok := e.encode(reflect.ValueOf(&cfg), "")
+	if !ok {
+		t.Fatalf("encode() returned nil")
+	}
+
+	want := map[string]interface{}{
+		"Inner": map[string]interface{}{"key": RedactedValue, "public": "p"},
+		"items": []interface{}{nil, map[string]interface{}{"key": RedactedValue, "public": ""}},
+		"ip":    "10.0.0.1",
+		"Date":  "2020-01-01T00:00:00Z",
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("\nwant %+v\ngot %+v", want, got)
+	}
+	if want := []string{"Inner.key", "items[1].key"}; !reflect.DeepEqual(want, e.redacted) {
+		t.Errorf("e.redacted == %v, expected %v", e.redacted, want)
+	}
+}
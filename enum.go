@@ -0,0 +1,116 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Enum is implemented by types whose values are limited to a fixed set, e.g.
+// a log level. Fields of such types are validated as if they were tagged
+// `oneof` with the values returned by Enum, which are also listed by `Docs`,
+// `Skeleton` and `Explain`.
+//
+//   type Level string
+//
+//   func (Level) Enum() []string { return []string{"debug", "info", "error"} }
+//
+// Values are compared by their formatting with fmt, so types based on
+// integers can implement `String` to be set by name.
+type Enum interface {
+	Enum() []string
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// enumValues returns the values of the Enum method of t, or of the element
+// type of t if t is a slice or an array. It returns nil if neither
+// implements Enum.
+func enumValues(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+	if !reflect.PtrTo(t).Implements(enumType) {
+		return nil
+	}
+	return reflect.New(t).Interface().(Enum).Enum()
+}
+
+// allowedValues returns the values that a field of type t with the tag st
+// may hold: the values of its oneof rule, or else those of its Enum type.
+func allowedValues(t reflect.Type, st structTag) []string {
+	for _, r := range st.rules {
+		if r.name == "oneof" && !r.warn {
+			return strings.Fields(r.param)
+		}
+	}
+	return enumValues(t)
+}
+
+// oneofRule fails if the field is set to a value that is not among the space
+// separated values in param, e.g. `oneof=debug info error`. Empty values are
+// left to the required rule.
+func oneofRule(f *field, param string) error {
+	allowed := strings.Fields(param)
+	if len(allowed) == 0 {
+		return fmt.Errorf("oneof: parameter must list values")
+	}
+	return checkOneof(f.v, allowed)
+}
+
+// checkOneof fails if v, or an element of v if it is a slice or an array,
+// is set to a value that is not allowed. Elements that are flattened into
+// fields of their own are left to be checked as such.
+func checkOneof(v reflect.Value, allowed []string) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		switch v.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkOneof(v.Index(i), allowed); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if isZero(v) {
+		return nil
+	}
+	if s := formatValue(v); !containsString(allowed, s) {
+		return fmt.Errorf("%q is not one of %s", s, strings.Join(allowed, ", "))
+	}
+	return nil
+}
+
+// checkEnum checks a field whose type implements Enum against its values,
+// unless the field has a oneof rule that takes precedence.
+func checkEnum(f *field) error {
+	for _, r := range f.rules {
+		if r.name == "oneof" && !r.warn {
+			return nil
+		}
+	}
+	allowed := enumValues(f.t)
+	if allowed == nil {
+		return nil
+	}
+	if err := checkOneof(f.v, allowed); err != nil {
+		return &FieldError{Rule: "oneof", Value: ruleValue(f), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package confucius
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testLogLevel string
+
+func (testLogLevel) Enum() []string { return []string{"debug", "info", "error"} }
+
+type testColor int
+
+func (c testColor) String() string { return []string{"", "red", "green"}[c] }
+
+func (*testColor) Enum() []string { return []string{"red", "green"} }
+
+func Test_confucius_Load_Oneof(t *testing.T) {
+	type Config struct {
+		Level   testLogLevel   `conf:"level"`
+		Levels  []testLogLevel `conf:"levels"`
+		Format  string         `conf:"format" validate:"oneof=json text"`
+		Formats []string       `conf:"formats" validate:"oneof=json text"`
+		Strict  testLogLevel   `conf:"strict" validate:"oneof=error"`
+		Color   *testColor     `conf:"color"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  string
+		wantErr []string
+	}{
+		{name: "empty", config: `{}`},
+		{name: "valid", config: `{"level": "info", "levels": ["debug", "error"], "format": "json", "formats": ["text"], "strict": "error", "color": 2}`},
+		{name: "invalid enum", config: `{"level": "trace"}`, wantErr: []string{"level"}},
+		{name: "invalid enum element", config: `{"levels": ["info", "trace"]}`, wantErr: []string{"levels"}},
+		{name: "invalid oneof", config: `{"format": "xml"}`, wantErr: []string{"format"}},
+		{name: "invalid oneof element", config: `{"formats": ["json", "xml"]}`, wantErr: []string{"formats"}},
+		{name: "oneof overrides enum", config: `{"strict": "info"}`, wantErr: []string{"strict"}},
+		{name: "unset stringer", config: `{"color": 0}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := Load(&cfg, String(tc.config, DecoderJSON))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(fieldErrors)
+			if !ok || len(fieldErrs) != len(tc.wantErr) {
+				t.Fatalf("want errors for %v, got %v", tc.wantErr, err)
+			}
+			for _, path := range tc.wantErr {
+				var fieldErr *FieldError
+				if !errors.As(fieldErrs[path], &fieldErr) || fieldErr.Rule != "oneof" {
+					t.Errorf("want oneof error for %s, got %v", path, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_Docs_Enum(t *testing.T) {
+	type Config struct {
+		Level  testLogLevel `conf:"level" desc:"The log level."`
+		Format string       `conf:"format" validate:"oneof=json text"`
+	}
+
+	b, err := Docs(&Config{})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	for _, want := range []string{
+		"| `level` | `confucius.testLogLevel` |  |  | The log level. One of `debug`, `info`, `error`. |",
+		"| `format` | `string` |  |  | One of `json`, `text`. |",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("want %q in:\n%s", want, b)
+		}
+	}
+}
+
+func Test_Skeleton_Enum(t *testing.T) {
+	type Config struct {
+		Level testLogLevel `conf:"level" default:"info"`
+	}
+
+	b, err := Skeleton(&Config{}, DecoderYaml)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if want := "# oneof=debug info error, default=info\nlevel: info\n"; string(b) != want {
+		t.Fatalf("want %q, got %q", want, b)
+	}
+}
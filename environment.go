@@ -0,0 +1,80 @@
+package confucius
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	// EnvironmentDev is the preset name of development environments.
+	EnvironmentDev = "dev"
+	// EnvironmentStaging is the preset name of staging environments.
+	EnvironmentStaging = "staging"
+	// EnvironmentProd is the preset name of production environments.
+	EnvironmentProd = "prod"
+)
+
+// environmentVariables are the environment variables that are inspected,
+// in order, to detect the execution environment.
+var environmentVariables = []string{"ENV", "APP_ENV", "ENVIRONMENT", "GO_ENV"}
+
+// kubernetesNamespaceFile is the file mounted into every pod that contains
+// the namespace of the pod.
+var kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// environmentAliases maps the commonly used environment names to presets.
+var environmentAliases = map[string]string{
+	"dev":         EnvironmentDev,
+	"develop":     EnvironmentDev,
+	"development": EnvironmentDev,
+	"local":       EnvironmentDev,
+	"stage":       EnvironmentStaging,
+	"staging":     EnvironmentStaging,
+	"stg":         EnvironmentStaging,
+	"prod":        EnvironmentProd,
+	"production":  EnvironmentProd,
+	"prd":         EnvironmentProd,
+}
+
+// detectEnvironment infers the execution environment. The environment
+// variables are checked first, then the Kubernetes namespace of the pod.
+// An empty string is returned when the environment cannot be inferred.
+func detectEnvironment() string {
+	for _, key := range environmentVariables {
+		if val, ok := os.LookupEnv(key); ok && strings.TrimSpace(val) != "" {
+			return normalizeEnvironment(val)
+		}
+	}
+
+	if _, ok := os.LookupEnv("KUBERNETES_SERVICE_HOST"); ok {
+		namespace := os.Getenv("POD_NAMESPACE")
+		if namespace == "" {
+			if b, err := ioutil.ReadFile(kubernetesNamespaceFile); err == nil {
+				namespace = string(b)
+			}
+		}
+		namespace = strings.ToLower(strings.TrimSpace(namespace))
+		for _, part := range strings.FieldsFunc(namespace, isNameSeparator) {
+			if env, ok := environmentAliases[part]; ok {
+				return env
+			}
+		}
+	}
+
+	return ""
+}
+
+// normalizeEnvironment maps well known aliases of an environment name
+// to its preset (e.g. production -> prod). Unknown names are lower-cased.
+func normalizeEnvironment(env string) string {
+	env = strings.ToLower(strings.TrimSpace(env))
+	if preset, ok := environmentAliases[env]; ok {
+		return preset
+	}
+	return env
+}
+
+func isNameSeparator(r rune) bool {
+	return r == '-' || r == '_' || r == '.'
+}
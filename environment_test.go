@@ -0,0 +1,97 @@
+package confucius
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_detectEnvironment(t *testing.T) {
+	namespaceFile := filepath.Join(t.TempDir(), "namespace")
+	if err := ioutil.WriteFile(namespaceFile, []byte("payments-production\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer func(path string) { kubernetesNamespaceFile = path }(kubernetesNamespaceFile)
+	kubernetesNamespaceFile = namespaceFile
+
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{name: "nothing set", env: map[string]string{}, want: ""},
+		{name: "ENV", env: map[string]string{"ENV": "Production"}, want: EnvironmentProd},
+		{name: "APP_ENV", env: map[string]string{"APP_ENV": "stage"}, want: EnvironmentStaging},
+		{name: "ENV wins over APP_ENV", env: map[string]string{"ENV": "dev", "APP_ENV": "prod"}, want: EnvironmentDev},
+		{name: "unknown name", env: map[string]string{"GO_ENV": "QA"}, want: "qa"},
+		{name: "kubernetes namespace file", env: map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"}, want: EnvironmentProd},
+		{
+			name: "kubernetes pod namespace",
+			env:  map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "POD_NAMESPACE": "shop-stg"},
+			want: EnvironmentStaging,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			for key, val := range tc.env {
+				setenv(t, key, val)
+			}
+			defer os.Clearenv()
+
+			if got := detectEnvironment(); got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_DetectEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml":         "name: app\nendpoint: \"https://${env}.example.com\"\n",
+		"config.staging.yaml": "name: staging-app\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Config struct {
+		Name     string `conf:"name"`
+		Endpoint string `conf:"endpoint"`
+	}
+
+	t.Run("profile of environment exists", func(t *testing.T) {
+		setenv(t, "APP_ENV", "staging")
+		defer os.Unsetenv("APP_ENV")
+
+		var cfg Config
+		if err := Load(&cfg, Dirs(dir), DetectEnvironment()); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Name != "staging-app" {
+			t.Errorf("cfg.Name == %q, expected %q", cfg.Name, "staging-app")
+		}
+		if cfg.Endpoint != "https://staging.example.com" {
+			t.Errorf("cfg.Endpoint == %q, expected %q", cfg.Endpoint, "https://staging.example.com")
+		}
+	})
+
+	t.Run("profile of environment is missing", func(t *testing.T) {
+		setenv(t, "APP_ENV", "production")
+		defer os.Unsetenv("APP_ENV")
+
+		var cfg Config
+		if err := Load(&cfg, Dirs(dir), DetectEnvironment()); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Name != "app" {
+			t.Errorf("cfg.Name == %q, expected %q", cfg.Name, "app")
+		}
+		if cfg.Endpoint != "https://prod.example.com" {
+			t.Errorf("cfg.Endpoint == %q, expected %q", cfg.Endpoint, "https://prod.example.com")
+		}
+	})
+}
@@ -1,15 +1,183 @@
 package confucius
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pelletier/go-toml/v2"
 )
 
 // ErrFileNotFound is returned as a wrapped error by `Load` when the config file is
 // not found in the given search dirs.
 var ErrFileNotFound = fmt.Errorf("file not found")
 
+var (
+	// ErrRequired is wrapped by the errors of fields that are required but
+	// not set, including by the required_if and required_unless rules.
+	ErrRequired = errors.New("required validation failed")
+
+	// ErrBadDefault is wrapped by the errors of fields whose default value
+	// cannot be set.
+	ErrBadDefault = errors.New("unable to set default")
+
+	// ErrUnsupportedExtension is wrapped by the error returned when a file or
+	// reader has an extension that no decoder is registered for.
+	ErrUnsupportedExtension = errors.New("unsupported file extension")
+
+	// ErrInsecureFileMode is wrapped by the error returned when a config
+	// file has more permissions than allowed by `RequireFileMode`.
+	ErrInsecureFileMode = errors.New("insecure file mode")
+)
+
+// FieldError is the error of a single field of the config struct. The
+// errors returned by `Load` for fields can be inspected with errors.As:
+//
+//   var fieldErr *confucius.FieldError
+//   if errors.As(err, &fieldErr) {
+//     log.Printf("%s failed %s with %q", fieldErr.Path, fieldErr.Rule, fieldErr.Value)
+//   }
+//
+// Use errors.Is with `ErrRequired` or `ErrBadDefault` to branch on the kind
+// of failure. When several fields fail, errors.As finds the first one in
+// the order of their paths.
+type FieldError struct {
+	Path  string // the path of the field, e.g. "server.port".
+	Rule  string // the rule that failed, e.g. "required" or "default", if any.
+	Value string // the offending value, if any; secrets are redacted.
+	Err   error  // the underlying error.
+}
+
+// Error formats the error with the path of the field, if set.
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// NotFoundError is returned by `Load` when config files are not found. It
+// lists where confucius looked for them, so that it is clear why discovery
+// failed. It matches `ErrFileNotFound` with errors.Is.
+//
+//   var notFound *confucius.NotFoundError
+//   if errors.As(err, &notFound) {
+//     log.Printf("%v not found in %v", notFound.Files, notFound.Dirs)
+//   }
+type NotFoundError struct {
+	Files         []string // the names of the files that were not found.
+	Dirs          []string // the absolute paths of the directories searched.
+	Embedded      bool     // whether the embedded file system was searched.
+	ProfileLayout string   // the layout of the profile file names, if profiles are used.
+}
+
+// Error lists the files that were not found and where they were looked for.
+func (e *NotFoundError) Error() string {
+	quote := func(s []string) []string {
+		quoted := make([]string, len(s))
+		for i := range s {
+			quoted[i] = strconv.Quote(s[i])
+		}
+		return quoted
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.Join(quote(e.Files), ", "))
+	sb.WriteString(" file(s) not found in ")
+	where := quote(e.Dirs)
+	if e.Embedded {
+		where = append(where, "embedded files")
+	}
+	sb.WriteString(strings.Join(where, ", "))
+	if e.ProfileLayout != "" {
+		fmt.Fprintf(&sb, " (profile layout %q)", e.ProfileLayout)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(ErrFileNotFound.Error())
+	return sb.String()
+}
+
+// Unwrap returns `ErrFileNotFound`.
+func (e *NotFoundError) Unwrap() error {
+	return ErrFileNotFound
+}
+
+// DecodeError is returned when a config file or the reader cannot be
+// decoded. It holds the path of the file and, where the decoder reports
+// it, the line and column of the error. YAML and TOML errors carry their
+// position in their message instead.
+type DecodeError struct {
+	File   string // the path of the file, or "" for the reader.
+	Line   int    // the line of the error, starting at 1, if known.
+	Column int    // the column of the error, starting at 1, if known.
+	Err    error  // the error of the decoder.
+}
+
+// Error formats the error as file:line:column: err, leaving out what is
+// not known.
+func (e *DecodeError) Error() string {
+	pos := e.File
+	if e.Line > 0 {
+		pos = fmt.Sprintf("%s:%d:%d", pos, e.Line, e.Column)
+		pos = strings.TrimPrefix(pos, ":")
+	}
+	if pos == "" {
+		return e.Err.Error()
+	}
+	return pos + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the decoder.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// newDecodeError wraps err, which occurred decoding b, in a *DecodeError
+// for the file name. The line and column are derived from the offset of
+// JSON errors, or taken from the *DecodeError of a decoder set with
+// WithDecoder.
+func newDecodeError(name string, b []byte, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	}
+
+	de := &DecodeError{File: name, Err: err}
+	var tomlErr *toml.DecodeError
+	if errors.As(err, &tomlErr) {
+		de.Line, de.Column = tomlErr.Position()
+	}
+	var posErr *DecodeError
+	if errors.As(err, &posErr) {
+		de.Line, de.Column, de.Err = posErr.Line, posErr.Column, posErr.Err
+	}
+	if offset > 0 && offset <= int64(len(b)) {
+		// the offset is just past the byte in error.
+		before := b[:offset-1]
+		de.Line = bytes.Count(before, []byte("\n")) + 1
+		de.Column = len(before) - bytes.LastIndexByte(before, '\n')
+	}
+	if de.File == "" && de.Line == 0 {
+		return err
+	}
+	return de
+}
+
 // fieldErrors collects errors for fields of config struct.
 type fieldErrors map[string]error
 
@@ -25,11 +193,255 @@ func (fe fieldErrors) Error() string {
 	sb.Grow(len(keys) * 10)
 
 	for _, key := range keys {
-		sb.WriteString(key)
-		sb.WriteString(": ")
+		if key != "" {
+			sb.WriteString(key)
+			sb.WriteString(": ")
+		}
 		sb.WriteString(fe[key].Error())
 		sb.WriteString(", ")
 	}
 
 	return strings.TrimSuffix(sb.String(), ", ")
 }
+
+// Unwrap returns the errors of all fields as *FieldError sorted by path.
+func (fe fieldErrors) Unwrap() []error {
+	keys := make([]string, 0, len(fe))
+	for key := range fe {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		fieldErr := &FieldError{Err: fe[key]}
+		if e, ok := fe[key].(*FieldError); ok {
+			copied := *e
+			fieldErr = &copied
+		}
+		fieldErr.Path = key
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+// Is reports whether any of the field errors matches target.
+func (fe fieldErrors) Is(target error) bool {
+	for _, err := range fe.Unwrap() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first field error that matches target.
+func (fe fieldErrors) As(target interface{}) bool {
+	for _, err := range fe.Unwrap() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge adds err to fe under path. If err is itself a fieldErrors then
+// each of its errors is added with its key prefixed by path.
+func (fe fieldErrors) merge(path string, err error) {
+	nested, ok := err.(fieldErrors)
+	if !ok {
+		fe[path] = err
+		return
+	}
+
+	for key, err := range nested {
+		switch {
+		case path == "":
+			fe[key] = err
+		case key == "":
+			fe[path] = err
+		case strings.HasPrefix(key, "["):
+			fe[path+key] = err
+		default:
+			fe[path+"."+key] = err
+		}
+	}
+}
+
+// LoadError is returned by `Load` when the `Lenient` option is used and
+// one or more stages of loading failed. It holds every error that occurred
+// together with the config, which is populated as far as possible.
+type LoadError struct {
+	Config interface{} // the pointer to the partially loaded config struct.
+	Errors []error     // the errors of all stages in the order they occurred.
+}
+
+// Error formats all errors into a single string.
+func (e *LoadError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors matches target.
+func (e *LoadError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error that matches target.
+func (e *LoadError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeErrorName matches the quoted name of the field in the errors of
+// mapstructure, e.g. 'server.port' in "'server.port' expected type 'int'".
+var typeErrorName = regexp.MustCompile(`'([^']*)'`)
+
+// typeErrors converts the errors of a mapstructure decode into fieldErrors
+// keyed by the paths of the fields, so that type mismatches are reported
+// like the errors of validation. renames maps the decoded names to field
+// paths as in decodeMap. Errors that name no field are returned as is.
+func typeErrors(err error, renames map[string]string) error {
+	msErr, ok := err.(*mapstructure.Error)
+	if !ok {
+		return err
+	}
+
+	errs := make(fieldErrors)
+	for _, msg := range msErr.Errors {
+		match := typeErrorName.FindStringSubmatchIndex(msg)
+		if match == nil {
+			return err
+		}
+		name := msg[match[2]:match[3]]
+		path, ok := unsquashKey(name, renames)
+		if !ok {
+			path = name
+		}
+
+		quoted := msg[match[0]:match[1]]
+		switch {
+		case strings.HasPrefix(msg, "error decoding "+quoted+": "):
+			msg = strings.TrimPrefix(msg, "error decoding "+quoted+": ")
+		case strings.HasPrefix(msg, quoted+" "):
+			msg = strings.TrimPrefix(msg, quoted+" ")
+		default:
+			msg = strings.Replace(msg, quoted+" ", "", 1)
+		}
+		errs[path] = errors.New(msg)
+	}
+	return errs
+}
+
+// quotedValue matches the values quoted in error messages, e.g. "abc" in
+// `strconv.Atoi: parsing "abc": invalid syntax` and value: 'abc' at the end
+// of the errors of mapstructure.
+var quotedValue = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|value: '.*'$`)
+
+// redactedError masks values in the message of err and, if quoted is set,
+// the values quoted in it, e.g. of errors that quote the raw input.
+type redactedError struct {
+	err    error
+	values []string
+	quoted bool
+}
+
+func (e *redactedError) Error() string {
+	msg := e.err.Error()
+	for _, val := range e.values {
+		msg = replaceValue(msg, val)
+	}
+	if !e.quoted {
+		return msg
+	}
+	return quotedValue.ReplaceAllStringFunc(msg, func(s string) string {
+		if strings.HasPrefix(s, "value: ") {
+			return "value: '" + RedactedValue + "'"
+		}
+		return strconv.Quote(RedactedValue)
+	})
+}
+
+// replaceValue replaces the occurrences of val in msg with RedactedValue,
+// except within longer words or numbers, so that masking 5 leaves the 50 in
+// "the maximum 50" alone.
+func replaceValue(msg, val string) string {
+	if val == "" {
+		return msg
+	}
+	var sb strings.Builder
+	for {
+		i := strings.Index(msg, val)
+		if i < 0 {
+			sb.WriteString(msg)
+			return sb.String()
+		}
+		end := i + len(val)
+		if isWordByte(val[0]) && i > 0 && isWordByte(msg[i-1]) ||
+			isWordByte(val[len(val)-1]) && end < len(msg) && isWordByte(msg[end]) {
+			sb.WriteString(msg[:i+1])
+			msg = msg[i+1:]
+			continue
+		}
+		sb.WriteString(msg[:i])
+		sb.WriteString(RedactedValue)
+		msg = msg[end:]
+	}
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactSecrets masks the values in the errors of the secret fields among
+// fields, so that error messages can be logged safely.
+func redactSecrets(errs fieldErrors, fields []*field) {
+	for _, f := range fields {
+		err, ok := errs[f.path()]
+		if !ok || !f.secret {
+			continue
+		}
+		if fieldErr, ok := err.(*FieldError); ok {
+			redacted := *fieldErr
+			redacted.Err = &redactedError{err: fieldErr.Err, values: secretValues(f.v)}
+			errs[f.path()] = &redacted
+		} else {
+			// the errors of decoding quote the values that were read.
+			errs[f.path()] = &redactedError{err: err, values: secretValues(f.v), quoted: true}
+		}
+	}
+}
+
+// secretValues returns the formatted value of v, and of its elements if v is
+// a slice or an array, to be masked in errors.
+func secretValues(v reflect.Value) []string {
+	values := []string{formatValue(v)}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return values
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, formatValue(v.Index(i)))
+		}
+	}
+	return values
+}
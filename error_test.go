@@ -1,7 +1,11 @@
 package confucius
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -25,3 +29,211 @@ func Test_fieldErrors_Error(t *testing.T) {
 		t.Fatalf("empty errors returned non-empty string: %s", got)
 	}
 }
+
+func Test_fieldErrors_merge(t *testing.T) {
+	fe := make(fieldErrors)
+
+	fe.merge("", fmt.Errorf("root"))
+	fe.merge("a", fmt.Errorf("aerr"))
+	fe.merge("b", fieldErrors{"c": fmt.Errorf("cerr"), "[0]": fmt.Errorf("idxerr"), "": fmt.Errorf("berr")})
+
+	got := fe.Error()
+
+	want := "root, a: aerr, b: berr, b.c: cerr, b[0]: idxerr"
+	if want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
+
+func Test_FieldError_As(t *testing.T) {
+	type Config struct {
+		Name    string `conf:"name" validate:"required"`
+		Mode    string `conf:"mode"`
+		Cert    string `conf:"cert" validate:"required_if=Mode tls"`
+		Timeout int    `conf:"timeout" default:"soon"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"mode": "tls"}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+
+	if !errors.Is(err, ErrRequired) || !errors.Is(err, ErrBadDefault) {
+		t.Errorf("expected err to match ErrRequired and ErrBadDefault, got %v", err)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("expected err to be a *FieldError, got %v", err)
+	}
+	if fieldErr.Path != "cert" || fieldErr.Rule != "required_if" || !errors.Is(fieldErr, ErrRequired) {
+		t.Errorf("fieldErr == %+v, expected the error of cert", fieldErr)
+	}
+
+	errs := err.(fieldErrors).Unwrap()
+	if len(errs) != 3 {
+		t.Fatalf("len(errs) == %d, expected 3", len(errs))
+	}
+	want := []FieldError{
+		{Path: "cert", Rule: "required_if"},
+		{Path: "name", Rule: "required"},
+		{Path: "timeout", Rule: "default", Value: "soon"},
+	}
+	for i, err := range errs {
+		got := err.(*FieldError)
+		if got.Path != want[i].Path || got.Rule != want[i].Rule || got.Value != want[i].Value {
+			t.Errorf("errs[%d] == %+v, expected %+v", i, got, want[i])
+		}
+	}
+	if got := errs[1].Error(); got != "name: required validation failed" {
+		t.Errorf("errs[1].Error() == %q", got)
+	}
+}
+
+func Test_ErrUnsupportedExtension(t *testing.T) {
+	var cfg struct{}
+	err := Load(&cfg, String("a = 1", ".hcl"))
+	if !errors.Is(err, ErrUnsupportedExtension) {
+		t.Errorf("err == %v, expected ErrUnsupportedExtension", err)
+	}
+}
+
+func Test_DecodeError(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(file, []byte("{\n  \"name\": \"app\",\n  \"port\": ]\n}\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	var cfg struct {
+		Name string `conf:"name"`
+		Port int    `conf:"port"`
+	}
+	err := Load(&cfg, File("config.json"), Dirs(dir))
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected *DecodeError, got %v", err)
+	}
+	if decodeErr.File != file || decodeErr.Line != 3 || decodeErr.Column != 11 {
+		t.Errorf("decodeErr == %+v, expected %s:3:11", decodeErr, file)
+	}
+	if want := file + ":3:11: "; !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("err == %q, expected prefix %q", err, want)
+	}
+
+	err = Load(&cfg, String(`{"name": "app", "port": "abc"}`, DecoderJSON))
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok || fieldErrs["port"] == nil || len(fieldErrs) != 1 {
+		t.Fatalf("expected port err, got %v", err)
+	}
+	if got := fieldErrs["port"].Error(); strings.Contains(got, "'port'") {
+		t.Errorf("fieldErrs[port] == %q, expected the path to be left out", got)
+	}
+}
+
+func Test_NotFoundError(t *testing.T) {
+	dir := t.TempDir()
+	var cfg struct{}
+	err := Load(&cfg, Dirs(dir, "."), Profiles("prod"))
+	if !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("err == %v, expected ErrFileNotFound", err)
+	}
+
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected *NotFoundError, got %T", err)
+	}
+	wd, _ := os.Getwd()
+	if want := []string{"config.yaml", "config.prod.yaml"}; fmt.Sprint(notFound.Files) != fmt.Sprint(want) {
+		t.Errorf("notFound.Files == %v, expected %v", notFound.Files, want)
+	}
+	if want := []string{dir, wd}; fmt.Sprint(notFound.Dirs) != fmt.Sprint(want) {
+		t.Errorf("notFound.Dirs == %v, expected %v", notFound.Dirs, want)
+	}
+	if notFound.ProfileLayout != DefaultProfileLayout {
+		t.Errorf("notFound.ProfileLayout == %q, expected %q", notFound.ProfileLayout, DefaultProfileLayout)
+	}
+
+	want := fmt.Sprintf(`"config.yaml", "config.prod.yaml" file(s) not found in %q, %q (profile layout %q): file not found`,
+		dir, wd, DefaultProfileLayout)
+	if err.Error() != want {
+		t.Errorf("err == %q, expected %q", err, want)
+	}
+}
+
+func Test_redactSecrets(t *testing.T) {
+	type Config struct {
+		Pin    int      `conf:"pin" secret:"true"`
+		Token  int      `conf:",secret"`
+		Listen HostPort `conf:"listen,secret"`
+		Port   int      `conf:"port"`
+	}
+
+	setenv(t, "APP_PIN", "12ab")
+	defer os.Unsetenv("APP_PIN")
+	setenv(t, "APP_LISTEN", "hunter2")
+	defer os.Unsetenv("APP_LISTEN")
+	setenv(t, "APP_PORT", "80ab")
+	defer os.Unsetenv("APP_PORT")
+
+	var cfg Config
+	err := Load(&cfg, UseEnv("app"), String(`{}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+	for _, secret := range []string{"12ab", "hunter2"} {
+		if strings.Contains(err.Error(), secret) {
+			t.Errorf("err == %q, expected %q to be redacted", err, secret)
+		}
+	}
+	if !strings.Contains(err.Error(), `"80ab"`) {
+		t.Errorf("err == %q, expected the value of port", err)
+	}
+
+	err = Load(&cfg, String(`{"Token": "s3cr3t"}`, DecoderJSON))
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok || fieldErrs["Token"] == nil {
+		t.Fatalf("expected Token err, got %v", err)
+	}
+	if got := fieldErrs["Token"].Error(); strings.Contains(got, "s3cr3t") || !strings.Contains(got, RedactedValue) {
+		t.Errorf("fieldErrs[Token] == %q, expected the value to be redacted", got)
+	}
+
+	type RuleConfig struct {
+		Pin   int    `conf:"pin" secret:"true" validate:"max=100"`
+		Level int    `conf:"level" secret:"true" validate:"max=50"`
+		Code  string `conf:"code" secret:"true" validate:"expr=len(code) == 4"`
+	}
+	var ruleCfg RuleConfig
+	err = Load(&ruleCfg, String(`{"pin": 12345, "level": 51, "code": "abc"}`, DecoderJSON))
+	fieldErrs, ok = err.(fieldErrors)
+	if !ok {
+		t.Fatalf("expected field errors, got %v", err)
+	}
+	for path, want := range map[string]string{
+		"pin":   "value *** is greater than the maximum 100",
+		"level": "value *** is greater than the maximum 50",
+		"code":  `value does not satisfy "len(code) == 4"`,
+	} {
+		if fieldErrs[path] == nil || !strings.Contains(fieldErrs[path].Error(), want) {
+			t.Errorf("fieldErrs[%s] == %v, expected %q", path, fieldErrs[path], want)
+		}
+	}
+}
+
+func Test_replaceValue(t *testing.T) {
+	for _, tc := range []struct {
+		msg, val, want string
+	}{
+		{msg: "value 5 is greater than the maximum 50", val: "5", want: "value *** is greater than the maximum 50"},
+		{msg: `"hunter2" is not one of a, b`, val: "hunter2", want: `"***" is not one of a, b`},
+		{msg: "ab and abc", val: "ab", want: "*** and abc"},
+		{msg: "a b", val: "", want: "a b"},
+	} {
+		if got := replaceValue(tc.msg, tc.val); got != tc.want {
+			t.Errorf("replaceValue(%q, %q) == %q, expected %q", tc.msg, tc.val, got, tc.want)
+		}
+	}
+}
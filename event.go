@@ -0,0 +1,38 @@
+package confucius
+
+// The kinds of events emitted while loading a config.
+const (
+	EventFileFound    = "file_found"     // a config file was found.
+	EventFileNotFound = "file_not_found" // an expected config file was not found.
+	EventDecode       = "decode"         // a source is decoded; Value is the decoder.
+	EventMerge        = "merge"          // the values of a file were merged over the previous ones.
+	EventSet          = "set"            // a field was set from the environment, a flag, an override or a default.
+	EventInvalid      = "invalid"        // a field failed validation.
+	EventWarning      = "warning"        // a field failed a warning-level rule, e.g. `warn:max=100`.
+)
+
+// Event describes a step of loading a config. Events are passed to the
+// function set by `WithLogger`.
+type Event struct {
+	Kind   string // one of the Event constants.
+	Path   string // the path of the field, for field events.
+	Source Source // the file, reader, environment variable, flag or default involved.
+	Value  string // the value set or the decoder used; secrets are redacted.
+	Err    error  // the validation error, for EventInvalid and EventWarning.
+}
+
+// emit passes e to the event function, if set.
+func (c *confucius) emit(e Event) {
+	if c.onEvent != nil {
+		c.onEvent(e)
+	}
+}
+
+// displayValue returns val as it is shown in events, which is redacted if
+// the field holds a secret.
+func displayValue(val string, st structTag) string {
+	if st.secret {
+		return RedactedValue
+	}
+	return val
+}
@@ -0,0 +1,55 @@
+package confucius
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_confucius_Load_WithLogger(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: app\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	type Config struct {
+		Name     string `conf:"name"`
+		Port     int    `conf:"port" default:"80"`
+		Password string `conf:"password" secret:"true"`
+		Region   string `conf:"region" validate:"required"`
+	}
+
+	setenv(t, "APP_PASSWORD", "hunter2")
+	defer os.Unsetenv("APP_PASSWORD")
+
+	var events []Event
+	var cfg Config
+	err := Load(&cfg, Dirs(dir), UseEnv("app"), WithLogger(func(e Event) {
+		events = append(events, e)
+	}))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+
+	file := filepath.Join(dir, "config.yaml")
+	want := []Event{
+		{Kind: EventFileFound, Source: Source{Kind: SourceFile, Name: file}},
+		{Kind: EventDecode, Source: Source{Kind: SourceFile, Name: file}, Value: ".yaml"},
+		{Kind: EventMerge, Source: Source{Kind: SourceFile, Name: file}},
+		{Kind: EventSet, Path: "port", Source: Source{Kind: SourceDefault}, Value: "80"},
+		{Kind: EventSet, Path: "password", Source: Source{Kind: SourceEnv, Name: "APP_PASSWORD"}, Value: RedactedValue},
+		{Kind: EventInvalid, Path: "region"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events == %+v, expected %+v", events, want)
+	}
+	for i, e := range events {
+		if e.Kind == EventInvalid && e.Err == nil {
+			t.Errorf("event %d: expected err", i)
+		}
+		e.Err = nil
+		if e != want[i] {
+			t.Errorf("event %d == %+v, expected %+v", i, e, want[i])
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package confucius
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Explanation describes how each field of a config would be loaded.
+type Explanation struct {
+	Fields []ExplainedField // the fields that hold values, in the order of the struct.
+}
+
+// ExplainedField describes the resolved value of a field, where it came from
+// and the rules that apply to it.
+type ExplainedField struct {
+	Path   string   // the path of the field, e.g. "server.port".
+	Value  string   // the resolved value; secrets are redacted.
+	Source Source   // where the value came from; the zero Source if no source set it.
+	Rules  []string // the validation rules and default of the field, e.g. "required" or "default=80".
+	Err    error    // the error of the field, if it failed to load or validate.
+}
+
+// Field returns the explanation of the field with the given path.
+func (e *Explanation) Field(path string) (ExplainedField, bool) {
+	for _, f := range e.Fields {
+		if f.Path == path {
+			return f, true
+		}
+	}
+	return ExplainedField{}, false
+}
+
+// String formats the explanation with a line per field, e.g.
+// `server.port = 8080 (env MYAPP_SERVER_PORT) [required]`.
+func (e *Explanation) String() string {
+	var sb strings.Builder
+	for _, f := range e.Fields {
+		fmt.Fprintf(&sb, "%s = %s", f.Path, f.Value)
+		if f.Source.Kind != "" {
+			fmt.Fprintf(&sb, " (%s)", f.Source)
+		}
+		if len(f.Rules) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(f.Rules, ", "))
+		}
+		if f.Err != nil {
+			fmt.Fprintf(&sb, ": %v", f.Err)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Explain runs the whole loading pipeline like `Load` but leaves cfg
+// untouched, and explains the result: the resolved value, the source and
+// the rules of every field. It is meant to power commands that show how a
+// config is resolved.
+//
+//   explanation, err := confucius.Explain(&cfg, confucius.UseEnv("myapp"))
+//   fmt.Print(explanation)
+//
+// The parameter `cfg` must be a pointer to a struct, whose values are used as
+// the starting point as with `Load`. The explanation is returned even if
+// loading fails, with the error of each field that failed.
+func Explain(cfg interface{}, options ...Option) (*Explanation, error) {
+	if !isStructPtr(cfg) {
+		return nil, fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	c := defaultConfucius()
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	c.sources = make(map[string]Source)
+	v := reflect.ValueOf(cfg).Elem()
+	tmp := reflect.New(v.Type())
+	tmp.Elem().Set(deepCopy(v))
+	err := c.load(tmp.Interface())
+	return c.explain(tmp.Interface(), err), err
+}
+
+// explain builds the explanation of the fields of cfg, which was loaded
+// with the error err.
+func (c *confucius) explain(cfg interface{}, err error) *Explanation {
+	var fieldErrs fieldErrors
+	errors.As(err, &fieldErrs)
+
+	e := &Explanation{}
+	for _, f := range valueFields(c.flatten(cfg)) {
+		path := f.path()
+		e.Fields = append(e.Fields, ExplainedField{
+			Path:   path,
+			Value:  explainValue(f),
+			Source: c.sources[path],
+			Rules:  explainRules(f.t, f.structTag),
+			Err:    fieldErrs[path],
+		})
+	}
+	return e
+}
+
+// valueFields returns the fields among fields that hold values, which are
+// the fields without children that are not structs.
+func valueFields(fields []*field) []*field {
+	parents := make(map[*field]bool)
+	for _, f := range fields {
+		for p := f.parent; p != nil; p = p.parent {
+			parents[p] = true
+		}
+	}
+
+	var result []*field
+	for _, f := range fields {
+		if parents[f] || (f.t.Kind() == reflect.Struct && !isLeafStruct(f.t)) {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// explainValue formats the value of f, which is redacted if f holds a
+// secret.
+func explainValue(f *field) string {
+	if f.secret {
+		return RedactedValue
+	}
+	v := f.v
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// explainRules lists the validation rules and the default of a field of
+// type t. The values of Enum types are listed as a oneof rule.
+func explainRules(t reflect.Type, st structTag) []string {
+	var rules []string
+	oneof := false
+	for _, r := range st.rules {
+		oneof = oneof || (r.name == "oneof" && !r.warn)
+		name := r.name
+		if r.warn {
+			name = warnPrefix + name
+		}
+		if r.param == "" {
+			rules = append(rules, name)
+		} else {
+			rules = append(rules, name+"="+r.param)
+		}
+	}
+	if values := enumValues(t); !oneof && values != nil {
+		rules = append(rules, "oneof="+strings.Join(values, " "))
+	}
+	if st.setDefault {
+		rules = append(rules, "default="+st.defaultVal)
+	}
+	return rules
+}
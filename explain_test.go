@@ -0,0 +1,61 @@
+package confucius
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_Explain(t *testing.T) {
+	type Config struct {
+		Name     string `conf:"name" validate:"required"`
+		Port     int    `conf:"port" default:"80"`
+		Password string `conf:"password" secret:"true"`
+		Region   string `conf:"region" validate:"required"`
+		Server   struct {
+			Hosts []string `conf:"hosts"`
+		} `conf:"server"`
+	}
+
+	setenv(t, "APP_PASSWORD", "hunter2")
+	defer os.Unsetenv("APP_PASSWORD")
+
+	cfg := Config{Name: "before"}
+	explanation, err := Explain(&cfg, UseEnv("app"),
+		String(`{"name": "app", "server": {"hosts": ["a", "b"]}}`, DecoderJSON))
+	if !errors.Is(err, ErrRequired) {
+		t.Fatalf("err == %v, expected ErrRequired", err)
+	}
+	if !reflect.DeepEqual(cfg, Config{Name: "before"}) {
+		t.Errorf("cfg == %+v, expected it to be untouched", cfg)
+	}
+
+	want := []ExplainedField{
+		{Path: "name", Value: "app", Source: Source{Kind: SourceReader}, Rules: []string{"required"}},
+		{Path: "port", Value: "80", Source: Source{Kind: SourceDefault}, Rules: []string{"default=80"}},
+		{Path: "password", Value: RedactedValue, Source: Source{Kind: SourceEnv, Name: "APP_PASSWORD"}},
+		{Path: "region", Value: "", Rules: []string{"required"}},
+		{Path: "server.hosts", Value: "[a b]", Source: Source{Kind: SourceReader}},
+	}
+	if len(explanation.Fields) != len(want) {
+		t.Fatalf("explanation.Fields == %+v, expected %+v", explanation.Fields, want)
+	}
+	for i, got := range explanation.Fields {
+		if (got.Err != nil) != (got.Path == "region") {
+			t.Errorf("%s: err == %v", got.Path, got.Err)
+		}
+		got.Err = nil
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("explanation.Fields[%d] == %+v, expected %+v", i, got, want[i])
+		}
+	}
+
+	if f, ok := explanation.Field("port"); !ok || f.Value != "80" {
+		t.Errorf("Field(port) == %+v, %v", f, ok)
+	}
+	if got := explanation.String(); !strings.Contains(got, "port = 80 (default) [default=80]\n") {
+		t.Errorf("String() == %q", got)
+	}
+}
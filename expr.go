@@ -0,0 +1,428 @@
+package confucius
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// exprRule fails if the expression in param does not evaluate to true, e.g.
+// `expr=Value > 0 && Value < MaxConnections`. `Value` is the value of the
+// field and other names refer to its sibling fields.
+func exprRule(f *field, param string) error {
+	e, err := parseExpr(param)
+	if err != nil {
+		return fmt.Errorf("expr: %v", err)
+	}
+	val, err := e(func(name string) (reflect.Value, bool) {
+		if name == "Value" {
+			return f.v, true
+		}
+		return lookupSibling(f, name)
+	})
+	if err != nil {
+		return fmt.Errorf("expr %q: %v", param, err)
+	}
+	ok, isBool := val.(bool)
+	if !isBool {
+		return fmt.Errorf("expr %q: result is %v, not a bool", param, val)
+	}
+	if !ok {
+		return fmt.Errorf("value does not satisfy %q", param)
+	}
+	return nil
+}
+
+// exprFunc evaluates a parsed expression. The values of names are looked up
+// with lookup.
+type exprFunc func(lookup func(name string) (reflect.Value, bool)) (interface{}, error)
+
+// exprToken is a token of an expression. Literals hold their value.
+type exprToken struct {
+	kind  string // "num", "str", "ident", "op" or "" at the end.
+	text  string
+	value interface{}
+}
+
+// exprParser parses expressions with the usual precedence of Go:
+//
+//   || < && < comparisons < + - < * / % < unary ! -
+//
+// Operands are numbers (durations such as 5s are numbers of nanoseconds),
+// strings in double or single quotes, true, false, names of fields and
+// len(x) of strings, slices and maps.
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+// parseExpr parses the expression s.
+func parseExpr(s string) (exprFunc, error) {
+	tokens, err := tokenizeExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	e, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != "" {
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	}
+	return e, nil
+}
+
+// exprPrecedence lists the binary operators from the lowest precedence.
+var exprPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) peek() exprToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return exprToken{}
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// parseBinary parses the binary operators of the given precedence level and
+// above.
+func (p *exprParser) parseBinary(level int) (exprFunc, error) {
+	if level == len(exprPrecedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != "op" || !containsString(exprPrecedence[level], tok.text) {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(tok.text, left, right)
+	}
+}
+
+func (p *exprParser) parseUnary() (exprFunc, error) {
+	tok := p.peek()
+	if tok.kind == "op" && (tok.text == "!" || tok.text == "-") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(lookup func(string) (reflect.Value, bool)) (interface{}, error) {
+			val, err := operand(lookup)
+			if err != nil {
+				return nil, err
+			}
+			switch v := val.(type) {
+			case bool:
+				if tok.text == "!" {
+					return !v, nil
+				}
+			case float64:
+				if tok.text == "-" {
+					return -v, nil
+				}
+			}
+			return nil, fmt.Errorf("invalid operand %v of %s", val, tok.text)
+		}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprFunc, error) {
+	tok := p.next()
+	switch tok.kind {
+	case "num", "str":
+		return func(func(string) (reflect.Value, bool)) (interface{}, error) {
+			return tok.value, nil
+		}, nil
+	case "ident":
+		switch tok.text {
+		case "true", "false":
+			b := tok.text == "true"
+			return func(func(string) (reflect.Value, bool)) (interface{}, error) {
+				return b, nil
+			}, nil
+		case "len":
+			if p.next().text != "(" {
+				return nil, fmt.Errorf("expected ( after len")
+			}
+			arg, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if p.next().text != ")" {
+				return nil, fmt.Errorf("expected ) after the argument of len")
+			}
+			return lenExpr(arg), nil
+		}
+		return func(lookup func(string) (reflect.Value, bool)) (interface{}, error) {
+			v, ok := lookup(tok.text)
+			if !ok {
+				return nil, fmt.Errorf("field %q not found", tok.text)
+			}
+			return exprValue(v), nil
+		}, nil
+	case "op":
+		if tok.text == "(" {
+			e, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if p.next().text != ")" {
+				return nil, fmt.Errorf("missing )")
+			}
+			return e, nil
+		}
+		return nil, fmt.Errorf("unexpected %q", tok.text)
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+// binaryExpr evaluates the binary operator op. && and || short-circuit.
+func binaryExpr(op string, left, right exprFunc) exprFunc {
+	return func(lookup func(string) (reflect.Value, bool)) (interface{}, error) {
+		l, err := left(lookup)
+		if err != nil {
+			return nil, err
+		}
+		if op == "&&" || op == "||" {
+			b, ok := l.(bool)
+			if !ok {
+				return nil, fmt.Errorf("invalid operand %v of %s", l, op)
+			}
+			if b == (op == "||") {
+				return b, nil
+			}
+			r, err := right(lookup)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := r.(bool); !ok {
+				return nil, fmt.Errorf("invalid operand %v of %s", r, op)
+			}
+			return r, nil
+		}
+
+		r, err := right(lookup)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		}
+
+		switch l := l.(type) {
+		case float64:
+			if r, ok := r.(float64); ok {
+				return numberOp(op, l, r)
+			}
+		case string:
+			if r, ok := r.(string); ok {
+				return stringOp(op, l, r)
+			}
+		}
+		return nil, fmt.Errorf("invalid operands %v and %v of %s", l, r, op)
+	}
+}
+
+func numberOp(op string, l, r float64) (interface{}, error) {
+	switch op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	default: // "%"
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(l, r), nil
+	}
+}
+
+func stringOp(op string, l, r string) (interface{}, error) {
+	switch op {
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	case "+":
+		return l + r, nil
+	default:
+		return nil, fmt.Errorf("invalid operands %q and %q of %s", l, r, op)
+	}
+}
+
+// lenExpr evaluates the length of a string, slice, array or map.
+func lenExpr(arg exprFunc) exprFunc {
+	return func(lookup func(string) (reflect.Value, bool)) (interface{}, error) {
+		val, err := arg(lookup)
+		if err != nil {
+			return nil, err
+		}
+		switch v := val.(type) {
+		case nil:
+			return float64(0), nil
+		case string:
+			return float64(len(v)), nil
+		case reflect.Value:
+			switch v.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				return float64(v.Len()), nil
+			}
+		}
+		return nil, fmt.Errorf("invalid argument %v of len", val)
+	}
+}
+
+// exprValue converts v to a value of an expression: numbers of any kind,
+// including durations, become float64. Values of other kinds, such as
+// slices and maps, are returned as they are for len.
+func exprValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// tokenizeExpr splits s into the tokens of an expression.
+func tokenizeExpr(s string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(s) && unicode.IsDigit(rune(s[i+1]))):
+			j := i
+			for j < len(s) && (isExprWordByte(s[j]) || s[j] == '.' || s[j] >= 0x80) {
+				j++
+			}
+			tok, err := numberToken(s[i:j])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(s) && isExprWordByte(s[j]) {
+				j++
+			}
+			tokens = append(tokens, exprToken{kind: "ident", text: s[i:j]})
+			i = j
+		case c == '"' || c == '\'':
+			j := strings.IndexByte(s[i+1:], s[i])
+			if j == -1 {
+				return nil, fmt.Errorf("unterminated string %s", s[i:])
+			}
+			text := s[i : i+j+2]
+			tokens = append(tokens, exprToken{kind: "str", text: text, value: text[1 : len(text)-1]})
+			i += j + 2
+		default:
+			op := ""
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")"} {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q", c)
+			}
+			tokens = append(tokens, exprToken{kind: "op", text: op})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// numberToken parses a number, or a duration such as 1h30m, which becomes
+// its number of nanoseconds.
+func numberToken(text string) (exprToken, error) {
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return exprToken{kind: "num", text: text, value: f}, nil
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil {
+		return exprToken{}, fmt.Errorf("invalid number %q", text)
+	}
+	return exprToken{kind: "num", text: text, value: float64(d)}, nil
+}
+
+func isExprWordByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
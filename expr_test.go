@@ -0,0 +1,108 @@
+package confucius
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_parseExpr(t *testing.T) {
+	type siblings struct {
+		Value   int
+		Max     int
+		Name    string
+		Timeout time.Duration
+		Tags    []string
+		Enabled bool
+		Ratio   *float64
+	}
+	ratio := 0.5
+	s := reflect.ValueOf(siblings{Value: 5, Max: 10, Name: "api", Timeout: 2 * time.Second, Tags: []string{"a", "b"}, Enabled: true, Ratio: &ratio})
+	lookup := func(name string) (reflect.Value, bool) {
+		v := s.FieldByName(name)
+		return v, v.IsValid()
+	}
+
+	for _, tc := range []struct {
+		expr string
+		want interface{}
+	}{
+		{"Value > 0 && Value < Max", true},
+		{"Value >= Max || Name == 'api'", true},
+		{"!(Value == 5)", false},
+		{"Value * 2 == Max", true},
+		{"(Value + 1) % 4 == 2", true},
+		{"-Value < 0", true},
+		{"Timeout <= 5s && Timeout > 1500ms", true},
+		{"Timeout == 1m", false},
+		{`Name + "-v1" == "api-v1"`, true},
+		{"len(Name) == 3 && len(Tags) > 1", true},
+		{"Enabled", true},
+		{"Ratio < 1", true},
+		{"Max / 4", 2.5},
+		{"1 < 2 == true", true},
+	} {
+		e, err := parseExpr(tc.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", tc.expr, err)
+			continue
+		}
+		got, err := e(lookup)
+		if err != nil {
+			t.Errorf("%s: unexpected err: %v", tc.expr, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: == %v, expected %v", tc.expr, got, tc.want)
+		}
+	}
+
+	for _, expr := range []string{"Value >", "(Value > 1", "Value @ 1", "'open", "len Value", "1 2"} {
+		if _, err := parseExpr(expr); err == nil {
+			t.Errorf("%s: expected a parse err", expr)
+		}
+	}
+	for _, expr := range []string{"Missing > 1", "Name > 1", "Value / 0 > 1", "Value && true", "len(Value) > 1"} {
+		e, err := parseExpr(expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected err: %v", expr, err)
+		}
+		if _, err := e(lookup); err == nil {
+			t.Errorf("%s: expected an eval err", expr)
+		}
+	}
+}
+
+func Test_Load_ExprRule(t *testing.T) {
+	type Config struct {
+		MaxConnections int `conf:"max_connections"`
+		Pool           struct {
+			Size  int `conf:"size" validate:"expr=Value > 0 && Value <= Limit"`
+			Limit int `conf:"limit"`
+		} `conf:"pool"`
+		Connections int `conf:"connections" validate:"expr=Value > 0 && Value < MaxConnections"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"max_connections": 100, "connections": 10, "pool": {"size": 5, "limit": 8}}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	err = Load(&Config{}, String(`{"max_connections": 100, "connections": 100, "pool": {"size": 9, "limit": 8}}`, DecoderJSON))
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok {
+		t.Fatalf("err == %v, expected fieldErrors", err)
+	}
+	for _, path := range []string{"connections", "pool.size"} {
+		var fieldErr *FieldError
+		if !errors.As(fieldErrs[path], &fieldErr) || fieldErr.Rule != "expr" {
+			t.Errorf("%s: err == %v, expected an expr err", path, fieldErrs[path])
+		}
+	}
+	if !strings.Contains(fieldErrs["connections"].Error(), "Value < MaxConnections") {
+		t.Errorf("err == %v, expected the expression", fieldErrs["connections"])
+	}
+}
@@ -3,16 +3,26 @@ package confucius
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 // flattenCfg recursively flattens a cfg struct into
 // a slice of its constituent fields.
 func flattenCfg(cfg interface{}, tagKey string) []*field {
+	return flattenCfgTags(cfg, tagKey, nil)
+}
+
+// flattenCfgTags is like flattenCfg but looks up the parsed tags of the
+// fields in tags.
+func flattenCfgTags(cfg interface{}, tagKey string, tags *tagCache) []*field {
 	root := &field{
 		v:        reflect.ValueOf(cfg).Elem(),
 		t:        reflect.ValueOf(cfg).Elem().Type(),
 		sliceIdx: -1,
+		tagKey:   tagKey,
+		tags:     tags,
 	}
 	fs := make([]*field, 0)
 	flattenField(root, &fs, tagKey)
@@ -29,14 +39,15 @@ func flattenField(f *field, fs *[]*field, tagKey string) {
 
 	switch f.v.Kind() {
 	case reflect.Struct:
-		for i := 0; i < f.t.NumField(); i++ {
-			unexported := f.t.Field(i).PkgPath != ""
-			embedded := f.t.Field(i).Anonymous
-			if unexported && !embedded {
-				continue
+		layout := f.tags.layout(f.t, tagKey)
+		if layout.leaf {
+			return
+		}
+		for _, lf := range layout.fields {
+			child := newLayoutField(f, lf, tagKey)
+			if !child.squash {
+				*fs = append(*fs, child)
 			}
-			child := newStructField(f, i, tagKey)
-			*fs = append(*fs, child)
 			flattenField(child, fs, tagKey)
 		}
 
@@ -48,6 +59,23 @@ func flattenField(f *field, fs *[]*field, tagKey string) {
 				flattenField(child, fs, tagKey)
 			}
 		}
+
+	case reflect.Map:
+		elem := f.t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct || isLeafStruct(elem) {
+			return
+		}
+		keys := f.v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			child := newMapField(f, key, tagKey)
+			flattenField(child, fs, tagKey)
+		}
 	}
 }
 
@@ -55,14 +83,24 @@ func flattenField(f *field, fs *[]*field, tagKey string) {
 // member. idx is the field's index in the struct. tagKey is the
 // key of the tag that contains the field alt name (if any).
 func newStructField(parent *field, idx int, tagKey string) *field {
+	return newLayoutField(parent, parent.tags.field(parent.t.Field(idx), idx, tagKey), tagKey)
+}
+
+// newLayoutField is like newStructField but takes the field from the
+// layout of the parent's struct type.
+func newLayoutField(parent *field, lf layoutField, tagKey string) *field {
 	f := &field{
-		parent:   parent,
-		v:        parent.v.Field(idx),
-		t:        parent.v.Field(idx).Type(),
-		st:       parent.t.Field(idx),
-		sliceIdx: -1,
+		parent:    parent,
+		v:         parent.v.Field(lf.idx),
+		t:         lf.sf.Type,
+		st:        lf.sf,
+		sliceIdx:  -1,
+		tagKey:    tagKey,
+		squash:    lf.squash,
+		tags:      parent.tags,
+		structTag: lf.tag,
 	}
-	f.structTag = parseTag(f.st.Tag, tagKey)
+	f.secret = f.secret || lf.secret
 	return f
 }
 
@@ -76,11 +114,67 @@ func newSliceField(parent *field, idx int, tagKey string) *field {
 		t:        parent.v.Index(idx).Type(),
 		st:       parent.st,
 		sliceIdx: idx,
+		tagKey:   tagKey,
+		tags:     parent.tags,
+	}
+	f.structTag = f.tags.parse(f.st.Tag, tagKey)
+	f.secret = f.secret || isSecretType(f.t)
+	return f
+}
+
+// newMapField is a constructor for a field that is a map value. key is
+// the value's key in the map. Struct values are not settable, so the field
+// holds a copy of the value that is written back by commitMapValues.
+func newMapField(parent *field, key reflect.Value, tagKey string) *field {
+	f := &field{
+		parent:   parent,
+		v:        parent.v.MapIndex(key),
+		t:        parent.t.Elem(),
+		st:       parent.st,
+		sliceIdx: -1,
+		mapKey:   key,
+		tagKey:   tagKey,
+		tags:     parent.tags,
 	}
-	f.structTag = parseTag(f.st.Tag, tagKey)
+	if f.v.Kind() == reflect.Struct {
+		v := reflect.New(f.t).Elem()
+		v.Set(f.v)
+		f.v, f.copied = v, true
+	}
+	f.structTag = f.tags.parse(f.st.Tag, tagKey)
+	f.secret = f.secret || isSecretType(f.t)
 	return f
 }
 
+// commitMapValues writes the copies of the map values that fields belong
+// to back into their maps, innermost first.
+func commitMapValues(fields []*field) {
+	seen := make(map[*field]bool)
+	var values []*field
+	for _, f := range fields {
+		for p := f; p != nil; p = p.parent {
+			if p.copied && !seen[p] {
+				seen[p] = true
+				values = append(values, p)
+			}
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].depth() > values[j].depth()
+	})
+	for _, f := range values {
+		f.parent.v.SetMapIndex(f.mapKey, f.v)
+	}
+}
+
+// depth is the number of ancestors of the field.
+func (f *field) depth() (n int) {
+	for p := f.parent; p != nil; p = p.parent {
+		n++
+	}
+	return n
+}
+
 // field is a settable field of a config object.
 type field struct {
 	parent *field
@@ -88,7 +182,12 @@ type field struct {
 	v        reflect.Value
 	t        reflect.Type
 	st       reflect.StructField
-	sliceIdx int // >=0 if this field is a member of a slice.
+	sliceIdx int           // >=0 if this field is a member of a slice.
+	mapKey   reflect.Value // the key of the field in its map, if this field is a map value.
+	copied   bool          // true if v is a copy of a map value that must be committed.
+	tagKey   string        // the key of the tag that contains the field's alt name.
+	squash   bool          // true if this field is an embedded base struct whose fields belong to its parent.
+	tags     *tagCache     // the cache of parsed tags and struct layouts, if any.
 
 	structTag
 }
@@ -102,6 +201,9 @@ func (f *field) name() string {
 	if f.sliceIdx >= 0 {
 		return fmt.Sprintf("[%d]", f.sliceIdx)
 	}
+	if f.mapKey.IsValid() {
+		return fmt.Sprintf("[%v]", f.mapKey)
+	}
 	if f.altName != "" {
 		return f.altName
 	}
@@ -117,10 +219,13 @@ func (f *field) path() (path string) {
 		if f.parent != nil {
 			visit(f.parent)
 		}
+		if f.squash {
+			return
+		}
 		path += f.name()
 		// if it's a slice/array we don't want a dot before the slice indexer
 		// e.g. we want A[0].B instead of A.[0].B
-		if f.t.Kind() != reflect.Slice && f.t.Kind() != reflect.Array {
+		if f.t.Kind() != reflect.Slice && f.t.Kind() != reflect.Array && f.t.Kind() != reflect.Map {
 			path += "."
 		}
 	}
@@ -128,19 +233,56 @@ func (f *field) path() (path string) {
 	return strings.Trim(path, ".")
 }
 
+// tagParses counts the calls of parseTag, so that tests can check that the
+// loads of a Loader parse each tag once.
+var tagParses int64
+
 // parseTag parses a fields struct tags into a more easy to use structTag.
-// key is the key of the struct tag which contains the field's alt name.
+// key is the key of the struct tag which contains the field's alt name, or
+// a comma separated list of keys that are tried in order.
 func parseTag(tag reflect.StructTag, key string) (st structTag) {
-	if val, ok := tag.Lookup(key); ok {
-		i := strings.Index(val, ",")
-		if i == -1 {
-			i = len(val)
+	atomic.AddInt64(&tagParses, 1)
+	if val, ok := lookupTag(tag, key); ok {
+		// the default of the legacy syntax may contain commas, so it is
+		// the rest of the tag.
+		if i := strings.Index(val, ",default="); i != -1 {
+			st.legacy.setDefault = true
+			st.legacy.defaultVal = val[i+len(",default="):]
+			val = val[:i]
+		}
+		opts := strings.Split(val, ",")
+		st.altName = opts[0]
+		for _, opt := range opts[1:] {
+			name, param := opt, ""
+			if i := strings.Index(opt, "="); i != -1 {
+				name, param = opt[:i], opt[i+1:]
+			}
+			switch name {
+			case "unit":
+				st.unit = param
+			case "envmode":
+				st.envMode = param
+			case "secret":
+				st.secret = true
+			case "alias":
+				st.aliases = append(st.aliases, param)
+			case "required":
+				st.legacy.required = true
+			}
 		}
-		st.altName = val[:i]
 	}
 
-	if val := tag.Get("validate"); val == "required" {
-		st.required = true
+	if val, ok := tag.Lookup("validate"); ok {
+		st.rules = parseRules(val)
+		for _, r := range st.rules {
+			if r.name == "required" && !r.warn {
+				st.required = true
+			}
+		}
+	}
+
+	if val, ok := tag.Lookup("secret"); ok && val == "true" {
+		st.secret = true
 	}
 
 	if val, ok := tag.Lookup("default"); ok {
@@ -148,13 +290,80 @@ func parseTag(tag reflect.StructTag, key string) (st structTag) {
 		st.defaultVal = val
 	}
 
+	if val, ok := tag.Lookup("defaultLen"); ok {
+		st.defaultLen = val
+	}
+
+	if val, ok := tag.Lookup("desc"); ok {
+		st.desc = val
+	}
+
 	return
 }
 
 // structTag contains information gathered from parsing a field's tags.
 type structTag struct {
-	altName    string // the alt name of the field as defined in the tag.
-	required   bool   // true if the tag contained a required validation key.
-	setDefault bool   // true if tag contained a default key.
-	defaultVal string // the value of the default key.
+	altName    string   // the alt name of the field as defined in the tag.
+	required   bool     // true if the tag contained a required validation key.
+	setDefault bool     // true if tag contained a default key.
+	defaultVal string   // the value of the default key.
+	rules      []rule   // the rules of the validate key.
+	unit       string   // the unit of bare numbers as defined in the tag.
+	envMode    string   // how values from the environment are set on slices.
+	secret     bool     // true if the field holds a secret that must be redacted.
+	aliases    []string // the deprecated names of the field.
+	defaultLen string   // the number of elements of an empty slice as defined in the tag.
+	desc       string   // the description of the field, used in generated docs.
+
+	// the required and default options of the combined tag syntax of
+	// older fig versions, e.g. `fig:"name,required"`.
+	legacy struct {
+		required   bool
+		setDefault bool
+		defaultVal string
+	}
+}
+
+// applyLegacy applies the options of the legacy combined tag syntax to the
+// tag, unless the tag declares them in the validate and default tags.
+func (st *structTag) applyLegacy() {
+	if st.legacy.required && !st.required {
+		st.required = true
+		st.rules = append(st.rules, rule{name: "required"})
+	}
+	if st.legacy.setDefault && !st.setDefault {
+		st.setDefault = true
+		st.defaultVal = st.legacy.defaultVal
+	}
+}
+
+// lenHint returns the number of elements that an empty slice field is
+// instantiated with. It is set by the defaultLen tag, or by the default
+// tag of a slice of structs.
+func (f *field) lenHint() (string, bool) {
+	if f.t.Kind() != reflect.Slice {
+		return "", false
+	}
+	if f.defaultLen != "" {
+		return f.defaultLen, true
+	}
+	elem := f.t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if f.setDefault && elem.Kind() == reflect.Struct && !isLeafStruct(elem) {
+		return f.defaultVal, true
+	}
+	return "", false
+}
+
+// lookupTag returns the value of the first of the comma separated tag keys
+// in key that is present in tag.
+func lookupTag(tag reflect.StructTag, key string) (string, bool) {
+	for _, k := range strings.Split(key, ",") {
+		if val, ok := tag.Lookup(k); ok {
+			return val, true
+		}
+	}
+	return "", false
 }
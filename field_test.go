@@ -145,16 +145,32 @@ func Test_parseTag(t *testing.T) {
 		},
 		{
 			tagVal: `conf:"b" validate:"required"`,
-			want:   structTag{altName: "b", required: true},
+			want:   structTag{altName: "b", required: true, rules: []rule{{name: "required"}}},
 		},
 		{
 			tagVal: `conf:"b" validate:"required" default:"go"`,
-			want:   structTag{altName: "b", required: true, setDefault: true, defaultVal: "go"},
+			want:   structTag{altName: "b", required: true, setDefault: true, defaultVal: "go", rules: []rule{{name: "required"}}},
+		},
+		{
+			tagVal: `conf:"b" validate:"required_if=Mode production Region eu"`,
+			want:   structTag{altName: "b", rules: []rule{{name: "required_if", param: "Mode production Region eu"}}},
+		},
+		{
+			tagVal: `conf:"d,secret"`,
+			want:   structTag{altName: "d", secret: true},
+		},
+		{
+			tagVal: `conf:"d" secret:"true"`,
+			want:   structTag{altName: "d", secret: true},
 		},
 		{
 			tagVal: `conf:"c,omitempty"`,
 			want:   structTag{altName: "c"},
 		},
+		{
+			tagVal: `conf:"workers" defaultLen:"3"`,
+			want:   structTag{altName: "workers", defaultLen: "3"},
+		},
 	} {
 		t.Run(tc.tagVal, func(t *testing.T) {
 			tag := parseTag(reflect.StructTag(tc.tagVal), "conf")
@@ -165,6 +181,19 @@ func Test_parseTag(t *testing.T) {
 	}
 }
 
+func Test_parseTag_MultipleKeys(t *testing.T) {
+	for tagVal, want := range map[string]string{
+		`fig:"a"`:          "a",
+		`conf:"a" fig:"b"`: "a",
+		`fig:"b" conf:"a"`: "a",
+		`json:"c"`:         "",
+	} {
+		if got := parseTag(reflect.StructTag(tagVal), "conf,fig").altName; got != want {
+			t.Errorf("%s: altName == %q, expected %q", tagVal, got, want)
+		}
+	}
+}
+
 func checkField(t *testing.T, f *field, name, path string) {
 	t.Helper()
 	if f.name() != name {
@@ -0,0 +1,155 @@
+package confucius
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// LoadEnvFlags loads a config from the environment and command line flags
+// only, without looking for config files. It is meant for small tools that
+// never have config files. The parameter `cfg` must be a pointer to a
+// struct.
+//
+//   var cfg Config
+//   err := confucius.LoadEnvFlags(&cfg, "mytool", flag.CommandLine)
+//
+// A flag named after the path of each field is defined on fs, unless fs
+// already has a flag with that name, and fs is parsed from os.Args[1:] if it
+// was not parsed yet. With the struct of `UseEnv` the flags -build,
+// -log_level and -server.host are defined.
+//
+// Values set by flags take precedence over the environment, which takes
+// precedence over defaults. The tags and validations work as with `Load`,
+// and options that relate to tags and the environment (e.g. `Tag` or
+// `EnvSeparator`) are applied.
+func LoadEnvFlags(cfg interface{}, prefix string, fs *flag.FlagSet, options ...Option) error {
+	c := defaultConfucius()
+
+	for _, opt := range options {
+		opt(c)
+	}
+	c.useEnv = true
+	c.envPrefix = prefix
+
+	if !isStructPtr(cfg) {
+		return fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	for _, def := range c.flagDefs(cfg) {
+		if fs.Lookup(def.Name) != nil {
+			continue
+		}
+		fs.Var(&flagValue{value: def.Default, isBool: def.Bool}, def.Name, def.Usage)
+	}
+
+	if !fs.Parsed() {
+		if err := fs.Parse(os.Args[1:]); err != nil {
+			return err
+		}
+	}
+
+	c.flags = make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		c.flags[f.Name] = f.Value.String()
+	})
+
+	return c.atomically(cfg, func(cfg interface{}) error {
+		if err := c.fail(c.processCfg(cfg)); err != nil {
+			return err
+		}
+		if len(c.errs) > 0 {
+			return &LoadError{Config: cfg, Errors: c.errs}
+		}
+		return nil
+	})
+}
+
+// FlagDef describes the command line flag of a field of a config.
+type FlagDef struct {
+	Name    string // the path of the field, e.g. "server.host".
+	Default string // the default value of the field; empty for secrets.
+	Usage   string // the description of the field and the environment variable that sets it.
+	Bool    bool   // true if the field is a bool, so the flag needs no value.
+}
+
+// FlagDefs describes a command line flag for every field of cfg that holds
+// a single value, so that flags can be defined on any flag package. The
+// parameter `cfg` must be a pointer to a struct. The values of the flags
+// that were set are passed to `Load` with `FlagValues`.
+//
+//   defs, err := confucius.FlagDefs(&cfg, confucius.UseEnv("myapp"))
+//
+// The usage holds the description of the `desc` tag and, with `UseEnv`, the
+// environment variable of the field.
+func FlagDefs(cfg interface{}, options ...Option) ([]FlagDef, error) {
+	c := defaultConfucius()
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if !isStructPtr(cfg) {
+		return nil, fmt.Errorf("cfg must be a pointer to a struct")
+	}
+	return c.flagDefs(cfg), nil
+}
+
+// flagDefs returns the flags of the fields of cfg.
+func (c *confucius) flagDefs(cfg interface{}) []FlagDef {
+	var defs []FlagDef
+	for _, f := range c.flatten(cfg) {
+		if !isFlagField(f) {
+			continue
+		}
+		path := f.path()
+		def := FlagDef{Name: path, Usage: f.desc, Bool: f.t.Kind() == reflect.Bool}
+		if f.setDefault && !f.secret {
+			def.Default = f.defaultVal
+		}
+		if c.useEnv {
+			env := "env " + c.formatEnvKey(path)
+			if def.Usage != "" {
+				env = def.Usage + " (" + env + ")"
+			}
+			def.Usage = env
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// isFlagField reports whether a flag can be defined for f, which is the
+// case for fields that hold a single value and are not slice elements or
+// map values.
+func isFlagField(f *field) bool {
+	for p := f; p != nil; p = p.parent {
+		if p.sliceIdx >= 0 || p.mapKey.IsValid() {
+			return false
+		}
+	}
+	return f.t.Kind() != reflect.Struct || isLeafStruct(f.t)
+}
+
+// flagValue is the flag.Value of the flags defined by LoadEnvFlags. It
+// holds the flag's value as a string, which is then set like a value from
+// the environment.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	v.value = strings.TrimSpace(s)
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
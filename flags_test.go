@@ -0,0 +1,136 @@
+package confucius
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_LoadEnvFlags(t *testing.T) {
+	type Config struct {
+		Name    string        `conf:"name" validate:"required"`
+		Verbose bool          `conf:"verbose"`
+		Timeout time.Duration `conf:"timeout" default:"5s"`
+		Retries int           `conf:"retries" default:"3"`
+		Server  struct {
+			Host string `conf:"host" default:"localhost"`
+			Port int    `conf:"port"`
+		} `conf:"server"`
+		Tags []string `conf:"tags"`
+	}
+
+	setenv(t, "TOOL_SERVER_HOST", "env-host")
+	setenv(t, "TOOL_SERVER_PORT", "80")
+	defer os.Unsetenv("TOOL_SERVER_HOST")
+	defer os.Unsetenv("TOOL_SERVER_PORT")
+
+	t.Run("flags and env", func(t *testing.T) {
+		fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+		defer func(args []string) { os.Args = args }(os.Args)
+		os.Args = []string{"tool", "-name", "demo", "-verbose", "-server.port", "8080", "-tags", "a,b"}
+
+		var cfg Config
+		if err := LoadEnvFlags(&cfg, "tool", fs); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Name != "demo" || !cfg.Verbose || cfg.Timeout != 5*time.Second || cfg.Retries != 3 {
+			t.Errorf("unexpected cfg: %+v", cfg)
+		}
+		if cfg.Server.Host != "env-host" || cfg.Server.Port != 8080 {
+			t.Errorf("unexpected cfg.Server: %+v", cfg.Server)
+		}
+		if len(cfg.Tags) != 2 {
+			t.Errorf("unexpected cfg.Tags: %v", cfg.Tags)
+		}
+		if f := fs.Lookup("timeout"); f == nil || f.DefValue != "5s" || f.Usage != "env TOOL_TIMEOUT" {
+			t.Errorf("unexpected timeout flag: %+v", f)
+		}
+	})
+
+	t.Run("parsed flag set with own flags", func(t *testing.T) {
+		fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+		fs.Int("retries", 0, "number of retries")
+		if err := fs.Parse([]string{"-retries", "0"}); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+
+		var cfg Config
+		err := LoadEnvFlags(&cfg, "tool", fs)
+		if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["name"] == nil || len(fieldErrs) != 1 {
+			t.Fatalf("expected name err, got %v", err)
+		}
+		if cfg.Retries != 0 {
+			t.Errorf("expected explicit retries flag to be kept, got %d", cfg.Retries)
+		}
+	})
+
+	t.Run("invalid flag value", func(t *testing.T) {
+		fs := flag.NewFlagSet("tool", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		if err := fs.Parse(nil); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		fs.Var(&flagValue{value: "x"}, "name", "")
+		_ = fs.Set("name", "demo")
+		fs.Var(&flagValue{}, "retries", "")
+		_ = fs.Set("retries", "many")
+
+		err := LoadEnvFlags(&Config{}, "tool", fs)
+		if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["retries"] == nil {
+			t.Errorf("expected retries err, got %v", err)
+		}
+	})
+}
+
+func Test_FlagDefs(t *testing.T) {
+	type Config struct {
+		Verbose  bool   `conf:"verbose"`
+		Password string `conf:"password" default:"changeme" secret:"true"`
+		Server   struct {
+			Host string `conf:"host" default:"localhost" desc:"The host to bind."`
+		} `conf:"server"`
+		Tags []string `conf:"tags"`
+	}
+
+	defs, err := FlagDefs(&Config{}, UseEnv("myapp"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := []FlagDef{
+		{Name: "verbose", Usage: "env MYAPP_VERBOSE", Bool: true},
+		{Name: "password", Usage: "env MYAPP_PASSWORD"},
+		{Name: "server.host", Default: "localhost", Usage: "The host to bind. (env MYAPP_SERVER_HOST)"},
+		{Name: "tags", Usage: "env MYAPP_TAGS"},
+	}
+	if !reflect.DeepEqual(defs, want) {
+		t.Errorf("FlagDefs() == %+v, expected %+v", defs, want)
+	}
+
+	if _, err := FlagDefs(Config{}); err == nil {
+		t.Errorf("expected err for a non pointer cfg")
+	}
+}
+
+func Test_FlagValues(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host" default:"localhost"`
+		Port int    `conf:"port"`
+	}
+
+	setenv(t, "MYAPP_HOST", "env-host")
+	setenv(t, "MYAPP_PORT", "80")
+	defer os.Unsetenv("MYAPP_HOST")
+	defer os.Unsetenv("MYAPP_PORT")
+
+	var cfg Config
+	err := Load(&cfg, String("{}", DecoderJSON), UseEnv("myapp"), FlagValues(map[string]string{"port": "8080"}))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "env-host" || cfg.Port != 8080 {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+}
@@ -0,0 +1,70 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Get loads the single value at path, e.g. `features.new_checkout`, without
+// declaring a struct for the config. The config files, profiles and the
+// environment are found and merged as by `Load`.
+//
+//   enabled, err := confucius.Get[bool]("features.new_checkout", confucius.UseEnv("app"))
+//
+// The value is decoded into T as a struct field would be, so T can be any
+// type that a field can have, including structs and slices. Nested keys
+// are separated by dots; paths into slices are not supported. The zero
+// value of T is returned if the key is absent from all sources.
+func Get[T any](path string, options ...Option) (T, error) {
+	var zero T
+
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if segment == "" || strings.ContainsAny(segment, "[]") {
+			return zero, fmt.Errorf("invalid path %q", path)
+		}
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for i := len(segments) - 1; i >= 0; i-- {
+		t = reflect.StructOf([]reflect.StructField{{
+			Name: "Value",
+			Type: t,
+			Tag:  reflect.StructTag(fmt.Sprintf("%s:%q", c.decodeTag(), segments[i])),
+		}})
+	}
+
+	cfg := reflect.New(t)
+	if err := c.Load(cfg.Interface()); err != nil {
+		return zero, err
+	}
+
+	v := cfg.Elem()
+	for range segments {
+		v = v.Field(0)
+	}
+	return v.Interface().(T), nil
+}
+
+// LoadAs loads a config into a new value of type T and returns it, without
+// declaring a variable and passing a pointer as with `Load`.
+//
+//   cfg, err := confucius.LoadAs[Config](confucius.File("config.yaml"))
+//
+// T must be a struct, or a slice for config documents whose root is a
+// list. On error the zero value of T is returned, never a partially loaded
+// config; use `Load` with `Lenient` to inspect what could be loaded.
+func LoadAs[T any](options ...Option) (T, error) {
+	var cfg T
+	if err := Load(&cfg, options...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,88 @@
+package confucius
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Get(t *testing.T) {
+	dir := t.TempDir()
+	content := "features:\n  new_checkout: true\n  rollout: 25%\nserver:\n  timeout: 5s\n  hosts: [a, b]\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	enabled, err := Get[bool]("features.new_checkout", Dirs(dir))
+	if err != nil || !enabled {
+		t.Errorf("Get[bool]() == %v, %v", enabled, err)
+	}
+
+	rollout, err := Get[Percent]("features.rollout", Dirs(dir))
+	if err != nil || rollout != 0.25 {
+		t.Errorf("Get[Percent]() == %v, %v", rollout, err)
+	}
+
+	hosts, err := Get[[]string]("server.hosts", Dirs(dir))
+	if err != nil || !reflect.DeepEqual(hosts, []string{"a", "b"}) {
+		t.Errorf("Get[[]string]() == %v, %v", hosts, err)
+	}
+
+	setenv(t, "APP_SERVER_TIMEOUT", "10s")
+	defer os.Unsetenv("APP_SERVER_TIMEOUT")
+	timeout, err := Get[time.Duration]("server.timeout", Dirs(dir), UseEnv("app"))
+	if err != nil || timeout != 10*time.Second {
+		t.Errorf("Get[time.Duration]() == %v, %v", timeout, err)
+	}
+
+	type Server struct {
+		Timeout time.Duration `conf:"timeout"`
+		Port    int           `conf:"port" default:"8080"`
+	}
+	server, err := Get[Server]("server", Dirs(dir))
+	if err != nil || server.Timeout != 5*time.Second || server.Port != 8080 {
+		t.Errorf("Get[Server]() == %+v, %v", server, err)
+	}
+
+	missing, err := Get[string]("features.missing", Dirs(dir))
+	if err != nil || missing != "" {
+		t.Errorf("Get[string]() == %q, %v", missing, err)
+	}
+
+	if _, err := Get[string]("server..port", Dirs(dir)); err == nil {
+		t.Errorf("expected err for invalid path")
+	}
+}
+
+func Test_LoadAs(t *testing.T) {
+	type Config struct {
+		Name string `conf:"name" validate:"required"`
+		Port int    `conf:"port" default:"80"`
+	}
+
+	cfg, err := LoadAs[Config](String(`{"name": "app"}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg != (Config{Name: "app", Port: 80}) {
+		t.Errorf("cfg == %+v", cfg)
+	}
+
+	cfg, err = LoadAs[Config](String(`{"port": 8080}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+	if cfg != (Config{}) {
+		t.Errorf("cfg == %+v, expected the zero value on error", cfg)
+	}
+
+	list, err := LoadAs[[]Config](String(`[{"name": "a"}, {"name": "b", "port": 1}]`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(list) != 2 || list[0].Port != 80 || list[1].Port != 1 {
+		t.Errorf("list == %+v", list)
+	}
+}
@@ -0,0 +1,78 @@
+package confucius
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Glob is a glob pattern that is compiled and validated when the config is
+// loaded. Patterns use the syntax of path.Match for each path segment and
+// additionally support `**` as a segment that matches zero or more
+// directories, e.g. `src/**/*.go`.
+//
+//   type Config struct {
+//     Include []confucius.Glob `conf:"include" default:"[**/*.go]"`
+//     Exclude []confucius.Glob `conf:"exclude"`
+//   }
+type Glob struct {
+	pattern  string
+	segments []string
+}
+
+// String returns the pattern.
+func (g Glob) String() string {
+	return g.pattern
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (g Glob) MarshalText() ([]byte, error) {
+	return []byte(g.pattern), nil
+}
+
+// Match reports whether the slash separated name matches the pattern.
+func (g Glob) Match(name string) bool {
+	return matchSegments(g.segments, strings.Split(name, "/"))
+}
+
+func (g *Glob) parse(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return fmt.Errorf("empty glob pattern")
+	}
+
+	segments := strings.Split(s, "/")
+	for _, segment := range segments {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("invalid glob pattern %q: %v", s, err)
+		}
+	}
+	*g = Glob{pattern: s, segments: segments}
+	return nil
+}
+
+// matchSegments matches the segments of a name against the segments of a
+// pattern. A `**` segment matches any number of name segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,66 @@
+package confucius
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGlob_Match(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.go", name: "main.go", want: true},
+		{pattern: "*.go", name: "cmd/main.go", want: false},
+		{pattern: "**/*.go", name: "main.go", want: true},
+		{pattern: "**/*.go", name: "cmd/app/main.go", want: true},
+		{pattern: "src/**", name: "src", want: true},
+		{pattern: "src/**", name: "src/a/b", want: true},
+		{pattern: "src/**/test/*.yaml", name: "src/x/y/test/a.yaml", want: true},
+		{pattern: "src/**/test/*.yaml", name: "src/x/y/a.yaml", want: false},
+		{pattern: "backup-[0-9]?.tar", name: "backup-12.tar", want: true},
+		{pattern: "/var/log/*.log", name: "/var/log/syslog.log", want: true},
+	} {
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			var g Glob
+			if err := g.parse(tc.pattern); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if got := g.Match(tc.name); got != tc.want {
+				t.Errorf("Match(%q) == %v, expected %v", tc.name, got, tc.want)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_Glob(t *testing.T) {
+	type Config struct {
+		Include []Glob `conf:"include" default:"[**/*.go]"`
+		Exclude []Glob `conf:"exclude"`
+		Root    Glob   `conf:"root"`
+	}
+
+	setenv(t, "APP_EXCLUDE", "vendor/**,**/*_test.go")
+	defer os.Unsetenv("APP_EXCLUDE")
+
+	var cfg Config
+	if err := Load(&cfg, UseEnv("app"), String(`{"root": "src/*"}`, DecoderJSON)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if len(cfg.Include) != 1 || !cfg.Include[0].Match("a/b.go") {
+		t.Errorf("cfg.Include == %v", cfg.Include)
+	}
+	if len(cfg.Exclude) != 2 || !cfg.Exclude[0].Match("vendor/x/y.go") || !cfg.Exclude[1].Match("a/b_test.go") {
+		t.Errorf("cfg.Exclude == %v", cfg.Exclude)
+	}
+	if cfg.Root.String() != "src/*" {
+		t.Errorf("cfg.Root == %v", cfg.Root)
+	}
+
+	err := Load(&cfg, String(`{"exclude": ["[a-"]}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+}
@@ -1,11 +1,16 @@
 module github.com/hasanozgan/confucius
 
-go 1.16
+go 1.18
 
 require (
 	github.com/imdario/mergo v0.3.12
+	github.com/klauspost/compress v1.15.15
 	github.com/mattn/goveralls v0.0.8 // indirect
-	github.com/mitchellh/mapstructure v1.1.2
-	github.com/pelletier/go-toml v1.6.0
+	github.com/mitchellh/mapstructure v1.5.0
+	github.com/pelletier/go-toml/v2 v2.0.5
+	github.com/robfig/cron/v3 v3.0.1
+	github.com/vmihailenco/msgpack/v5 v5.3.5
 	gopkg.in/yaml.v2 v2.3.0
 )
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
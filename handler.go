@@ -0,0 +1,73 @@
+package confucius
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+)
+
+// handlerResponse is the body served by Handler.
+type handlerResponse struct {
+	Config  interface{}       `json:"config"`
+	Sources map[string]string `json:"sources"`
+}
+
+// Handler returns an http.Handler that serves the effective configuration
+// as JSON, suitable for mounting at a debug endpoint:
+//
+//   report, err := confucius.LoadWithReport(&cfg, confucius.UseEnv("myapp"))
+//   ...
+//   http.Handle("/debug/config", confucius.Handler(&cfg, report))
+//
+// The parameter `cfg` must be a pointer to a struct, or a
+// func() (interface{}, *Report) that returns one and the report of the load
+// that produced it, e.g. the current config of a holder that swaps configs
+// on reload. The body holds the config keyed by the names used in config
+// files, with secrets masked as with `Dump`, and the source of each field
+// by path, as found by the load:
+//
+//   {"config": {"port": 8080}, "sources": {"port": "env MYAPP_PORT"}}
+//
+// The parameter `report` is ignored if `cfg` is a func, and the sources are
+// empty if the report is nil. Nothing is loaded when the handler is served,
+// so the options only set the tags used to name the fields.
+func Handler(cfg interface{}, report *Report, options ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		current, currentReport := cfg, report
+		if fn, ok := cfg.(func() (interface{}, *Report)); ok {
+			current, currentReport = fn()
+		}
+		if !isStructPtr(current) {
+			http.Error(w, "cfg must be a pointer to a struct", http.StatusInternalServerError)
+			return
+		}
+
+		c := defaultConfucius()
+		for _, opt := range options {
+			opt(c)
+		}
+		e := c.newValueEncoder()
+		e.mask = true
+		values, _ := e.encode(reflect.ValueOf(current), "")
+
+		resp := handlerResponse{Config: values, Sources: make(map[string]string)}
+		if currentReport != nil {
+			for path, source := range currentReport.Sources {
+				if source.Kind != "" {
+					resp.Sources[path] = source.String()
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(resp)
+	})
+}
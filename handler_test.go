@@ -0,0 +1,71 @@
+package confucius
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Handler(t *testing.T) {
+	type Config struct {
+		Name     string `conf:"name"`
+		Port     int    `conf:"port" default:"80"`
+		Password string `conf:"password" secret:"true"`
+	}
+
+	setenv(t, "MYAPP_PORT", "8080")
+	defer os.Unsetenv("MYAPP_PORT")
+
+	options := []Option{String(`{"name": "app", "password": "hunter2"}`, DecoderJSON), UseEnv("myapp")}
+	var cfg Config
+	report, err := LoadWithReport(&cfg, options...)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	// the handler serves the sources of the load, not the current env.
+	setenv(t, "MYAPP_NAME", "other")
+	defer os.Unsetenv("MYAPP_NAME")
+
+	for name, h := range map[string]http.Handler{
+		"cfg":  Handler(&cfg, report),
+		"func": Handler(func() (interface{}, *Report) { return &cfg, report }, nil),
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status == %d, expected %d", name, rec.Code, http.StatusOK)
+		}
+		if strings.Contains(rec.Body.String(), "hunter2") {
+			t.Errorf("%s: body contains the secret: %s", name, rec.Body)
+		}
+
+		var body struct {
+			Config  map[string]interface{} `json:"config"`
+			Sources map[string]string      `json:"sources"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: unexpected err: %v", name, err)
+		}
+		if body.Config["name"] != "app" || body.Config["port"] != float64(8080) || body.Config["password"] != RedactedValue {
+			t.Errorf("%s: unexpected config: %v", name, body.Config)
+		}
+		if body.Sources["port"] != "env MYAPP_PORT" || body.Sources["name"] != "reader" {
+			t.Errorf("%s: unexpected sources: %v", name, body.Sources)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(&cfg, report).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/config", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status == %d, expected %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	rec = httptest.NewRecorder()
+	Handler(&cfg, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"sources": {}`) {
+		t.Errorf("unexpected response without report: %d %s", rec.Code, rec.Body)
+	}
+}
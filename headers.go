@@ -0,0 +1,125 @@
+package confucius
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Headers is a set of HTTP headers whose names are canonicalized when
+// the config is loaded, so `x-api-key` and `X-API-KEY` refer to the same
+// header. A header can have a single value or a list of values:
+//
+//   headers:
+//     x-api-key: "${API_KEY}"
+//     accept: ["application/json", "text/plain"]
+//
+// In the environment and in defaults headers are given as comma separated
+// `Name=value` pairs, repeating the name for multiple values:
+//
+//   MYAPP_HEADERS="X-Api-Key=secret,Accept=application/json,Accept=text/plain"
+type Headers map[string][]string
+
+var headersType = reflect.TypeOf(Headers{})
+
+// Get returns the first value of the header, or "" if it is not set.
+func (h Headers) Get(name string) string {
+	return http.Header(h).Get(name)
+}
+
+// Values returns all the values of the header.
+func (h Headers) Values(name string) []string {
+	return h[textproto.CanonicalMIMEHeaderKey(name)]
+}
+
+// Add adds a value to the header.
+func (h Headers) Add(name, value string) {
+	http.Header(h).Add(name, value)
+}
+
+// Set replaces the values of the header with value.
+func (h Headers) Set(name, value string) {
+	http.Header(h).Set(name, value)
+}
+
+// Header returns a copy of the headers as an http.Header.
+func (h Headers) Header() http.Header {
+	return http.Header(h).Clone()
+}
+
+// marshalValue returns the headers in the shape they are written in config
+// files: single values as strings and multiple values as lists.
+func (h Headers) marshalValue() interface{} {
+	m := make(map[string]interface{}, len(h))
+	for name, values := range h {
+		if len(values) == 1 {
+			m[name] = values[0]
+			continue
+		}
+		list := make([]interface{}, len(values))
+		for i, value := range values {
+			list[i] = value
+		}
+		m[name] = list
+	}
+	return m
+}
+
+func (h *Headers) parse(s string) error {
+	headers := Headers{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		i := strings.Index(pair, "=")
+		if i == -1 {
+			return fmt.Errorf("invalid header %q: expected Name=value", pair)
+		}
+		if err := headers.addValue(pair[:i], pair[i+1:]); err != nil {
+			return err
+		}
+	}
+	*h = headers
+	return nil
+}
+
+// addValue validates the name of the header and adds the value.
+func (h Headers) addValue(name, value string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	h.Add(name, strings.TrimSpace(value))
+	return nil
+}
+
+// headersHookFunc returns a decode hook that decodes maps into Headers,
+// canonicalizing the header names.
+func headersHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if t != headersType || f.Kind() != reflect.Map {
+			return data, nil
+		}
+
+		headers := Headers{}
+		v := reflect.ValueOf(data)
+		for _, key := range v.MapKeys() {
+			name := fmt.Sprint(key.Interface())
+			val := v.MapIndex(key).Interface()
+			values, ok := val.([]interface{})
+			if !ok {
+				values = []interface{}{val}
+			}
+			for _, value := range values {
+				if err := headers.addValue(name, fmt.Sprint(value)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return headers, nil
+	}
+}
@@ -0,0 +1,79 @@
+package confucius
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestHeaders_parse(t *testing.T) {
+	var h Headers
+	if err := h.parse("x-api-key=secret, accept=application/json,Accept=text/plain"); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := Headers{"X-Api-Key": {"secret"}, "Accept": {"application/json", "text/plain"}}
+	if !reflect.DeepEqual(want, h) {
+		t.Errorf("want %+v, got %+v", want, h)
+	}
+
+	for _, bad := range []string{"accept", "bad name=x", "=x"} {
+		if err := h.parse(bad); err == nil {
+			t.Errorf("parse(%q) expected err", bad)
+		}
+	}
+}
+
+func TestHeaders_marshalValue(t *testing.T) {
+	h := Headers{"X-Api-Key": {"secret"}, "Accept": {"a", "b"}}
+	want := map[string]interface{}{"X-Api-Key": "secret", "Accept": []interface{}{"a", "b"}}
+	if got := h.marshalValue(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func Test_confucius_Load_Headers(t *testing.T) {
+	type Config struct {
+		Upstream Headers  `conf:"upstream"`
+		Response Headers  `conf:"response" default:"cache-control=no-store"`
+		Extra    Headers  `conf:"extra"`
+		Optional *Headers `conf:"optional"`
+	}
+
+	setenv(t, "APP_EXTRA", "x-trace=on")
+	defer os.Unsetenv("APP_EXTRA")
+
+	var cfg Config
+	err := Load(&cfg, UseEnv("app"), String(`
+upstream:
+  x-api-key: secret
+  accept: ["application/json", "text/plain"]
+  x-retries: 3
+`, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if got := cfg.Upstream.Get("X-API-KEY"); got != "secret" {
+		t.Errorf("X-Api-Key == %q, expected %q", got, "secret")
+	}
+	if got := cfg.Upstream.Values("accept"); !reflect.DeepEqual([]string{"application/json", "text/plain"}, got) {
+		t.Errorf("Accept == %v", got)
+	}
+	if got := cfg.Upstream.Get("X-Retries"); got != "3" {
+		t.Errorf("X-Retries == %q, expected %q", got, "3")
+	}
+	if got := cfg.Response.Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control == %q, expected %q", got, "no-store")
+	}
+	if got := cfg.Extra.Get("X-Trace"); got != "on" {
+		t.Errorf("X-Trace == %q, expected %q", got, "on")
+	}
+	if cfg.Optional != nil {
+		t.Errorf("cfg.Optional == %v, expected nil", cfg.Optional)
+	}
+
+	err = Load(&cfg, String(`{"upstream": {"bad name": "x"}}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+}
@@ -0,0 +1,155 @@
+package confucius
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// srvScheme is the prefix of host list entries that are resolved using
+// DNS SRV records.
+const srvScheme = "srv://"
+
+// lookupSRV looks up SRV records. It's a variable so it can be replaced
+// in tests.
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+// HostPort is a `host:port` pair. IPv6 hosts must be enclosed in square
+// brackets (`[::1]:8080`) and the host may be left empty for listen
+// addresses (`:8080`). The port must be a number between 0 and 65535.
+//
+//   type Config struct {
+//     Listen   confucius.HostPort     `conf:"listen" default:":8080"`
+//     Backends confucius.HostPortList `conf:"backends"`
+//   }
+type HostPort struct {
+	Host string
+	Port int
+	// SRV is the name of the SRV record of entries set from `srv://name`.
+	// Such entries have no host and port until the list is resolved.
+	SRV string
+}
+
+// String formats the pair as `host:port`.
+func (hp HostPort) String() string {
+	if hp.SRV != "" {
+		return srvScheme + hp.SRV
+	}
+	return net.JoinHostPort(hp.Host, strconv.Itoa(hp.Port))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (hp HostPort) MarshalText() ([]byte, error) {
+	return []byte(hp.String()), nil
+}
+
+func (hp *HostPort) parse(s string) error {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, srvScheme) {
+		*hp = HostPort{SRV: strings.TrimPrefix(s, srvScheme)}
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return fmt.Errorf("invalid host:port %q: %v", s, err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > 65535 {
+		return fmt.Errorf("invalid port in %q", s)
+	}
+	*hp = HostPort{Host: host, Port: p}
+	return nil
+}
+
+// HostPortList is a list of `host:port` pairs. It can be set from a list
+// in a config file or from a comma separated string (`a:80,b:80`).
+//
+// Entries in the form `srv://_service._proto.name` are only resolved when
+// `Resolve` is called, which makes DNS SRV lookups opt-in.
+type HostPortList []HostPort
+
+// String formats the list as comma separated `host:port` pairs.
+func (l HostPortList) String() string {
+	parts := make([]string, len(l))
+	for i, hp := range l {
+		parts[i] = hp.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l HostPortList) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *HostPortList) parse(s string) error {
+	list := HostPortList{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var hp HostPort
+		if err := hp.parse(part); err != nil {
+			return err
+		}
+		list = append(list, hp)
+	}
+	*l = list
+	return nil
+}
+
+// Resolve returns the list with the `srv://` entries replaced by the
+// targets of their SRV records, in the order returned by the resolver.
+func (l HostPortList) Resolve(ctx context.Context) (HostPortList, error) {
+	result := make(HostPortList, 0, len(l))
+	for _, hp := range l {
+		if hp.SRV == "" {
+			result = append(result, hp)
+			continue
+		}
+		_, addrs, err := lookupSRV(ctx, "", "", hp.SRV)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			result = append(result, HostPort{Host: strings.TrimSuffix(addr.Target, "."), Port: int(addr.Port)})
+		}
+	}
+	return result, nil
+}
+
+// hostportRule fails if a string field does not hold a `host:port` pair
+// with a numeric port, e.g. `localhost:8080` or `:8080`. Empty strings are
+// left to the required rule.
+func hostportRule(f *field, _ string) error {
+	return checkStrings(f.v, func(s string) error {
+		_, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return fmt.Errorf("invalid host:port %q: %v", s, err)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+			return fmt.Errorf("invalid port in %q", s)
+		}
+		return nil
+	})
+}
+
+// tcpAddrRule fails if a string field does not hold a TCP address that can
+// be resolved, e.g. a listen address. Host names are looked up. Empty
+// strings are left to the required rule.
+func tcpAddrRule(f *field, _ string) error {
+	return checkStrings(f.v, func(s string) error {
+		if _, err := resolveTCPAddr("tcp", s); err != nil {
+			return fmt.Errorf("invalid TCP address %q: %v", s, err)
+		}
+		return nil
+	})
+}
+
+// resolveTCPAddr resolves TCP addresses. It's a variable so it can be
+// replaced in tests.
+var resolveTCPAddr = net.ResolveTCPAddr
@@ -0,0 +1,205 @@
+package confucius
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHostPort_parse(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    HostPort
+		wantErr bool
+	}{
+		{in: "localhost:8080", want: HostPort{Host: "localhost", Port: 8080}},
+		{in: ":8080", want: HostPort{Port: 8080}},
+		{in: "[::1]:443", want: HostPort{Host: "::1", Port: 443}},
+		{in: " 10.0.0.1:53 ", want: HostPort{Host: "10.0.0.1", Port: 53}},
+		{in: "srv://_ldap._tcp.example.com", want: HostPort{SRV: "_ldap._tcp.example.com"}},
+		{in: "localhost", wantErr: true},
+		{in: "::1:443", wantErr: true},
+		{in: "localhost:http", wantErr: true},
+		{in: "localhost:70000", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			var hp HostPort
+			err := hp.parse(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if hp != tc.want {
+				t.Errorf("want %+v, got %+v", tc.want, hp)
+			}
+		})
+	}
+}
+
+func TestHostPortList_String(t *testing.T) {
+	l := HostPortList{{Host: "::1", Port: 80}, {Host: "a", Port: 81}, {SRV: "_x._tcp.b"}}
+	if got, want := l.String(), "[::1]:80,a:81,srv://_x._tcp.b"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestHostPortList_Resolve(t *testing.T) {
+	defer func(fn func(context.Context, string, string, string) (string, []*net.SRV, error)) { lookupSRV = fn }(lookupSRV)
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		return name, []*net.SRV{{Target: "a.example.com.", Port: 389}, {Target: "b.example.com.", Port: 636}}, nil
+	}
+
+	l := HostPortList{{Host: "c", Port: 1}, {SRV: "_ldap._tcp.example.com"}}
+	got, err := l.Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := HostPortList{{Host: "c", Port: 1}, {Host: "a.example.com", Port: 389}, {Host: "b.example.com", Port: 636}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func Test_confucius_Load_HostPort(t *testing.T) {
+	type Config struct {
+		Listen   HostPort     `conf:"listen" default:":8080"`
+		Admin    *HostPort    `conf:"admin"`
+		Backends HostPortList `conf:"backends"`
+		Peers    HostPortList `conf:"peers"`
+		Required HostPort     `conf:"required" validate:"required"`
+	}
+
+	setenv(t, "APP_PEERS", "p1:7000, [fe80::1]:7000")
+	defer os.Unsetenv("APP_PEERS")
+
+	var cfg Config
+	err := Load(&cfg, UseEnv("app"), String(`
+admin: "127.0.0.1:9000"
+backends:
+  - "b1:80"
+  - "b2:80"
+required: "r:1"
+`, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if cfg.Listen != (HostPort{Port: 8080}) {
+		t.Errorf("cfg.Listen == %+v", cfg.Listen)
+	}
+	if cfg.Admin == nil || *cfg.Admin != (HostPort{Host: "127.0.0.1", Port: 9000}) {
+		t.Errorf("cfg.Admin == %+v", cfg.Admin)
+	}
+	if want := (HostPortList{{Host: "b1", Port: 80}, {Host: "b2", Port: 80}}); !reflect.DeepEqual(want, cfg.Backends) {
+		t.Errorf("cfg.Backends == %+v", cfg.Backends)
+	}
+	if want := (HostPortList{{Host: "p1", Port: 7000}, {Host: "fe80::1", Port: 7000}}); !reflect.DeepEqual(want, cfg.Peers) {
+		t.Errorf("cfg.Peers == %+v", cfg.Peers)
+	}
+
+	var bad Config
+	err = Load(&bad, String(`{"backends": "b1"}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["backends"] == nil {
+		t.Fatalf("expected backends err, got %v", err)
+	}
+
+	err = Load(&bad, String(`{}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["required"] == nil {
+		t.Fatalf("expected required err, got %v", err)
+	}
+}
+
+func Test_confucius_Load_HostPortRules(t *testing.T) {
+	defer func(fn func(string, string) (*net.TCPAddr, error)) { resolveTCPAddr = fn }(resolveTCPAddr)
+	resolveTCPAddr = func(network, address string) (*net.TCPAddr, error) {
+		if strings.HasPrefix(address, "unknown:") {
+			return nil, errors.New("no such host")
+		}
+		return net.ResolveTCPAddr(network, address)
+	}
+
+	type Config struct {
+		Upstream string   `conf:"upstream" validate:"hostport"`
+		Peers    []string `conf:"peers" validate:"hostport"`
+		Listen   string   `conf:"listen" validate:"tcp_addr"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  string
+		wantErr map[string]string
+	}{
+		{name: "empty", config: `{}`},
+		{name: "valid", config: `{"upstream": "api.example.com:443", "peers": ["[::1]:7000", ":7001"], "listen": "127.0.0.1:8080"}`},
+		{
+			name:   "invalid",
+			config: `{"upstream": "api.example.com", "peers": [":7000", "p:http"], "listen": "unknown:8080"}`,
+			wantErr: map[string]string{
+				"upstream": `invalid host:port "api.example.com"`,
+				"peers":    `invalid port in "p:http"`,
+				"listen":   `invalid TCP address "unknown:8080": no such host`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := Load(&cfg, String(tc.config, DecoderJSON))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(fieldErrors)
+			if !ok || len(fieldErrs) != len(tc.wantErr) {
+				t.Fatalf("want errors for %v, got %v", tc.wantErr, err)
+			}
+			for path, want := range tc.wantErr {
+				if err := fieldErrs[path]; err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("want error %q for %s, got %v", want, path, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_HostPortEnv(t *testing.T) {
+	type Config struct {
+		Listen HostPort `conf:"listen" default:":8080"`
+	}
+
+	setenv(t, "APP_LISTEN", "example.com:80")
+	defer os.Unsetenv("APP_LISTEN")
+	setenv(t, "APP_LISTEN_PORT", "9999")
+	defer os.Unsetenv("APP_LISTEN_PORT")
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg, String(`{}`, DecoderJSON), UseEnv("app"), ReportUnusedEnv())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if want := (HostPort{Host: "example.com", Port: 80}); cfg.Listen != want {
+		t.Errorf("cfg.Listen == %+v, expected %+v", cfg.Listen, want)
+	}
+	if !containsString(report.UnusedEnv, "APP_LISTEN_PORT") {
+		t.Errorf("report.UnusedEnv == %v, expected APP_LISTEN_PORT", report.UnusedEnv)
+	}
+
+	defs, err := FlagDefs(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(defs) != 1 || defs[0].Name != "listen" {
+		t.Errorf("defs == %+v, expected only listen", defs)
+	}
+}
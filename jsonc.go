@@ -0,0 +1,88 @@
+package confucius
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
+// jsoncReader returns a reader of the JSON with comments (JSONC) read from
+// reader as plain JSON. Line comments (`//`), block comments (`/* */`) and
+// trailing commas are replaced with spaces, so that the offsets of syntax
+// errors still point into the original document.
+func jsoncReader(reader io.Reader) (io.Reader, error) {
+	b, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(stripTrailingCommas(stripJSONComments(b))), nil
+}
+
+// stripJSONComments replaces the comments in b, outside of strings, with
+// spaces. Line breaks within block comments are kept.
+func stripJSONComments(b []byte) []byte {
+	out := make([]byte, len(b))
+	copy(out, b)
+	inString := false
+	for i := 0; i < len(out); i++ {
+		switch {
+		case inString:
+			if out[i] == '\\' {
+				i++
+			} else if out[i] == '"' {
+				inString = false
+			}
+		case out[i] == '"':
+			inString = true
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			out[i], out[i+1] = ' ', ' '
+			for i += 2; i < len(out); i++ {
+				if out[i] == '*' && i+1 < len(out) && out[i+1] == '/' {
+					out[i], out[i+1] = ' ', ' '
+					i++
+					break
+				}
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+		}
+	}
+	return out
+}
+
+// stripTrailingCommas replaces the commas in b, outside of strings, that
+// are followed by only whitespace and the end of an object or array with
+// spaces. b is modified in place.
+func stripTrailingCommas(b []byte) []byte {
+	inString := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case inString:
+			if b[i] == '\\' {
+				i++
+			} else if b[i] == '"' {
+				inString = false
+			}
+		case b[i] == '"':
+			inString = true
+		case b[i] == ',':
+			j := i + 1
+			for j < len(b) && isJSONSpace(b[j]) {
+				j++
+			}
+			if j < len(b) && (b[j] == '}' || b[j] == ']') {
+				b[i] = ' '
+			}
+		}
+	}
+	return b
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
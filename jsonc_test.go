@@ -0,0 +1,91 @@
+package confucius
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_stripJSONComments(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "line", in: "{\"a\": 1} // x\n", want: "{\"a\": 1}     \n"},
+		{name: "block", in: "{/* a\nb */\"a\": 1}", want: "{    \n    \"a\": 1}"},
+		{name: "in string", in: `{"a": "http://x/*y*/"}`, want: `{"a": "http://x/*y*/"}`},
+		{name: "escaped quote", in: `{"a": "\"//"} //`, want: `{"a": "\"//"}   `},
+		{name: "trailing commas", in: "{\"a\": [1, 2,\n], \"b\": \",}\",}", want: "{\"a\": [1, 2 \n], \"b\": \",}\" }"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := string(stripTrailingCommas(stripJSONComments([]byte(tc.in))))
+			if got != tc.want {
+				t.Errorf("got %q, expected %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_JSONC(t *testing.T) {
+	const content = `{
+  // the server
+  "host": "example.com", /* the port */ "port": 8080,
+  "tags": ["a", "b",],
+}
+`
+	type Config struct {
+		Host string   `conf:"host"`
+		Port int      `conf:"port"`
+		Tags []string `conf:"tags"`
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"config.jsonc", "config.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		options []Option
+	}{
+		{name: "jsonc file", options: []Option{File("config.jsonc"), Dirs(dir)}},
+		{name: "jsonc string", options: []Option{String(content, DecoderJSONC)}},
+		{name: "lenient json", options: []Option{File("config.json"), Dirs(dir), LenientJSON()}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			if err := Load(&cfg, tc.options...); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if cfg.Host != "example.com" || cfg.Port != 8080 || len(cfg.Tags) != 2 {
+				t.Errorf("cfg == %+v", cfg)
+			}
+		})
+	}
+
+	t.Run("strict json", func(t *testing.T) {
+		var cfg Config
+		if err := Load(&cfg, File("config.json"), Dirs(dir)); err == nil {
+			t.Fatal("expected err")
+		}
+	})
+
+	t.Run("error position", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "broken.jsonc"), []byte("{\n  // comment\n  \"host\": ]\n}\n"), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		var cfg Config
+		err := Load(&cfg, File("broken.jsonc"), Dirs(dir))
+		var decodeErr *DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Fatalf("expected a DecodeError, got %v", err)
+		}
+		if decodeErr.Line != 3 || decodeErr.Column != 11 {
+			t.Errorf("position == %d:%d, expected 3:11", decodeErr.Line, decodeErr.Column)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// keyMatcher reports whether a key in a config file matches the name of a
+// field.
+type keyMatcher func(key, name string) bool
+
+// normalizeKey lower-cases key and strips dashes and underscores from it,
+// so that `logLevel`, `log-level` and `log_level` are the same key.
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+}
+
+// normalizedKeysMatch reports whether key and name are the same key once
+// normalized.
+func normalizedKeysMatch(key, name string) bool {
+	return normalizeKey(key) == normalizeKey(name)
+}
+
+// matchKeys walks the decoded values alongside the struct type t and
+// renames the keys that match the name of a field according to
+// c.keyMatcher to that name, so that the rest of the pipeline finds them.
+// It is an error for several keys to match the same field.
+func (c *confucius) matchKeys(vals map[string]interface{}, t reflect.Type) error {
+	errs := make(fieldErrors)
+	c.matchStructKeys(vals, t, "", errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *confucius) matchStructKeys(vals map[string]interface{}, t reflect.Type, path string, errs fieldErrors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || isLeafStruct(t) {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if isSquashed(sf) {
+			c.matchStructKeys(vals, sf.Type, path, errs)
+			continue
+		}
+
+		name := c.tags.fieldName(sf, c.tag)
+		fieldPath := joinPath(path, name)
+
+		var matched []string
+		for key := range vals {
+			if c.keyMatcher(key, name) {
+				matched = append(matched, key)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		if len(matched) > 1 {
+			sort.Strings(matched)
+			errs[fieldPath] = fmt.Errorf("keys %q all match the field", matched)
+			continue
+		}
+
+		val := vals[matched[0]]
+		delete(vals, matched[0])
+		vals[name] = c.matchNestedKeys(val, sf.Type, fieldPath, errs)
+	}
+}
+
+// matchNestedKeys descends into val if it holds the values of a struct, of
+// a slice or of a map.
+func (c *confucius) matchNestedKeys(val interface{}, t reflect.Type, path string, errs fieldErrors) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := toStringMap(val); ok {
+			c.matchStructKeys(m, t, path, errs)
+			return m
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := val.([]interface{}); ok {
+			for i := range s {
+				s[i] = c.matchNestedKeys(s[i], t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if m, ok := toStringMap(val); ok {
+			for key := range m {
+				m[key] = c.matchNestedKeys(m[key], t.Elem(), fmt.Sprintf("%s[%s]", path, key), errs)
+			}
+			return m
+		}
+	}
+	return val
+}
@@ -0,0 +1,76 @@
+package confucius
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_normalizeKey(t *testing.T) {
+	for _, key := range []string{"logLevel", "log-level", "log_level", "LOG_LEVEL", "LogLevel"} {
+		if got := normalizeKey(key); got != "loglevel" {
+			t.Errorf("normalizeKey(%q) == %q, expected %q", key, got, "loglevel")
+		}
+	}
+}
+
+func Test_confucius_Load_NormalizedKeys(t *testing.T) {
+	type Server struct {
+		ReadTimeout string `conf:"read_timeout"`
+	}
+	type Config struct {
+		LogLevel  string            `conf:"log_level"`
+		MaxConns  int               `conf:"maxConns"`
+		Servers   []Server          `conf:"servers"`
+		ByName    map[string]Server `conf:"by_name"`
+		Unchanged string            `conf:"unchanged"`
+	}
+
+	content := `{
+		"logLevel": "debug",
+		"max-conns": 10,
+		"servers": [{"Read-Timeout": "5s"}],
+		"by-name": {"primary_db": {"readTimeout": "1s"}},
+		"unchanged": "yes"
+	}`
+
+	var cfg Config
+	if err := Load(&cfg, String(content, DecoderJSON), NormalizedKeys()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := Config{
+		LogLevel:  "debug",
+		MaxConns:  10,
+		Servers:   []Server{{ReadTimeout: "5s"}},
+		ByName:    map[string]Server{"primary_db": {ReadTimeout: "1s"}},
+		Unchanged: "yes",
+	}
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+
+	err := Load(&Config{}, String(`{"log_level": "info", "log-level": "debug"}`, DecoderJSON), NormalizedKeys())
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["log_level"] == nil ||
+		!strings.Contains(fieldErrs["log_level"].Error(), `"log-level" "log_level"`) {
+		t.Errorf("expected conflict error for log_level, got %v", err)
+	}
+}
+
+func Test_confucius_Load_CaseInsensitiveKeys(t *testing.T) {
+	type Config struct {
+		Name string `conf:"name"`
+	}
+
+	var cfg Config
+	if err := Load(&cfg, String(`{"NAME": "app"}`, DecoderJSON), CaseInsensitiveKeys()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Name != "app" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	err := Load(&Config{}, String(`{"Name": "a", "name": "b"}`, DecoderJSON), CaseInsensitiveKeys())
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["name"] == nil {
+		t.Errorf("expected conflict error for name, got %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package confucius
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Loader loads configs with a fixed set of options. It caches the layout
+// of the config types, i.e. the fields of each struct type with their
+// parsed tags, so that loading the same type again, e.g. on every reload,
+// only walks the values of the config.
+//
+//   loader := confucius.NewLoader(confucius.File("config.yaml"), confucius.UseEnv("myapp"))
+//   err := loader.Load(&cfg)
+//   ...
+//   err = loader.Load(&cfg) // on reload
+//
+// A Loader is safe for concurrent use. The options are applied anew for
+// every load, except that a `Reader` can only be read once; use `String`
+// to load the same contents on every load.
+//
+// A Loader counts its loads, see `Stats`, and implements expvar.Var, so
+// that dashboards can alert on failing reloads:
+//
+//   expvar.Publish("config", loader)
+type Loader struct {
+	options []Option
+	tags    *tagCache
+
+	mu    sync.Mutex
+	stats LoaderStats
+}
+
+// LoaderStats describes the loads of a Loader.
+type LoaderStats struct {
+	Loads       int64     `json:"loads"`                // the number of loads.
+	Failures    int64     `json:"failures"`             // the number of loads that failed.
+	LastSuccess time.Time `json:"last_success"`         // the time of the last successful load.
+	LastError   string    `json:"last_error,omitempty"` // the error of the last load, if it failed.
+	Hash        string    `json:"hash,omitempty"`       // the SHA-256 of the config of the last successful load.
+}
+
+// NewLoader returns a Loader that loads configs with the given options.
+func NewLoader(options ...Option) *Loader {
+	return &Loader{
+		options: append([]Option(nil), options...),
+		tags:    &tagCache{},
+	}
+}
+
+// Load loads a config like `Load` with the options of the loader. The
+// parameter `cfg` must be a pointer to a struct, or a pointer to a slice
+// for config documents whose root is a list.
+func (l *Loader) Load(cfg interface{}) error {
+	c := defaultConfucius()
+
+	for _, opt := range l.options {
+		opt(c)
+	}
+
+	c.tags = l.tags
+	err := c.Load(cfg)
+	l.record(c, cfg, err)
+	return err
+}
+
+// Stats returns the counters of the loads of the loader.
+func (l *Loader) Stats() LoaderStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// String returns the stats of the loader as JSON, which makes a Loader an
+// expvar.Var.
+func (l *Loader) String() string {
+	b, _ := json.Marshal(l.Stats())
+	return string(b)
+}
+
+// record adds a load of cfg that returned err to the stats.
+func (l *Loader) record(c *confucius, cfg interface{}, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.stats.Loads++
+	if err != nil {
+		l.stats.Failures++
+		l.stats.LastError = err.Error()
+		return
+	}
+	l.stats.LastSuccess = time.Now()
+	l.stats.LastError = ""
+	l.stats.Hash = configHash(c, cfg)
+}
+
+// configHash returns the hex encoded SHA-256 of the values of cfg as JSON.
+// Secrets are masked, so they are not part of the hash.
+func configHash(c *confucius, cfg interface{}) string {
+	e := c.newValueEncoder()
+	e.mask = true
+	values, _ := e.encode(reflect.ValueOf(cfg), "")
+	b, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// tagCache caches parsed struct tags by the tag and the key of the tag that
+// holds the alt names, and the layouts of struct types by the type and the
+// key. The nil cache parses every tag and computes every layout.
+type tagCache struct {
+	m       sync.Map
+	layouts sync.Map
+}
+
+// tagCacheKey is the key of a parsed tag in the cache.
+type tagCacheKey struct {
+	tag reflect.StructTag
+	key string
+}
+
+// layoutKey is the key of a struct layout in the cache.
+type layoutKey struct {
+	t   reflect.Type
+	key string
+}
+
+// structLayout is the layout of a struct type as flattened by
+// flattenField.
+type structLayout struct {
+	leaf   bool          // true if the struct holds a single value, see isLeafStruct.
+	fields []layoutField // the exported and the embedded fields of the struct.
+}
+
+// layoutField is a field of a struct layout.
+type layoutField struct {
+	idx    int                 // the index of the field in the struct.
+	sf     reflect.StructField // the field.
+	tag    structTag           // the parsed tags of the field.
+	squash bool                // true if the field is a squashed base struct.
+	secret bool                // true if the type of the field is a secret type.
+}
+
+// parse returns the parsed tag, parsing it only the first time.
+func (tc *tagCache) parse(tag reflect.StructTag, key string) structTag {
+	if tc == nil {
+		return parseTag(tag, key)
+	}
+	if st, ok := tc.m.Load(tagCacheKey{tag, key}); ok {
+		return st.(structTag)
+	}
+
+	st := parseTag(tag, key)
+	// the slices of the cached tag are shared by the fields, so appending
+	// to them must not write into the same array.
+	st.rules = st.rules[:len(st.rules):len(st.rules)]
+	st.aliases = st.aliases[:len(st.aliases):len(st.aliases)]
+	tc.m.Store(tagCacheKey{tag, key}, st)
+	return st
+}
+
+// layout returns the layout of the struct type t, computing it only the
+// first time.
+func (tc *tagCache) layout(t reflect.Type, key string) *structLayout {
+	if tc != nil {
+		if l, ok := tc.layouts.Load(layoutKey{t, key}); ok {
+			return l.(*structLayout)
+		}
+	}
+
+	l := &structLayout{leaf: isLeafStruct(t)}
+	if !l.leaf {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" && !sf.Anonymous {
+				continue
+			}
+			l.fields = append(l.fields, tc.field(sf, i, key))
+		}
+	}
+	if tc != nil {
+		tc.layouts.Store(layoutKey{t, key}, l)
+	}
+	return l
+}
+
+// field returns the layout of the struct field sf at index idx.
+func (tc *tagCache) field(sf reflect.StructField, idx int, key string) layoutField {
+	return layoutField{
+		idx:    idx,
+		sf:     sf,
+		tag:    tc.parse(sf.Tag, key),
+		squash: isSquashed(sf),
+		secret: isSecretType(sf.Type),
+	}
+}
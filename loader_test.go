@@ -0,0 +1,146 @@
+package confucius
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Loader_Load(t *testing.T) {
+	type Config struct {
+		Name    string `conf:"name" validate:"required"`
+		Port    int    `conf:"port" default:"80"`
+		Workers []struct {
+			ID string `conf:"id,required"`
+		} `conf:"workers"`
+	}
+
+	setenv(t, "APP_PORT", "8080")
+	defer os.Unsetenv("APP_PORT")
+
+	loader := NewLoader(UseEnv("app"), LegacyFigTags(),
+		String(`{"name": "app", "workers": [{"id": "a"}, {"id": "b"}]}`, DecoderJSON))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	cfgs := make([]Config, 4)
+	for i := range cfgs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = loader.Load(&cfgs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, cfg := range cfgs {
+		if errs[i] != nil {
+			t.Fatalf("unexpected err: %v", errs[i])
+		}
+		if cfg.Name != "app" || cfg.Port != 8080 || len(cfg.Workers) != 2 || cfg.Workers[1].ID != "b" {
+			t.Errorf("cfg == %+v", cfg)
+		}
+	}
+
+	sf, _ := reflect.TypeOf(Config{}.Workers).Elem().FieldByName("ID")
+	st, ok := loader.tags.m.Load(tagCacheKey{sf.Tag, DefaultTag})
+	if !ok {
+		t.Fatalf("expected the tag of ID to be cached")
+	}
+	if rules := st.(structTag).rules; len(rules) != 0 {
+		t.Errorf("cached rules == %+v, expected the legacy rules not to be cached", rules)
+	}
+
+	l, ok := loader.tags.layouts.Load(layoutKey{reflect.TypeOf(Config{}), DefaultTag})
+	if !ok {
+		t.Fatalf("expected the layout of Config to be cached")
+	}
+	if fields := l.(*structLayout).fields; len(fields) != 3 || fields[2].tag.altName != "workers" {
+		t.Errorf("cached layout == %+v", fields)
+	}
+
+	var bad Config
+	if err := NewLoader(String(`{"workers": [{}]}`, DecoderJSON), LegacyFigTags()).Load(&bad); err == nil {
+		t.Errorf("expected err")
+	}
+}
+
+func Test_Loader_Load_ParsesTagsOnce(t *testing.T) {
+	type Limits struct {
+		Burst int `conf:"burst" default:"10"`
+	}
+	type Config struct {
+		SharedConfig
+		Timeout  time.Duration `conf:"timeout,unit=ms"`
+		Username string        `conf:"username" validate:"required_with=Password"`
+		Password string        `conf:"password" secret:"true"`
+		Limits   *Limits       `conf:"limits"`
+		Backends []struct {
+			Host string `conf:"host" default:"localhost"`
+		} `conf:"backends"`
+	}
+
+	loader := NewLoader(UseEnv("app"), FillNilStructs(), String(`{"name": "app", "timeout": 1500, "username": "u", "password": "p", "backends": [{}]}`, DecoderJSON))
+	load := func() {
+		var cfg Config
+		if err := loader.Load(&cfg); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Timeout != 1500*time.Millisecond || cfg.Limits == nil || cfg.Limits.Burst != 10 || cfg.Backends[0].Host != "localhost" {
+			t.Fatalf("unexpected cfg: %+v", cfg)
+		}
+	}
+
+	load()
+	parses := atomic.LoadInt64(&tagParses)
+	load()
+	if n := atomic.LoadInt64(&tagParses) - parses; n != 0 {
+		t.Errorf("the second load parsed %d tags, expected none", n)
+	}
+}
+
+func Test_Loader_Stats(t *testing.T) {
+	type Config struct {
+		Name     string `conf:"name" validate:"required"`
+		Password string `conf:"password" secret:"true"`
+	}
+
+	good := NewLoader(String(`{"name": "app", "password": "a"}`, DecoderJSON))
+	var cfg Config
+	if err := good.Load(&cfg); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	stats := good.Stats()
+	if stats.Loads != 1 || stats.Failures != 0 || stats.LastError != "" || stats.LastSuccess.IsZero() || len(stats.Hash) != 64 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	other := NewLoader(String(`{"name": "app", "password": "b"}`, DecoderJSON))
+	if err := other.Load(&cfg); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if other.Stats().Hash != stats.Hash {
+		t.Errorf("hash == %s, expected secrets not to change the hash %s", other.Stats().Hash, stats.Hash)
+	}
+
+	bad := NewLoader(String(`{}`, DecoderJSON))
+	if err := bad.Load(&Config{}); err == nil {
+		t.Fatalf("expected err")
+	}
+	stats = bad.Stats()
+	if stats.Loads != 1 || stats.Failures != 1 || stats.LastError == "" || !stats.LastSuccess.IsZero() || stats.Hash != "" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	var decoded LoaderStats
+	if err := json.Unmarshal([]byte(bad.String()), &decoded); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if decoded.Failures != 1 {
+		t.Errorf("String() == %s, expected 1 failure", bad.String())
+	}
+}
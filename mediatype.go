@@ -0,0 +1,70 @@
+package confucius
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// MediaType is a parsed media type (content type) such as
+// `text/html; charset=utf-8`.
+//
+//   type Config struct {
+//     ContentType confucius.MediaType `conf:"content_type" default:"application/json"`
+//   }
+type MediaType struct {
+	Type    string            // the top-level type, e.g. "text".
+	Subtype string            // the subtype, e.g. "html".
+	Params  map[string]string // the parameters with lower-cased names, e.g. charset.
+}
+
+// String formats the media type so it can be used as a Content-Type header.
+func (m MediaType) String() string {
+	if m.Type == "" {
+		return ""
+	}
+	return mime.FormatMediaType(m.Type+"/"+m.Subtype, m.Params)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m MediaType) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *MediaType) parse(s string) error {
+	t, params, err := parseMediaType(s)
+	if err != nil {
+		return err
+	}
+	parts := strings.SplitN(t, "/", 2)
+	*m = MediaType{Type: parts[0], Subtype: parts[1], Params: params}
+	return nil
+}
+
+// parseMediaType parses a media type and checks that it consists of a type
+// and a subtype.
+func parseMediaType(s string) (string, map[string]string, error) {
+	t, params, err := mime.ParseMediaType(strings.TrimSpace(s))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid media type %q: %v", s, err)
+	}
+	parts := strings.Split(t, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", nil, fmt.Errorf("invalid media type %q: expected type/subtype", s)
+	}
+	if len(params) == 0 {
+		params = nil
+	}
+	return t, params, nil
+}
+
+// mimetypeRule fails if a string field does not hold a valid media type.
+// Empty strings are left to the required rule.
+func mimetypeRule(f *field, _ string) error {
+	s := formatValue(f.v)
+	if s == "" {
+		return nil
+	}
+	_, _, err := parseMediaType(s)
+	return err
+}
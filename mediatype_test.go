@@ -0,0 +1,101 @@
+package confucius
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMediaType_parse(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    MediaType
+		wantErr bool
+	}{
+		{in: "application/json", want: MediaType{Type: "application", Subtype: "json"}},
+		{in: "Text/HTML; Charset=utf-8", want: MediaType{Type: "text", Subtype: "html", Params: map[string]string{"charset": "utf-8"}}},
+		{in: `multipart/form-data; boundary="a b"`, want: MediaType{Type: "multipart", Subtype: "form-data", Params: map[string]string{"boundary": "a b"}}},
+		{in: "text", wantErr: true},
+		{in: "text/", wantErr: true},
+		{in: "text/html; charset", wantErr: true},
+		{in: "", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			var m MediaType
+			err := m.parse(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if !reflect.DeepEqual(tc.want, m) {
+				t.Errorf("want %+v, got %+v", tc.want, m)
+			}
+		})
+	}
+}
+
+func TestMediaType_String(t *testing.T) {
+	m := MediaType{Type: "text", Subtype: "plain", Params: map[string]string{"charset": "utf-8"}}
+	if got, want := m.String(), "text/plain; charset=utf-8"; got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func Test_confucius_Load_MediaType(t *testing.T) {
+	type Config struct {
+		Accept      MediaType `conf:"accept" default:"application/json"`
+		ContentType string    `conf:"content_type" validate:"mimetype"`
+	}
+
+	var cfg Config
+	if err := Load(&cfg, String(`{"content_type": "text/csv; header=present"}`, DecoderJSON)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Accept.Type != "application" || cfg.Accept.Subtype != "json" {
+		t.Errorf("cfg.Accept == %+v", cfg.Accept)
+	}
+
+	err := Load(&cfg, String(`{"content_type": "csv"}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["content_type"] == nil {
+		t.Fatalf("expected content_type err, got %v", err)
+	}
+
+	err = Load(&cfg, String(`{"accept": "json"}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+}
+
+func Test_confucius_Load_MediaTypeEnv(t *testing.T) {
+	type Config struct {
+		Accept MediaType `conf:"accept" default:"application/json"`
+	}
+
+	setenv(t, "APP_ACCEPT_SUBTYPE", "xml")
+	defer os.Unsetenv("APP_ACCEPT_SUBTYPE")
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg, String(`{}`, DecoderJSON), UseEnv("app"), ReportUnusedEnv())
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Accept.String() != "application/json" {
+		t.Errorf("cfg.Accept == %v, expected application/json", cfg.Accept)
+	}
+	if !containsString(report.UnusedEnv, "APP_ACCEPT_SUBTYPE") {
+		t.Errorf("report.UnusedEnv == %v, expected APP_ACCEPT_SUBTYPE", report.UnusedEnv)
+	}
+
+	defs, err := FlagDefs(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(defs) != 1 || defs[0].Name != "accept" {
+		t.Errorf("defs == %+v, expected only accept", defs)
+	}
+}
@@ -0,0 +1,75 @@
+package confucius
+
+import (
+	"reflect"
+)
+
+// Merge loads the configuration into a struct that is already populated,
+// e.g. programmatically or by a previous call to Load. The parameter `cfg`
+// must be a pointer to a struct.
+//
+//   cfg := Config{Host: "localhost", Tags: []string{"a", "b"}}
+//   err := confucius.Merge(&cfg, confucius.File("override.yaml"))
+//
+// Merge takes the same options as Load. Only the fields that are set by a
+// source (config files, reader or environment) are overwritten:
+//
+//   - fields that are absent from all sources keep their value
+//   - nested structs and maps are merged key by key
+//   - a slice that is set by a source replaces the whole slice
+//
+// Defaults are applied to fields that are absent from all sources and hold
+// their zero value, as with Load. A zero value that was set on purpose can't
+// be told apart from a field that was never set, so use a pointer field if
+// such a value must survive a field's default.
+func Merge(cfg interface{}, options ...Option) error {
+	c := defaultConfucius()
+
+	for _, opt := range options {
+		opt(c)
+	}
+	c.merge = true
+
+	return c.Load(cfg)
+}
+
+// resetSlices clears the slices of v that are set in vals, so that they are
+// replaced by the decoded slice instead of having their elements
+// overwritten one by one.
+func (c *confucius) resetSlices(v reflect.Value, vals map[string]interface{}) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		if isSquashed(sf) {
+			c.resetSlices(v.Field(i), vals)
+			continue
+		}
+
+		key, ok := matchKey(vals, c.tags.fieldName(sf, c.tag))
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Slice {
+			fv.Set(reflect.Zero(fv.Type()))
+			continue
+		}
+		if m, ok := toStringMap(vals[key]); ok {
+			c.resetSlices(fv, m)
+		}
+	}
+}
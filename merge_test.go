@@ -0,0 +1,63 @@
+package confucius
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func Test_Merge(t *testing.T) {
+	type Inner struct {
+		A string `conf:"a"`
+		B string `conf:"b"`
+	}
+	type Config struct {
+		Name  string            `conf:"name" default:"default"`
+		Host  string            `conf:"host"`
+		Port  int               `conf:"port" default:"8080"`
+		Ptr   *Inner            `conf:"ptr"`
+		Val   Inner             `conf:"val"`
+		List  []string          `conf:"list"`
+		Kept  []string          `conf:"kept"`
+		Items []Inner           `conf:"items"`
+		Map   map[string]string `conf:"map"`
+	}
+
+	setenv(t, "APP_HOST", "env-host")
+	defer os.Unsetenv("APP_HOST")
+
+	cfg := Config{
+		Name:  "programmatic",
+		Ptr:   &Inner{A: "a", B: "b"},
+		Val:   Inner{A: "a", B: "b"},
+		List:  []string{"x", "y", "z"},
+		Kept:  []string{"k"},
+		Items: []Inner{{A: "a", B: "b"}},
+		Map:   map[string]string{"k": "v"},
+	}
+	err := Merge(&cfg, String(`{
+		"ptr": {"a": "new"},
+		"val": {"b": "new"},
+		"list": ["n"],
+		"items": [{"b": "new"}],
+		"map": {"j": "w"}
+	}`, DecoderJSON), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := Config{
+		Name:  "programmatic",
+		Host:  "env-host",
+		Port:  8080,
+		Ptr:   &Inner{A: "new", B: "b"},
+		Val:   Inner{A: "a", B: "new"},
+		List:  []string{"n"},
+		Kept:  []string{"k"},
+		Items: []Inner{{B: "new"}},
+		Map:   map[string]string{"k": "v", "j": "w"},
+	}
+	if !reflect.DeepEqual(want, cfg) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, cfg)
+	}
+}
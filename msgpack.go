@@ -0,0 +1,16 @@
+package confucius
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// decodeMsgpack decodes a MessagePack document, e.g. a snapshot of a config
+// that was encoded by another service. Integers are decoded as int64 or
+// uint64, floats as float64 and timestamps as time.Time.
+func decodeMsgpack(reader io.Reader) (interface{}, error) {
+	dec := msgpack.NewDecoder(reader)
+	dec.UseLooseInterfaceDecoding(true)
+	return dec.DecodeInterface()
+}
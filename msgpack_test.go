@@ -0,0 +1,78 @@
+package confucius
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func Test_confucius_Load_Msgpack(t *testing.T) {
+	type Config struct {
+		Host    string            `conf:"host"`
+		Port    int               `conf:"port"`
+		Ratio   float64           `conf:"ratio"`
+		Tags    []string          `conf:"tags"`
+		Labels  map[string]string `conf:"labels"`
+		Started time.Time         `conf:"started"`
+	}
+
+	started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	b, err := msgpack.Marshal(map[string]interface{}{
+		"host":    "example.com",
+		"port":    8080,
+		"ratio":   0.5,
+		"tags":    []string{"a", "b"},
+		"labels":  map[string]string{"team": "core"},
+		"started": started,
+	})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.msgpack"), b, 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for name, options := range map[string][]Option{
+		"bytes": {Bytes(b, DecoderMsgpack)},
+		"file":  {File("config.msgpack"), Dirs(dir)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var cfg Config
+			if err := Load(&cfg, options...); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if cfg.Host != "example.com" || cfg.Port != 8080 || cfg.Ratio != 0.5 ||
+				len(cfg.Tags) != 2 || cfg.Labels["team"] != "core" || !cfg.Started.Equal(started) {
+				t.Errorf("unexpected cfg: %+v", cfg)
+			}
+		})
+	}
+
+	t.Run("root list", func(t *testing.T) {
+		b, err := msgpack.Marshal([]map[string]string{{"name": "a"}, {"name": "b"}})
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		var cfg []struct {
+			Name string `conf:"name"`
+		}
+		if err := Load(&cfg, Bytes(b, DecoderMsgpack)); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if len(cfg) != 2 || cfg[1].Name != "b" {
+			t.Errorf("unexpected cfg: %+v", cfg)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		var cfg Config
+		if err := Load(&cfg, Bytes(b[:len(b)/2], DecoderMsgpack)); err == nil {
+			t.Fatal("expected err")
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package confucius
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	urlType   = reflect.TypeOf(url.URL{})
+	ipType    = reflect.TypeOf(net.IP{})
+	ipNetType = reflect.TypeOf(net.IPNet{})
+)
+
+// isNetType reports whether t is url.URL or net.IPNet, which confucius
+// parses from strings. net.IP is parsed as an encoding.TextUnmarshaler.
+func isNetType(t reflect.Type) bool {
+	return t == urlType || t == ipNetType
+}
+
+// parseNetValue parses s into a value of type t, which must be url.URL,
+// net.IPNet or net.IP.
+func parseNetValue(s string, t reflect.Type) (interface{}, error) {
+	switch t {
+	case urlType:
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return *u, nil
+	case ipNetType:
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		return *n, nil
+	case ipType:
+		var ip net.IP
+		if err := ip.UnmarshalText([]byte(s)); err != nil {
+			return nil, err
+		}
+		return ip, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// netTransform checks that values of url.URL, net.IP and net.IPNet fields
+// in config files can be parsed, so that invalid values are reported by the
+// field's path. The values are converted by netHookFunc.
+func netTransform(st structTag, t reflect.Type, val interface{}) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s, ok := val.(string)
+	if !ok || (!isNetType(t) && t != ipType) {
+		return val, nil
+	}
+	if _, err := parseNetValue(s, t); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// netHookFunc returns a decode hook that parses strings into url.URL and
+// net.IPNet fields.
+func netHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f.Kind() != reflect.String || !isNetType(t) {
+			return data, nil
+		}
+		return parseNetValue(data.(string), t)
+	}
+}
+
+// setNetValue parses val into fv if fv is a url.URL or a net.IPNet. The
+// returned bool is false if it is not.
+func setNetValue(fv reflect.Value, val string) (bool, error) {
+	if !isNetType(fv.Type()) {
+		return false, nil
+	}
+	v, err := parseNetValue(val, fv.Type())
+	if err != nil {
+		return true, err
+	}
+	fv.Set(reflect.ValueOf(v))
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package confucius
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Load_NetTypes(t *testing.T) {
+	type Config struct {
+		Endpoint url.URL     `conf:"endpoint"`
+		Proxy    *url.URL    `conf:"proxy"`
+		Callback url.URL     `conf:"callback" default:"https://example.com/cb"`
+		IP       net.IP      `conf:"ip"`
+		Subnet   net.IPNet   `conf:"subnet"`
+		Allowed  []net.IPNet `conf:"allowed"`
+		Trusted  *net.IPNet  `conf:"trusted"`
+	}
+
+	setenv(t, "APP_TRUSTED", "192.168.0.0/16")
+	defer os.Unsetenv("APP_TRUSTED")
+
+	content := `{
+		"endpoint": "https://api.example.com:8443/v1",
+		"proxy": "http://proxy:3128",
+		"ip": "10.0.0.1",
+		"subnet": "10.0.0.0/8",
+		"allowed": ["127.0.0.0/8", "::1/128"]
+	}`
+
+	var cfg Config
+	if err := Load(&cfg, String(content, DecoderJSON), UseEnv("app")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Endpoint.Host != "api.example.com:8443" || cfg.Endpoint.Path != "/v1" {
+		t.Errorf("unexpected endpoint %v", cfg.Endpoint)
+	}
+	if cfg.Proxy == nil || cfg.Proxy.String() != "http://proxy:3128" {
+		t.Errorf("unexpected proxy %v", cfg.Proxy)
+	}
+	if cfg.Callback.String() != "https://example.com/cb" {
+		t.Errorf("unexpected callback %v", cfg.Callback)
+	}
+	if cfg.IP.String() != "10.0.0.1" || cfg.Subnet.String() != "10.0.0.0/8" {
+		t.Errorf("unexpected ip %v and subnet %v", cfg.IP, cfg.Subnet)
+	}
+	if len(cfg.Allowed) != 2 || cfg.Allowed[1].String() != "::1/128" {
+		t.Errorf("unexpected allowed %v", cfg.Allowed)
+	}
+	if cfg.Trusted == nil || !cfg.Trusted.Contains(net.ParseIP("192.168.1.1")) {
+		t.Errorf("unexpected trusted %v", cfg.Trusted)
+	}
+}
+
+func Test_Load_NetTypes_Invalid(t *testing.T) {
+	type Config struct {
+		Endpoint url.URL   `conf:"endpoint"`
+		IP       net.IP    `conf:"ip"`
+		Subnet   net.IPNet `conf:"subnet"`
+	}
+
+	content := `{"endpoint": "http://[::1", "ip": "10.0.0.256", "subnet": "10.0.0.0/33"}`
+
+	err := Load(&Config{}, String(content, DecoderJSON))
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok {
+		t.Fatalf("expected fieldErrors, got %v", err)
+	}
+	for path, want := range map[string]string{
+		"endpoint": "missing ']' in host",
+		"ip":       "invalid IP address",
+		"subnet":   "invalid CIDR address",
+	} {
+		if fieldErrs[path] == nil || !strings.Contains(fieldErrs[path].Error(), want) {
+			t.Errorf("%s: expected err containing %q, got %v", path, want, fieldErrs[path])
+		}
+	}
+}
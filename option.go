@@ -1,12 +1,17 @@
 package confucius
 
 import (
+	"bytes"
 	"embed"
+	"flag"
 	"io"
+	"os"
 	"reflect"
 	"runtime"
 	"sort"
 	"strings"
+
+	"github.com/mitchellh/mapstructure"
 )
 
 // Option configures how confucius loads the configuration.
@@ -20,25 +25,119 @@ type Option func(c *confucius)
 //
 //   confucius.Load(&cfg, confucius.File("config.toml"))
 //
+// A decoder may be given to decode the file, and its profile files, with
+// whatever extension they have:
+//
+//   confucius.Load(&cfg, confucius.File("settings.conf", confucius.DecoderYaml))
+//
 // If this option is not used then confucius looks for a file with name `config.yaml`.
-func File(name string) Option {
+func File(name string, decoder ...Decoder) Option {
 	return func(c *confucius) {
 		c.filename = name
+		c.filesSet = true
+		c.fileDecoder = ""
+		if len(decoder) > 0 {
+			c.fileDecoder = decoder[0]
+		}
 	}
 }
 
 // Reader returns an option that configure from reader for reference configuration.
+// Only one of Reader, String, Bytes and Stdin can be used, loading fails if
+// more than one is set.
 func Reader(reader io.Reader, decoder Decoder) Option {
 	return func(c *confucius) {
 		c.useReader = true
+		c.readers++
 		c.readerConfig = reader
 		c.readerDecoder = decoder
 	}
 }
 
 // String returns an option that configure from string for reference configuration.
+// The string is read anew by every load, so the option can be reused, e.g.
+// with a `Loader`. Like `Reader`, it cannot be combined with another reader.
 func String(file string, decoder Decoder) Option {
-	return Reader(strings.NewReader(strings.TrimSpace(file)), decoder)
+	return func(c *confucius) {
+		Reader(strings.NewReader(strings.TrimSpace(file)), decoder)(c)
+	}
+}
+
+// FileFromEnv returns an option that takes the path of the config file
+// from the environment variable key, if it is set, instead of the file set
+// with `File` and the directories set with `Dirs`:
+//
+//   MYAPP_CONFIG=/etc/myapp/prod.yaml myapp
+//
+//   confucius.Load(&cfg, confucius.File("config.yaml"), confucius.FileFromEnv("MYAPP_CONFIG"))
+//
+// The profile files are searched for next to the file. A path set by the
+// flag of `FileFromFlag` takes precedence over the environment.
+func FileFromEnv(key string) Option {
+	return func(c *confucius) {
+		c.fileEnv = key
+		c.filesSet = true
+	}
+}
+
+// FileFromFlag returns an option that takes the path of the config file
+// from the flag name of fs, if it was set on the command line, like
+// `FileFromEnv` does from the environment. The flag must be defined by the
+// caller, e.g. with `fs.String("config", "", "the config file")`, and fs
+// must be parsed before loading.
+//
+//   confucius.Load(&cfg, confucius.FileFromEnv("MYAPP_CONFIG"), confucius.FileFromFlag(flag.CommandLine, "config"))
+func FileFromFlag(fs *flag.FlagSet, name string) Option {
+	return FileFromFlagFunc(func() string {
+		var path string
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				path = f.Value.String()
+			}
+		})
+		return path
+	})
+}
+
+// FileFromFlagFunc is like `FileFromFlag` but calls fn for the path when
+// loading, which returns "" if the flag was not set. It is meant for other
+// flag packages.
+func FileFromFlagFunc(fn func() string) Option {
+	return func(c *confucius) {
+		c.fileFlag = fn
+		c.filesSet = true
+	}
+}
+
+// DetectFormat returns an option that picks the decoder of files without an
+// extension, or with one that has no decoder, by their content instead of
+// failing with ErrUnsupportedExtension, e.g. for the keys of Kubernetes
+// ConfigMaps such as `application` and its profile `application.prod`.
+// Valid JSON is decoded as JSON, documents that start with a TOML table
+// header or `key = value` line as TOML, data that is not valid UTF-8 as
+// MessagePack and other documents as YAML.
+func DetectFormat() Option {
+	return func(c *confucius) {
+		c.detectFormat = true
+	}
+}
+
+// Bytes returns an option that configure from b for reference configuration.
+// Unlike String, b is decoded as it is, so it may hold a binary format such
+// as `DecoderMsgpack`. b is read anew by every load.
+func Bytes(b []byte, decoder Decoder) Option {
+	return func(c *confucius) {
+		Reader(bytes.NewReader(b), decoder)(c)
+	}
+}
+
+// LenientJSON returns an option that makes confucius accept comments and
+// trailing commas in .json files, like in .jsonc files, so that
+// human-edited files such as VS Code settings load as they are.
+func LenientJSON() Option {
+	return func(c *confucius) {
+		c.lenientJSON = true
+	}
 }
 
 // Dirs returns an option that configures the directories that confucius searches
@@ -50,11 +149,16 @@ func String(file string, decoder Decoder) Option {
 //
 //   confucius.Load(&cfg, confucius.Dirs(".", "/etc/myapp", "/home/user/myapp"))
 //
+// A leading `~` is expanded to the home directory and environment variables
+// such as `$HOME` and `${VAR}` are expanded before searching, e.g.
+// `Dirs("~/.myapp", "${MYAPP_DIR}")`. Directories that refer to a variable
+// that is not set are skipped.
 //
 // If this option is not used then confucius looks in the directory it is run from.
 func Dirs(dirs ...string) Option {
 	return func(c *confucius) {
 		c.dirs = dirs
+		c.filesSet = true
 	}
 }
 
@@ -70,6 +174,21 @@ func Tag(tag string) Option {
 	}
 }
 
+// Tags returns an option that configures several tag keys that confucius
+// uses for the alt name struct tag key in fields. The keys are tried in
+// order and the first one present in a field's tags is used.
+//
+//   confucius.Load(&cfg, confucius.Tags("conf", "fig"))
+//
+// This lets structs annotated for fig, or for other packages that use the
+// same tag syntax, load unchanged alongside structs tagged for confucius.
+// Tags overrides the tag key set by `Tag`.
+func Tags(tags ...string) Option {
+	return func(c *confucius) {
+		c.tag = strings.Join(tags, ",")
+	}
+}
+
 // TimeLayout returns an option that conmfigures the time layout that confucius uses when
 // parsing a time in a config file or in the default tag for time.Time fields.
 //
@@ -111,6 +230,14 @@ func TimeLayout(layout string) Option {
 //   MYAPP_BUILD
 //   MYAPP_LOG_LEVEL
 //   MYAPP_SERVER_HOST
+//
+// The prefix may contain the placeholder `{profile}`, which is replaced by
+// the active profile, i.e. the last one passed to `Profiles` or the
+// detected environment. Deployments with different profiles on the same
+// host can so be configured independently: with UseEnv("myapp_{profile}")
+// and Profiles("canary") confucius searches for MYAPP_CANARY_BUILD and so
+// on. Without profiles the placeholder is dropped along with its
+// separator.
 func UseEnv(prefix string) Option {
 	return func(c *confucius) {
 		c.useEnv = true
@@ -118,17 +245,68 @@ func UseEnv(prefix string) Option {
 	}
 }
 
-// Profiles returns an option that configures the profile key that confucius uses
+// EnvSeparator returns an option that configures the separator that confucius
+// uses between the prefix and the names of the fields in environment
+// variable keys.
 //
-//  confucius.Load(&cfg, confucius.UseProfile("test"))
+//   confucius.Load(&cfg, confucius.UseEnv("myapp"), confucius.EnvSeparator("__"))
 //
-// If this option is not used then confucius uses the tag `fig`.
+// With a separator that does not occur in field names, nested fields can be
+// told apart from fields whose names contain underscores. With the struct of
+// `UseEnv` confucius would search for:
+//
+//   MYAPP__BUILD
+//   MYAPP__LOG_LEVEL
+//   MYAPP__SERVER__HOST
+//
+// If this option is not used then confucius uses `_`.
+func EnvSeparator(sep string) Option {
+	return func(c *confucius) {
+		c.envSeparator = sep
+	}
+}
+
+// ReportUnusedEnv returns an option that makes confucius report the
+// environment variables with the prefix of `UseEnv` that match no field,
+// so that typos such as MYAPP_SERVERR_HOST do not go unnoticed. They are
+// logged as warnings and listed in `Report.UnusedEnv`. Without a prefix
+// every variable of the environment would match, so none are reported.
+func ReportUnusedEnv() Option {
+	return func(c *confucius) {
+		c.reportUnusedEnv = true
+	}
+}
+
+// Profiles returns an option that configures the profiles whose files
+// confucius merges over the config file, e.g. `config.prod.yaml`.
+//
+//  confucius.Load(&cfg, confucius.Profiles("base", "prod", "eu-west"))
+//
+// The profile files are merged in the given order and later profiles take
+// precedence, so above the values of `config.eu-west.yaml` win over those
+// of `config.prod.yaml`. The order in which the files were merged is part
+// of the report returned by `LoadWithReport`.
 func Profiles(profiles ...string) Option {
 	return func(c *confucius) {
 		c.profiles = profiles
 	}
 }
 
+// DefaultProfile returns an option that configures the profile that is
+// used when no profile is set, neither with `Profiles` nor by
+// `DetectEnvironment`.
+//
+//   confucius.Load(&cfg, confucius.DetectEnvironment(), confucius.DefaultProfile("dev"))
+//
+// Above `config.dev.yaml` is merged over the config file on machines where
+// no environment is detected. Whether the default profile was used is
+// logged at debug level and reported by `LoadWithReport`.
+func DefaultProfile(profile string) Option {
+	return func(c *confucius) {
+		c.defaultProfile = profile
+	}
+}
+
 // ProfileLayout returns an option that configures the profile layout that confucius uses
 //
 //  confucius.Load(&cfg, confucius.UseProfileLayout("config-test.yaml"))
@@ -145,6 +323,7 @@ func EmbedFS(fs embed.FS) Option {
 	return func(c *confucius) {
 		c.useEmbedFS = true
 		c.embedFS = fs
+		c.filesSet = true
 	}
 }
 
@@ -160,3 +339,431 @@ func Logger(opts ...LogOption) Option {
 		}
 	}
 }
+
+// DetectEnvironment returns an option that configures confucius to infer the
+// execution environment (dev, staging, prod) and activate it as a profile.
+//
+//   confucius.Load(&cfg, confucius.DetectEnvironment())
+//
+// The environment is read from the ENV, APP_ENV, ENVIRONMENT or GO_ENV
+// environment variables. When none of them is set and the process runs in
+// Kubernetes, the pod's namespace is used instead (e.g. `payments-prod`).
+// Well known aliases are normalized to the presets, so `production` becomes
+// `prod` and `development` becomes `dev`.
+//
+// The profile of the detected environment is optional; if its file does not
+// exist the load continues with the remaining files. The detected environment
+// is also available in config files through the `${env}` pseudo-variable:
+//
+//   endpoint: "https://${env}.api.example.com"
+func DetectEnvironment() Option {
+	return func(c *confucius) {
+		c.detectEnv = true
+	}
+}
+
+// Lenient returns an option that configures confucius to carry on loading
+// when a stage fails, instead of returning the first error.
+//
+//   err := confucius.Load(&cfg, confucius.Lenient())
+//   var loadErr *confucius.LoadError
+//   if errors.As(err, &loadErr) {
+//     // cfg holds everything that could be loaded, loadErr.Errors
+//     // holds everything that went wrong.
+//   }
+//
+// Missing files, files that cannot be decoded and values that cannot be
+// decoded into the struct are skipped, and defaults and validations are
+// still processed. All errors are returned together in a `*LoadError`.
+func Lenient() Option {
+	return func(c *confucius) {
+		c.lenient = true
+	}
+}
+
+// FillNilStructs returns an option that configures confucius to allocate nil
+// struct pointers whose struct declares default values, so that the
+// defaults of optional sections are applied even if the section is missing
+// from all sources.
+//
+//   type Config struct {
+//     Cache *struct {
+//       TTL time.Duration `conf:"ttl" default:"5m"`
+//     } `conf:"cache"`
+//   }
+//
+//   confucius.Load(&cfg, confucius.FillNilStructs())
+//   // cfg.Cache.TTL == 5m
+//
+// Without this option nil struct pointers are left untouched. Struct pointers
+// without any default are never allocated.
+func FillNilStructs() Option {
+	return func(c *confucius) {
+		c.fillNilStructs = true
+	}
+}
+
+// DisableExpansion returns an option that configures confucius to leave
+// references to environment variables in the form `${NAME}` in config files
+// as they are, instead of replacing them with the variable's value.
+//
+//   confucius.Load(&cfg, confucius.DisableExpansion())
+//
+// This is useful when values legitimately contain `${...}`, e.g. templates
+// or shell snippets. Without this option a single reference can be kept by
+// escaping it as `$${NAME}`, which results in the literal `${NAME}`.
+func DisableExpansion() Option {
+	return func(c *confucius) {
+		c.disableExpansion = true
+	}
+}
+
+// LegacyFigTags returns an option that configures confucius to accept the
+// combined tag syntax of older fig versions, where a field is marked as
+// required and given a default in its name tag.
+//
+//   type Config struct {
+//     Host string `fig:"host,required"`
+//     Port int    `fig:"port,default=8080"`
+//   }
+//
+//   confucius.Load(&cfg, confucius.Tag("fig"), confucius.LegacyFigTags())
+//
+// The options are translated into the `validate` and `default` tags, which
+// take precedence if a field declares both. A default is the rest of the
+// tag, so it must be the last option and may contain commas.
+func LegacyFigTags() Option {
+	return func(c *confucius) {
+		c.legacyTags = true
+	}
+}
+
+// CaseInsensitiveKeys returns an option that configures confucius to match
+// the keys in config files to the names of fields regardless of case, and
+// to fail if several keys, e.g. `Name` and `name`, match the same field
+// instead of silently using one of them.
+//
+//   confucius.Load(&cfg, confucius.CaseInsensitiveKeys())
+func CaseInsensitiveKeys() Option {
+	return func(c *confucius) {
+		c.keyMatcher = strings.EqualFold
+	}
+}
+
+// NormalizedKeys returns an option that configures confucius to match the
+// keys in config files to the names of fields after lower-casing them and
+// stripping dashes and underscores, so that `logLevel`, `log-level` and
+// `log_level` all set the same field.
+//
+//   type Config struct {
+//     LogLevel string `conf:"log_level"`
+//   }
+//
+//   confucius.Load(&cfg, confucius.NormalizedKeys())
+//
+// Configs that mix conventions are thus loaded completely. As with
+// `CaseInsensitiveKeys` it is an error for several keys to match the same
+// field.
+func NormalizedKeys() Option {
+	return func(c *confucius) {
+		c.keyMatcher = normalizedKeysMatch
+	}
+}
+
+// Atomic returns an option that configures confucius to load the config
+// into a copy of the struct and to only copy it into the struct once the
+// load succeeded, so that the struct is never left partially loaded.
+//
+//   if err := confucius.Load(&cfg, confucius.Atomic()); err != nil {
+//     // cfg is unchanged
+//   }
+//
+// The copy starts out as a deep copy of the struct, so values set before
+// loading are kept as without this option. When used with `Lenient` the
+// partially loaded copy is available as `LoadError.Config`. Loading will
+// be atomic by default in a future major version.
+func Atomic() Option {
+	return func(c *confucius) {
+		c.atomic = true
+	}
+}
+
+// WithDecodeHook returns an option that appends hook to the mapstructure
+// decode hooks that confucius uses to decode config files into the struct,
+// e.g. to convert strings into custom types.
+//
+//   confucius.Load(&cfg, confucius.WithDecodeHook(mapstructure.StringToIPHookFunc()))
+//
+// The hooks run after the built-in hooks, in the order they are added.
+// They do not apply to values set from the environment and defaults.
+func WithDecodeHook(hook mapstructure.DecodeHookFunc) Option {
+	return func(c *confucius) {
+		c.decodeHooks = append(c.decodeHooks, hook)
+	}
+}
+
+// Metadata returns an option that configures confucius to fill md with the
+// metadata of decoding the files and the reader into the struct, as
+// mapstructure does for `mapstructure.DecoderConfig.Metadata`.
+//
+//   var md mapstructure.Metadata
+//   confucius.Load(&cfg, confucius.Metadata(&md))
+//   // md.Unused == []string{"sever.port"}
+//
+// Keys are the paths of the fields, as used in the environment and in
+// validation errors. The metadata does not cover values set from the
+// environment, flags or defaults. The same metadata is part of the report
+// returned by `LoadWithReport`.
+func Metadata(md *mapstructure.Metadata) Option {
+	return func(c *confucius) {
+		c.userMetadata = md
+	}
+}
+
+// WithLogger returns an option that configures confucius to pass fn a
+// structured `Event` for each step of loading: the files found and not
+// found, the decoder used for each source, the merge of each file, each
+// field set from the environment, a flag or a default, and each validation
+// failure.
+//
+//   confucius.Load(&cfg, confucius.WithLogger(func(e confucius.Event) {
+//     log.Printf("%s %s %s %s", e.Kind, e.Path, e.Source, e.Value)
+//   }))
+//
+// Unlike `Logger`, which writes text messages, the events can be filtered
+// and forwarded to a structured logger. The values of secret fields are
+// redacted.
+func WithLogger(fn func(Event)) Option {
+	return func(c *confucius) {
+		c.onEvent = fn
+	}
+}
+
+// SubKey returns an option that configures confucius to load only the
+// section at path of the config into the struct, e.g. so that a library
+// can load its own section of a larger application config.
+//
+//   var serverCfg ServerConfig
+//   confucius.Load(&serverCfg, confucius.SubKey("server"), confucius.UseEnv("myapp"))
+//
+// Nested sections are separated by dots, e.g. "services.billing". The
+// section may refer to values outside of it. Environment variables keep
+// the names of the whole config, e.g. MYAPP_SERVER_HOST, while the paths in
+// errors are relative to the section. A struct is loaded with only its
+// defaults if the section is absent.
+func SubKey(path string) Option {
+	return func(c *confucius) {
+		c.subKey = path
+	}
+}
+
+// ProfileDocuments returns an option that keeps the profiles of a config in
+// a single YAML file, with a document for each profile. Of a file with
+// multiple documents, the documents that declare no `profile` are merged in
+// order, followed by those that declare a profile, or a list of profiles,
+// that is active, in the order of `Profiles`. The key `profile` is removed
+// from the merged documents.
+//
+//   host: localhost
+//   ---
+//   profile: prod
+//   host: example.com
+//
+//   confucius.Load(&cfg, confucius.Profiles("prod"), confucius.ProfileDocuments())
+//
+// Profile files are then optional, as with `OptionalProfiles`, and are
+// merged over the config file if they exist. Without this option only the
+// first document of a YAML file is loaded.
+func ProfileDocuments() Option {
+	return func(c *confucius) {
+		c.profileDocuments = true
+	}
+}
+
+// OptionalProfiles returns an option that configures confucius to skip the
+// profile files that are not found instead of failing with ErrFileNotFound,
+// e.g. for local overrides that are not always present.
+//
+//   confucius.Load(&cfg, confucius.Profiles("local"), confucius.OptionalProfiles())
+//
+// The main config file is still required.
+func OptionalProfiles() Option {
+	return func(c *confucius) {
+		c.optionalProfiles = true
+	}
+}
+
+// FlagValues returns an option that sets fields from the given values of
+// command line flags, keyed by the paths of the fields as in `FlagDefs`.
+//
+//   confucius.Load(&cfg, confucius.UseEnv("myapp"), confucius.FlagValues(map[string]string{
+//     "server.port": "8080",
+//   }))
+//
+// Flags take precedence over the environment, the files and the defaults.
+// Only the flags that were set on the command line should be passed, so
+// that their defaults do not override the other sources.
+func FlagValues(values map[string]string) Option {
+	return FlagValuesFunc(func() map[string]string {
+		return values
+	})
+}
+
+// FlagValuesFunc is like `FlagValues` but calls fn for the values when the
+// option is applied, i.e. on every call to `Load`. It is meant for flag
+// packages that parse the flags after the option was created.
+func FlagValuesFunc(fn func() map[string]string) Option {
+	return func(c *confucius) {
+		if c.flags == nil {
+			c.flags = make(map[string]string)
+		}
+		for path, val := range fn() {
+			c.flags[path] = val
+		}
+	}
+}
+
+// Override returns an option that forces the field with the given path to
+// val, e.g. in tests or from a feature flag system, without touching the
+// config files or the environment.
+//
+//   confucius.Load(&cfg, confucius.Override("server.port", 9090))
+//
+// Overrides are applied after all other sources, including the flags. A
+// value of the field's type is set as it is, while strings are parsed like
+// environment variables, so Override("timeout", "5s") sets a time.Duration.
+// An override whose path matches no field fails the load.
+func Override(path string, val interface{}) Option {
+	return Overrides(map[string]interface{}{path: val})
+}
+
+// Overrides returns an option that forces several fields at once, see
+// `Override`.
+func Overrides(values map[string]interface{}) Option {
+	return func(c *confucius) {
+		if c.overrides == nil {
+			c.overrides = make(map[string]interface{})
+		}
+		for path, val := range values {
+			c.overrides[path] = val
+		}
+	}
+}
+
+// Precedence returns an option that sets the order in which the sources
+// of values are merged, from the lowest to the highest precedence, instead
+// of the implicit order of the reader, the config file, the profile files,
+// the providers and then the environment.
+//
+//   confucius.Load(&cfg, confucius.String(defaults, confucius.DecoderYaml), confucius.UseEnv("myapp"),
+//     confucius.Precedence(confucius.SourceReader, confucius.SourceEnv, confucius.SourceFile))
+//
+// The kinds are SourceReader, SourceFile, SourceProfile, SourceProvider and
+// SourceEnv. Config files are only searched if SourceFile or SourceProfile
+// is listed, and the environment does not override the fields set by the
+// sources listed after it. Flags and overrides always take precedence.
+//
+// Loading fails if the order is ambiguous: if a kind is unknown or listed
+// twice, or if a source is used whose kind is not listed. Config files are
+// used if they are set with `File`, `FileFromEnv`, `FileFromFlag`, `Dirs`
+// or `EmbedFS`.
+func Precedence(kinds ...string) Option {
+	return func(c *confucius) {
+		c.precedence = append([]string{}, kinds...)
+	}
+}
+
+// FromProviders returns an option that merges the values of the given
+// providers over the config files, in order, e.g. to keep reading sources
+// of koanf during a migration:
+//
+//   confucius.Load(&cfg, confucius.FromProviders(confmap.Provider(defaults, ".")))
+//
+// The providers are read on every load. Environment variables, flags and
+// overrides still take precedence over them. With providers the config file
+// is optional, as with `UseEnv`.
+func FromProviders(providers ...MapProvider) Option {
+	return func(c *confucius) {
+		c.providers = append(c.providers, providers...)
+	}
+}
+
+// WithSpans returns an option that starts a span around each step of
+// loading a config with start: the whole load, finding the files, decoding
+// each file and the reader, reading each provider and processing the
+// struct. The span names are the Span constants. Spans make slow startups,
+// e.g. caused by remote providers, visible in traces.
+//
+//   confucius.Load(&cfg, confotel.WithTracer(otel.Tracer("myapp")))
+//
+// The package github.com/hasanozgan/confucius/otel provides the adapter for
+// OpenTelemetry.
+func WithSpans(start SpanStarter) Option {
+	return func(c *confucius) {
+		c.spans = start
+	}
+}
+
+// WithDecrypter returns an option that decrypts the inline encrypted values
+// of the given scheme in config files, so that individual secrets can live
+// in otherwise plaintext files:
+//
+//   password: ENC[age:YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOS...]
+//
+// decrypt receives the data between the colon and the closing bracket and
+// returns the plaintext, which is then decoded like any other value. Values
+// of schemes without a decrypter fail to load. The package
+// github.com/hasanozgan/confucius/age provides the decrypter of the age
+// scheme.
+func WithDecrypter(scheme string, decrypt func(data string) (string, error)) Option {
+	return func(c *confucius) {
+		if c.decrypters == nil {
+			c.decrypters = make(map[string]decryptFunc)
+		}
+		c.decrypters[scheme] = decrypt
+	}
+}
+
+// WithDecoder returns an option that decodes the files with the extension
+// of decoder, e.g. `Decoder(".cue")`, and the readers set with that decoder
+// with decode, so that formats without a built-in decoder can be loaded:
+//
+//   confucius.Load(&cfg, confucius.File("config.ini"), confucius.WithDecoder(".ini", decodeINI))
+//
+// decode returns the document as maps, lists and scalars, like
+// encoding/json does when decoding into an interface{}. A decoder set for
+// a built-in extension replaces the built-in decoder. decode may return a
+// *DecodeError to report the line and column of an error. The package
+// github.com/hasanozgan/confucius/cue provides the decoder of CUE files.
+func WithDecoder(decoder Decoder, decode func(r io.Reader) (interface{}, error)) Option {
+	return func(c *confucius) {
+		if c.decoders == nil {
+			c.decoders = make(map[Decoder]decodeFunc)
+		}
+		c.decoders[decoder] = decode
+	}
+}
+
+// RequireFileMode returns an option that fails the load if a config file
+// has permissions beyond perm, like ssh does for its keys, so that files
+// holding secrets cannot be world-readable by mistake:
+//
+//   confucius.Load(&cfg, confucius.RequireFileMode(0600))
+//
+// Above a file with the mode 0644 fails with ErrInsecureFileMode, while
+// 0600 and 0400 are accepted. Embedded files are not checked.
+func RequireFileMode(perm os.FileMode) Option {
+	return func(c *confucius) {
+		c.fileMode = &perm
+		c.fileModeWarn = false
+	}
+}
+
+// WarnFileMode is like `RequireFileMode` but logs a warning instead of
+// failing the load.
+func WarnFileMode(perm os.FileMode) Option {
+	return func(c *confucius) {
+		c.fileMode = &perm
+		c.fileModeWarn = true
+	}
+}
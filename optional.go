@@ -0,0 +1,105 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Optional is a value that records whether it was set. It tells a field that
+// is absent from all sources apart from a field that is explicitly set to
+// its zero value, without having to use a pointer.
+//
+//   type Config struct {
+//     Retries confucius.Optional[int]  `conf:"retries"`
+//     Debug   confucius.Optional[bool] `conf:"debug"`
+//   }
+//
+//   if retries, ok := cfg.Retries.Get(); ok {
+//     // retries was set, possibly to 0
+//   }
+//
+// Optional fields are set from config files, the environment and defaults
+// like a field of type T. A `required` validation fails only if the field
+// is not set, so an explicit zero value passes. Fields that are not set are
+// left out when the config is encoded (e.g. by `Snapshot()`).
+//
+// The type is named Optional rather than Option, as `Option` is the type of
+// the options passed to `Load`.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+// Some returns an Optional that is set to v.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Set: true}
+}
+
+// Get returns the value and whether it is set.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// Or returns the value if it is set, and def otherwise.
+func (o Optional[T]) Or(def T) T {
+	if o.Set {
+		return o.Value
+	}
+	return def
+}
+
+// String formats the value if it is set, and returns an empty string
+// otherwise.
+func (o Optional[T]) String() string {
+	if !o.Set {
+		return ""
+	}
+	return fmt.Sprint(o.Value)
+}
+
+func (o Optional[T]) isSet() bool {
+	return o.Set
+}
+
+func (o Optional[T]) elem() reflect.Value {
+	return reflect.ValueOf(&o.Value).Elem()
+}
+
+func (o *Optional[T]) target() reflect.Value {
+	o.Set = true
+	return reflect.ValueOf(&o.Value).Elem()
+}
+
+// optional is implemented by Optional.
+type optional interface {
+	isSet() bool
+	elem() reflect.Value
+}
+
+// optionalTarget is implemented by pointers to Optional. target marks the
+// Optional as set and returns its settable value.
+type optionalTarget interface {
+	optional
+	target() reflect.Value
+}
+
+var optionalTargetType = reflect.TypeOf((*optionalTarget)(nil)).Elem()
+
+// isOptionalType reports whether t is an Optional.
+func isOptionalType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(optionalTargetType)
+}
+
+// optionalHookFunc returns a decode hook that decodes values into fields of
+// type Optional by decoding them into the Optional's value and marking it
+// as set.
+func optionalHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if f == t || !isOptionalType(t) {
+			return data, nil
+		}
+		return map[string]interface{}{"Value": data, "Set": true}, nil
+	}
+}
@@ -0,0 +1,132 @@
+package confucius
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestOptional(t *testing.T) {
+	var o Optional[int]
+	if v, ok := o.Get(); ok || v != 0 {
+		t.Errorf("expected unset optional, got %v %v", v, ok)
+	}
+	if o.Or(5) != 5 || o.String() != "" {
+		t.Errorf("unexpected unset optional: %q", o.String())
+	}
+
+	o = Some(0)
+	if v, ok := o.Get(); !ok || v != 0 {
+		t.Errorf("expected optional set to 0, got %v %v", v, ok)
+	}
+	if o.Or(5) != 0 || o.String() != "0" {
+		t.Errorf("unexpected set optional: %q", o.String())
+	}
+}
+
+func Test_confucius_Load_Optional(t *testing.T) {
+	type Config struct {
+		Retries  Optional[int]           `conf:"retries"`
+		Debug    Optional[bool]          `conf:"debug"`
+		Name     Optional[string]        `conf:"name"`
+		Timeout  Optional[time.Duration] `conf:"timeout" default:"5s"`
+		Workers  Optional[int]           `conf:"workers" default:"4"`
+		Hosts    Optional[[]string]      `conf:"hosts"`
+		Region   Optional[string]        `conf:"region"`
+		Schedule Optional[string]        `conf:"schedule" validate:"cron"`
+	}
+
+	setenv(t, "APP_DEBUG", "false")
+	setenv(t, "APP_HOSTS", "a,b")
+	defer os.Unsetenv("APP_DEBUG")
+	defer os.Unsetenv("APP_HOSTS")
+
+	var cfg Config
+	err := Load(&cfg, String(`{"retries": 0, "workers": 0, "timeout": "1m"}`, DecoderJSON), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if v, ok := cfg.Retries.Get(); !ok || v != 0 {
+		t.Errorf("cfg.Retries == %+v, expected set to 0", cfg.Retries)
+	}
+	if v, ok := cfg.Debug.Get(); !ok || v {
+		t.Errorf("cfg.Debug == %+v, expected set to false", cfg.Debug)
+	}
+	if _, ok := cfg.Name.Get(); ok {
+		t.Errorf("cfg.Name == %+v, expected unset", cfg.Name)
+	}
+	if v, ok := cfg.Timeout.Get(); !ok || v != time.Minute {
+		t.Errorf("cfg.Timeout == %+v, expected set to 1m", cfg.Timeout)
+	}
+	if v, ok := cfg.Workers.Get(); !ok || v != 0 {
+		t.Errorf("cfg.Workers == %+v, expected explicit 0 to be kept", cfg.Workers)
+	}
+	if v, ok := cfg.Hosts.Get(); !ok || len(v) != 2 {
+		t.Errorf("cfg.Hosts == %+v, expected [a b]", cfg.Hosts)
+	}
+
+	cfg = Config{}
+	if err := Load(&cfg, String(`{}`, DecoderJSON)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if v, ok := cfg.Workers.Get(); !ok || v != 4 {
+		t.Errorf("cfg.Workers == %+v, expected default 4", cfg.Workers)
+	}
+}
+
+func Test_confucius_Load_Optional_Validation(t *testing.T) {
+	type Config struct {
+		Retries  Optional[int]    `conf:"retries" validate:"required"`
+		Schedule Optional[string] `conf:"schedule" validate:"cron"`
+		Mode     Optional[string] `conf:"mode"`
+		Cert     string           `conf:"cert" validate:"required_if=Mode production"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"retries": 0, "schedule": "@daily"}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	err = Load(&Config{}, String(`{"schedule": "not a cron", "mode": "production"}`, DecoderJSON))
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok || len(fieldErrs) != 3 {
+		t.Fatalf("expected 3 field errors, got %v", err)
+	}
+	for _, path := range []string{"retries", "schedule", "cert"} {
+		if fieldErrs[path] == nil {
+			t.Errorf("expected err for %s, got %v", path, err)
+		}
+	}
+}
+
+func Test_Snapshot_Optional(t *testing.T) {
+	type Config struct {
+		Retries Optional[int]    `conf:"retries"`
+		Name    Optional[string] `conf:"name"`
+	}
+
+	b, err := Snapshot(&Config{Retries: Some(0)})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	var doc struct {
+		Values map[string]interface{} `json:"values"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if _, ok := doc.Values["name"]; ok || doc.Values["retries"] != float64(0) {
+		t.Errorf("unexpected snapshot: %s", b)
+	}
+
+	var restored Config
+	if err := RestoreSnapshot(b, &restored); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if restored != (Config{Retries: Some(0)}) {
+		t.Errorf("unexpected restored cfg: %+v", restored)
+	}
+}
@@ -0,0 +1,43 @@
+// Package otel traces the loading of confucius configs with OpenTelemetry.
+//
+//   err := confucius.Load(&cfg, confucius.File("config.yaml"), confotel.WithTracer(otel.Tracer("myapp")))
+//
+// A span is started for the whole load, and for each step of it as listed
+// by the Span constants of confucius.
+package otel
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hasanozgan/confucius"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracer returns an option that starts the spans of loading a config
+// with tracer. Spans of steps that fail record the error and have the
+// error status.
+func WithTracer(tracer trace.Tracer) confucius.Option {
+	return confucius.WithSpans(func(ctx context.Context, name string, attrs map[string]string) (context.Context, func(error)) {
+		keys := make([]string, 0, len(attrs))
+		for key := range attrs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		kvs := make([]attribute.KeyValue, len(keys))
+		for i, key := range keys {
+			kvs[i] = attribute.String("confucius."+key, attrs[key])
+		}
+
+		ctx, span := tracer.Start(ctx, name, trace.WithAttributes(kvs...))
+		return ctx, func(err error) {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}
+	})
+}
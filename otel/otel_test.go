@@ -0,0 +1,54 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/hasanozgan/confucius"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_WithTracer(t *testing.T) {
+	type Config struct {
+		Name string `conf:"name" validate:"required"`
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("test")
+
+	var cfg Config
+	err := confucius.Load(&cfg, confucius.String(`{}`, confucius.DecoderJSON), WithTracer(tracer))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+
+	spans := recorder.Ended()
+	names := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spans {
+		names[span.Name()] = span
+	}
+
+	load, ok := names[confucius.SpanLoad]
+	if !ok {
+		t.Fatalf("expected a %s span, got %d spans", confucius.SpanLoad, len(spans))
+	}
+	if load.Status().Code != codes.Error {
+		t.Errorf("status of %s == %v, expected an error", confucius.SpanLoad, load.Status())
+	}
+
+	decode, ok := names[confucius.SpanDecode]
+	if !ok {
+		t.Fatalf("expected a %s span", confucius.SpanDecode)
+	}
+	if decode.Parent().SpanID() != load.SpanContext().SpanID() {
+		t.Errorf("expected %s to be a child of %s", confucius.SpanDecode, confucius.SpanLoad)
+	}
+	if attrs := decode.Attributes(); len(attrs) != 1 || attrs[0].Key != "confucius.source" || attrs[0].Value.AsString() != confucius.SourceReader {
+		t.Errorf("attributes of %s == %v", confucius.SpanDecode, attrs)
+	}
+	if _, ok := names[confucius.SpanProcess]; !ok {
+		t.Errorf("expected a %s span", confucius.SpanProcess)
+	}
+}
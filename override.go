@@ -0,0 +1,82 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// setFromOverride sets a field to its override, if any. Overrides are
+// applied after the environment and the flags, so they take precedence
+// over all other sources.
+func (c *confucius) setFromOverride(field *field) error {
+	path := field.path()
+	val, ok := c.overrides[path]
+	if !ok {
+		return nil
+	}
+
+	c.markPresent(path)
+	c.setSource(path, Source{Kind: SourceOverride}, displayValue(fmt.Sprint(val), field.structTag))
+	if err := c.setOverrideValue(field.v, val, field.structTag); err != nil {
+		return fmt.Errorf("unable to set from override: %v", err)
+	}
+	return nil
+}
+
+// setOverrideValue sets fv to val. Values of the type of the field, or of
+// the type it points to, are set as they are. Strings are parsed like
+// values from the environment, and numbers and bools are formatted and
+// parsed likewise, so that e.g. an int can override an int64 field.
+func (c *confucius) setOverrideValue(fv reflect.Value, val interface{}, st structTag) error {
+	rv := reflect.ValueOf(val)
+	if !rv.IsValid() {
+		fv.Set(reflect.Zero(fv.Type()))
+		return nil
+	}
+	if rv.Type().AssignableTo(fv.Type()) {
+		fv.Set(rv)
+		return nil
+	}
+	if fv.Kind() == reflect.Ptr && rv.Type().AssignableTo(fv.Type().Elem()) {
+		ptr := reflect.New(fv.Type().Elem())
+		ptr.Elem().Set(rv)
+		fv.Set(ptr)
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return c.setTaggedValue(fv, rv.String(), st)
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return c.setTaggedValue(fv, fmt.Sprint(val), st)
+	default:
+		return fmt.Errorf("cannot set %s from %T", fv.Type(), val)
+	}
+}
+
+// unknownOverrides adds an error to errs for every override whose path
+// matches no field, so that typos in overrides do not go unnoticed.
+func (c *confucius) unknownOverrides(fields []*field, errs fieldErrors) {
+	if len(c.overrides) == 0 {
+		return
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.path()] = true
+	}
+	paths := make([]string, 0, len(c.overrides))
+	for path := range c.overrides {
+		if !known[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		errs[path] = &FieldError{Rule: "override", Err: fmt.Errorf("no field with this path")}
+	}
+}
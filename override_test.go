@@ -0,0 +1,84 @@
+package confucius
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_Override(t *testing.T) {
+	type Config struct {
+		Name    string        `conf:"name" validate:"required"`
+		Timeout time.Duration `conf:"timeout" default:"5s"`
+		Retries *int          `conf:"retries"`
+		Debug   bool          `conf:"debug"`
+		Server  struct {
+			Host string `conf:"host"`
+			Port int64  `conf:"port"`
+		} `conf:"server"`
+		Tags []string `conf:"tags"`
+	}
+
+	setenv(t, "MYAPP_SERVER_PORT", "80")
+	defer os.Unsetenv("MYAPP_SERVER_PORT")
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg,
+		String(`{"server": {"host": "file-host"}, "debug": true}`, DecoderJSON),
+		UseEnv("myapp"),
+		FlagValues(map[string]string{"server.port": "8080"}),
+		Override("server.port", 9090),
+		Overrides(map[string]interface{}{
+			"name":    "forced",
+			"timeout": "1m",
+			"retries": 3,
+			"debug":   false,
+			"tags":    []string{"a", "b"},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if cfg.Name != "forced" || cfg.Timeout != time.Minute || cfg.Debug {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+	if cfg.Retries == nil || *cfg.Retries != 3 {
+		t.Errorf("cfg.Retries == %v, expected 3", cfg.Retries)
+	}
+	if cfg.Server.Host != "file-host" || cfg.Server.Port != 9090 {
+		t.Errorf("unexpected cfg.Server: %+v", cfg.Server)
+	}
+	if len(cfg.Tags) != 2 || cfg.Tags[1] != "b" {
+		t.Errorf("cfg.Tags == %v, expected [a b]", cfg.Tags)
+	}
+	if got := report.Sources["server.port"]; got.Kind != SourceOverride {
+		t.Errorf("source of server.port == %v, expected %s", got, SourceOverride)
+	}
+}
+
+func Test_Override_Errors(t *testing.T) {
+	type Config struct {
+		Port int      `conf:"port"`
+		Tags []string `conf:"tags"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String("{}", DecoderJSON), Overrides(map[string]interface{}{
+		"prot": 8080,
+		"tags": map[string]string{},
+	}))
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok {
+		t.Fatalf("err == %v, expected fieldErrors", err)
+	}
+
+	var fieldErr *FieldError
+	if !errors.As(fieldErrs["prot"], &fieldErr) || fieldErr.Rule != "override" {
+		t.Errorf("err of prot == %v, expected an override error", fieldErrs["prot"])
+	}
+	if fieldErrs["tags"] == nil {
+		t.Errorf("expected an err for tags")
+	}
+}
@@ -0,0 +1,87 @@
+package confucius
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// parser is implemented by the field types of confucius that parse and
+// validate themselves from strings found in config files, the environment
+// and defaults.
+type parser interface {
+	parse(s string) error
+}
+
+var (
+	parserType          = reflect.TypeOf((*parser)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isTextUnmarshalerType reports whether values of type t are parsed with
+// their UnmarshalText method. time.Time and the types that implement
+// parser are parsed by confucius instead.
+func isTextUnmarshalerType(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(textUnmarshalerType) &&
+		t != reflect.TypeOf(time.Time{}) &&
+		!reflect.PtrTo(t).Implements(parserType)
+}
+
+// parserHookFunc returns a decode hook that parses scalar values into
+// fields whose type implements parser.
+func parserHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if !reflect.PtrTo(t).Implements(parserType) {
+			return data, nil
+		}
+		switch f.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return data, nil
+		}
+
+		v := reflect.New(t)
+		if err := v.Interface().(parser).parse(fmt.Sprint(data)); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+}
+
+// textUnmarshalerHookFunc returns a decode hook that parses scalar values
+// into fields whose type implements encoding.TextUnmarshaler, e.g. net.IP
+// or custom enum types.
+func textUnmarshalerHookFunc() mapstructure.DecodeHookFunc {
+	return func(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+		if !isTextUnmarshalerType(t) {
+			return data, nil
+		}
+		switch f.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return data, nil
+		}
+
+		v := reflect.New(t)
+		if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(fmt.Sprint(data))); err != nil {
+			return nil, err
+		}
+		return v.Elem().Interface(), nil
+	}
+}
+
+// parseValue parses val into fv if fv's type implements parser or
+// encoding.TextUnmarshaler. The returned bool is false if it does not.
+func parseValue(fv reflect.Value, val string) (bool, error) {
+	if !fv.CanAddr() {
+		return false, nil
+	}
+	if p, ok := fv.Addr().Interface().(parser); ok {
+		return true, p.parse(val)
+	}
+	if isTextUnmarshalerType(fv.Type()) {
+		return true, fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+	return false, nil
+}
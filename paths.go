@@ -0,0 +1,78 @@
+package confucius
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// fileRule fails if a field does not hold the path of a readable regular
+// file, e.g. a TLS certificate. Relative paths are relative to the working
+// directory. Empty paths are left to the required rule.
+func fileRule(f *field, _ string) error {
+	return checkStrings(f.v, func(path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory, not a file", path)
+		}
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		return fd.Close()
+	})
+}
+
+// dirRule fails if a field does not hold the path of a readable directory.
+// Relative paths are relative to the working directory. Empty paths are
+// left to the required rule.
+func dirRule(f *field, _ string) error {
+	return checkStrings(f.v, func(path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
+		}
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		if _, err := fd.Readdirnames(1); err != nil && err != io.EOF {
+			return err
+		}
+		return nil
+	})
+}
+
+// checkStrings calls check with v, or with every element of v if it is a
+// list of strings, skipping empty values.
+func checkStrings(v reflect.Value, check func(s string) error) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.String {
+		for i := 0; i < v.Len(); i++ {
+			if err := checkStrings(v.Index(i), check); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s := formatValue(v)
+	if s == "" {
+		return nil
+	}
+	return check(s)
+}
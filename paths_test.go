@@ -0,0 +1,78 @@
+package confucius
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_confucius_Load_FileDir(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(cert, []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.pem")
+
+	type Config struct {
+		Cert    string   `conf:"cert" validate:"file"`
+		Certs   []string `conf:"certs" validate:"file"`
+		DataDir *string  `conf:"data_dir" validate:"dir"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr map[string]string
+	}{
+		{name: "empty", config: map[string]interface{}{}},
+		{name: "valid", config: map[string]interface{}{"cert": cert, "certs": []string{cert}, "data_dir": dir}},
+		{
+			name:   "missing",
+			config: map[string]interface{}{"cert": missing, "certs": []string{cert, missing}, "data_dir": missing},
+			wantErr: map[string]string{
+				"cert":     "no such file or directory",
+				"certs":    "no such file or directory",
+				"data_dir": "no such file or directory",
+			},
+		},
+		{
+			name:   "wrong kind",
+			config: map[string]interface{}{"cert": dir, "data_dir": cert},
+			wantErr: map[string]string{
+				"cert":     "is a directory, not a file",
+				"data_dir": "is not a directory",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var cfg Config
+			err = Load(&cfg, String(string(b), DecoderJSON))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(fieldErrors)
+			if !ok || len(fieldErrs) != len(tc.wantErr) {
+				t.Fatalf("want errors for %v, got %v", tc.wantErr, err)
+			}
+			for path, want := range tc.wantErr {
+				var fieldErr *FieldError
+				if !errors.As(fieldErrs[path], &fieldErr) || !strings.Contains(fieldErr.Error(), want) {
+					t.Errorf("want error %q for %s, got %v", want, path, fieldErrs[path])
+				}
+			}
+		})
+	}
+}
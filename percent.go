@@ -0,0 +1,47 @@
+package confucius
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Percent is a ratio in the range [0, 1]. It can be set from a percentage
+// (`"85%"`), a fraction (`0.85`) or a whole number of percent (`85`), which
+// all result in the same value. Numbers greater than 1 are treated as a
+// number of percent, so `1` means 100%.
+//
+//   type Config struct {
+//     FillThreshold confucius.Percent `conf:"fill_threshold" default:"85%"`
+//   }
+//
+// Values outside of the range result in an error.
+type Percent float64
+
+// Float returns the ratio as a float64 in the range [0, 1].
+func (p Percent) Float() float64 {
+	return float64(p)
+}
+
+// String formats the ratio as a percentage, e.g. `85%`.
+func (p Percent) String() string {
+	return strconv.FormatFloat(float64(p)*100, 'f', -1, 64) + "%"
+}
+
+func (p *Percent) parse(s string) error {
+	s = strings.TrimSpace(s)
+	percentage := strings.HasSuffix(s, "%")
+	f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("invalid percent %q", s)
+	}
+	if percentage || f > 1 {
+		f /= 100
+	}
+	if f < 0 || f > 1 {
+		return fmt.Errorf("percent %q out of range [0%%, 100%%]", s)
+	}
+	*p = Percent(f)
+	return nil
+}
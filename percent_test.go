@@ -0,0 +1,83 @@
+package confucius
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPercent_parse(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    Percent
+		wantErr bool
+	}{
+		{in: "85%", want: 0.85},
+		{in: " 12.5 % ", want: 0.125},
+		{in: "0.85", want: 0.85},
+		{in: "85", want: 0.85},
+		{in: "1", want: 1},
+		{in: "0", want: 0},
+		{in: "100%", want: 1},
+		{in: "101", wantErr: true},
+		{in: "-5%", wantErr: true},
+		{in: "half", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "NaN%", wantErr: true},
+		{in: "Inf", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			var p Percent
+			err := p.parse(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if p != tc.want {
+				t.Errorf("want %v, got %v", tc.want, p)
+			}
+		})
+	}
+}
+
+func TestPercent_String(t *testing.T) {
+	if got := Percent(0.855).String(); got != "85.5%" {
+		t.Errorf("want %q, got %q", "85.5%", got)
+	}
+}
+
+func Test_confucius_Load_Percent(t *testing.T) {
+	type Config struct {
+		FillThreshold Percent  `conf:"fill_threshold"`
+		DrainRatio    Percent  `conf:"drain_ratio"`
+		Sampling      *Percent `conf:"sampling"`
+		Headroom      Percent  `conf:"headroom" default:"15%"`
+	}
+
+	setenv(t, "APP_SAMPLING", "5%")
+	defer os.Unsetenv("APP_SAMPLING")
+
+	var cfg Config
+	err := Load(&cfg, UseEnv("app"), String(`
+fill_threshold: "85%"
+drain_ratio: 0.25
+`, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.FillThreshold != 0.85 || cfg.DrainRatio != 0.25 || cfg.Headroom != 0.15 {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+	if cfg.Sampling == nil || *cfg.Sampling != 0.05 {
+		t.Errorf("cfg.Sampling == %v, expected %v", cfg.Sampling, 0.05)
+	}
+
+	err = Load(&cfg, String(`fill_threshold: 150`, DecoderYaml))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+}
@@ -0,0 +1,69 @@
+package confucius
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Pool is a config section for the settings of a connection pool.
+//
+//   type Config struct {
+//     DB struct {
+//       DSN  string         `conf:"dsn" validate:"required"`
+//       Pool confucius.Pool `conf:"pool"`
+//     } `conf:"db"`
+//   }
+//
+//   db:
+//     pool:
+//       max_open: 20
+//       max_idle: 5
+//       max_lifetime: 30m
+//       idle_timeout: 5m
+//
+// Settings that are not set keep the defaults of the pool. MaxIdle may not
+// exceed MaxOpen when both are set.
+type Pool struct {
+	MaxOpen     int           `conf:"max_open"`
+	MaxIdle     int           `conf:"max_idle"`
+	MaxLifetime time.Duration `conf:"max_lifetime"`
+	IdleTimeout time.Duration `conf:"idle_timeout"`
+}
+
+// Validate checks that the settings are not negative and that MaxIdle does
+// not exceed MaxOpen.
+func (p Pool) Validate() error {
+	switch {
+	case p.MaxOpen < 0:
+		return fmt.Errorf("max_open must not be negative")
+	case p.MaxIdle < 0:
+		return fmt.Errorf("max_idle must not be negative")
+	case p.MaxLifetime < 0:
+		return fmt.Errorf("max_lifetime must not be negative")
+	case p.IdleTimeout < 0:
+		return fmt.Errorf("idle_timeout must not be negative")
+	case p.MaxOpen > 0 && p.MaxIdle > p.MaxOpen:
+		return fmt.Errorf("max_idle (%d) exceeds max_open (%d)", p.MaxIdle, p.MaxOpen)
+	}
+	return nil
+}
+
+// Apply configures db with the settings that are set.
+//
+//   db, err := sql.Open("postgres", cfg.DB.DSN)
+//   cfg.DB.Pool.Apply(db)
+func (p Pool) Apply(db *sql.DB) {
+	if p.MaxOpen > 0 {
+		db.SetMaxOpenConns(p.MaxOpen)
+	}
+	if p.MaxIdle > 0 {
+		db.SetMaxIdleConns(p.MaxIdle)
+	}
+	if p.MaxLifetime > 0 {
+		db.SetConnMaxLifetime(p.MaxLifetime)
+	}
+	if p.IdleTimeout > 0 {
+		db.SetConnMaxIdleTime(p.IdleTimeout)
+	}
+}
@@ -0,0 +1,76 @@
+package confucius
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+type nopDriver struct{}
+
+func (nopDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func init() {
+	sql.Register("confucius-nop", nopDriver{})
+}
+
+func TestPool_Apply(t *testing.T) {
+	db, err := sql.Open("confucius-nop", "")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	defer db.Close()
+
+	Pool{MaxOpen: 20, MaxIdle: 5, MaxLifetime: time.Minute, IdleTimeout: time.Second}.Apply(db)
+	if got := db.Stats().MaxOpenConnections; got != 20 {
+		t.Errorf("MaxOpenConnections == %d, expected 20", got)
+	}
+
+	Pool{}.Apply(db)
+	if got := db.Stats().MaxOpenConnections; got != 20 {
+		t.Errorf("expected unset settings to be kept, got %d", got)
+	}
+}
+
+func TestPool_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		pool  Pool
+		valid bool
+	}{
+		{pool: Pool{}, valid: true},
+		{pool: Pool{MaxOpen: 10, MaxIdle: 10}, valid: true},
+		{pool: Pool{MaxIdle: 10}, valid: true},
+		{pool: Pool{MaxOpen: 5, MaxIdle: 10}, valid: false},
+		{pool: Pool{MaxOpen: -1}, valid: false},
+		{pool: Pool{MaxLifetime: -time.Second}, valid: false},
+	} {
+		if err := tc.pool.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%+v: unexpected err: %v", tc.pool, err)
+		}
+	}
+}
+
+func Test_confucius_Load_Pool(t *testing.T) {
+	type Config struct {
+		Pool Pool `conf:"pool"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"pool": {"max_open": 20, "max_idle": 5, "max_lifetime": "30m", "idle_timeout": "5m"}}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := Pool{MaxOpen: 20, MaxIdle: 5, MaxLifetime: 30 * time.Minute, IdleTimeout: 5 * time.Minute}
+	if cfg.Pool != want {
+		t.Errorf("want %+v, got %+v", want, cfg.Pool)
+	}
+
+	err = Load(&cfg, String(`{"pool": {"max_open": 2, "max_idle": 5}}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["pool"] == nil {
+		t.Errorf("expected pool err, got %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/imdario/mergo"
+)
+
+// precedenceKinds are the kinds of sources whose order can be set with
+// Precedence.
+var precedenceKinds = []string{SourceReader, SourceFile, SourceProfile, SourceProvider, SourceEnv}
+
+// checkPrecedence returns an error if the order set with Precedence is
+// ambiguous: if a kind is unknown or listed twice, or if a source is used
+// whose kind is not listed. Config files are used if they were set with
+// File, FileFromEnv, FileFromFlag, Dirs or EmbedFS.
+func (c *confucius) checkPrecedence() error {
+	seen := make(map[string]bool)
+	for _, kind := range c.precedence {
+		if !containsString(precedenceKinds, kind) {
+			return fmt.Errorf("precedence: unknown source %q, must be one of %s", kind, strings.Join(precedenceKinds, ", "))
+		}
+		if seen[kind] {
+			return fmt.Errorf("precedence: source %q is listed more than once", kind)
+		}
+		seen[kind] = true
+	}
+
+	used := map[string]bool{
+		SourceReader:   c.useReader,
+		SourceFile:     c.filesSet,
+		SourceProfile:  len(c.profiles) > 0,
+		SourceProvider: len(c.providers) > 0,
+		SourceEnv:      c.useEnv,
+	}
+	for _, kind := range precedenceKinds {
+		if used[kind] && !seen[kind] {
+			return fmt.Errorf("precedence: source %q is used but not listed", kind)
+		}
+	}
+	return nil
+}
+
+// usesFiles reports whether config files are searched.
+func (c *confucius) usesFiles() bool {
+	return c.precedence == nil || containsString(c.precedence, SourceFile) || containsString(c.precedence, SourceProfile)
+}
+
+// decodeByPrecedence merges the values of the reader, the files and the
+// providers in the order set with Precedence. The values of the sources
+// that take precedence over the environment, as recorded by recordSource,
+// are kept so that the environment does not override the fields they set.
+func (c *confucius) decodeByPrecedence(readerVals decodedObject, files []string) (decodedObject, error) {
+	vals := make(decodedObject)
+	aboveEnv := false
+	for _, kind := range c.precedence {
+		recorded := len(c.sourceVals)
+		var err error
+		switch kind {
+		case SourceReader:
+			vals, err = c.mergeReader(vals, readerVals)
+		case SourceFile, SourceProfile:
+			vals, err = c.decodeFiles(filesOfKind(files, kind), vals)
+		case SourceProvider:
+			vals, err = c.decodeProviders(vals)
+		case SourceEnv:
+			aboveEnv = true
+		}
+		if err != nil {
+			return nil, err
+		}
+		if aboveEnv {
+			for _, sv := range c.sourceVals[recorded:] {
+				c.aboveEnv = append(c.aboveEnv, sv.vals)
+			}
+		}
+	}
+	return vals, nil
+}
+
+// mergeReader merges the values of the reader over vals.
+func (c *confucius) mergeReader(vals, readerVals decodedObject) (decodedObject, error) {
+	if readerVals == nil {
+		return vals, nil
+	}
+	c.recordSource(Source{Kind: SourceReader}, readerVals)
+
+	mergeStart := time.Now()
+	err := mergo.Merge(&vals, readerVals, mergo.WithOverride, mergo.WithTypeCheck)
+	c.track(StageMerge, mergeStart)
+	if err := c.fail(err); err != nil {
+		return nil, err
+	}
+	c.merged = append(c.merged, Source{Kind: SourceReader})
+	c.emit(Event{Kind: EventMerge, Source: Source{Kind: SourceReader}})
+	return vals, nil
+}
+
+// filesOfKind returns the files found by findFiles that are of the given
+// kind, i.e. the config file or the profile files.
+func filesOfKind(files []string, kind string) []string {
+	var result []string
+	for _, file := range files {
+		if fileSource(file).Kind == kind {
+			result = append(result, file)
+		}
+	}
+	return result
+}
+
+// envShadowedPaths returns the paths of the fields of the struct type t
+// that were set by sources that take precedence over the environment.
+func (c *confucius) envShadowedPaths(t reflect.Type) map[string]bool {
+	if len(c.aboveEnv) == 0 {
+		return nil
+	}
+	paths := make(map[string]bool)
+	for _, vals := range c.aboveEnv {
+		for _, path := range c.sourceKeys(vals, t) {
+			paths[path] = true
+		}
+	}
+	return paths
+}
@@ -0,0 +1,114 @@
+package confucius
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_confucius_Load_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":      "name: file\nhost: file\n",
+		"config.prod.yaml": "host: profile\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Config struct {
+		Name string `conf:"name"`
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+	}
+
+	setenv(t, "APP_NAME", "env")
+	defer os.Unsetenv("APP_NAME")
+	setenv(t, "APP_PORT", "9090")
+	defer os.Unsetenv("APP_PORT")
+
+	reader := String(`{"name": "reader", "host": "reader", "port": 80}`, DecoderJSON)
+
+	for _, tc := range []struct {
+		name       string
+		options    []Option
+		want       Config
+		wantMerged []string
+	}{
+		{
+			name:       "implicit",
+			options:    []Option{Dirs(dir), reader, Profiles("prod")},
+			want:       Config{Name: "file", Host: "profile", Port: 80},
+			wantMerged: []string{"reader", "file", "profile"},
+		},
+		{
+			name:       "reader over files",
+			options:    []Option{Dirs(dir), reader, Profiles("prod"), Precedence(SourceFile, SourceProfile, SourceReader)},
+			want:       Config{Name: "reader", Host: "reader", Port: 80},
+			wantMerged: []string{"file", "profile", "reader"},
+		},
+		{
+			name:       "files over env",
+			options:    []Option{Dirs(dir), reader, UseEnv("app"), Precedence(SourceReader, SourceEnv, SourceFile)},
+			want:       Config{Name: "file", Host: "file", Port: 9090},
+			wantMerged: []string{"reader", "file"},
+		},
+		{
+			name:       "no files",
+			options:    []Option{reader, UseEnv("app"), Precedence(SourceReader, SourceEnv)},
+			want:       Config{Name: "env", Host: "reader", Port: 9090},
+			wantMerged: []string{"reader"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			report, err := LoadWithReport(&cfg, tc.options...)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if cfg != tc.want {
+				t.Errorf("cfg == %+v, expected %+v", cfg, tc.want)
+			}
+			var merged []string
+			for _, source := range report.Merged {
+				merged = append(merged, source.Kind)
+			}
+			if !reflect.DeepEqual(merged, tc.wantMerged) {
+				t.Errorf("merged == %v, expected %v", merged, tc.wantMerged)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name    string
+		options []Option
+		wantErr string
+	}{
+		{name: "two readers", options: []Option{reader, String(`{}`, DecoderJSON), Precedence(SourceReader, SourceFile)}, wantErr: "2 readers are set"},
+		{name: "unknown kind", options: []Option{Precedence(SourceFile, SourceFlag)}, wantErr: `unknown source "flag"`},
+		{name: "listed twice", options: []Option{Precedence(SourceFile, SourceFile)}, wantErr: `source "file" is listed more than once`},
+		{name: "not listed", options: []Option{UseEnv("app"), Precedence(SourceFile)}, wantErr: `source "env" is used but not listed`},
+		{name: "file not listed", options: []Option{File("config.yaml"), reader, Precedence(SourceReader, SourceEnv)}, wantErr: `source "file" is used but not listed`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := Load(&cfg, append([]Option{Dirs(dir)}, tc.options...)...)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected err %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_TwoReaders(t *testing.T) {
+	var cfg struct {
+		Name string `conf:"name"`
+	}
+	err := Load(&cfg, String(`{"name": "a"}`, DecoderJSON), String(`{"name": "b"}`, DecoderJSON))
+	if err == nil || !strings.Contains(err.Error(), "2 readers are set") {
+		t.Fatalf("expected two readers err, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package confucius
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Pending is a config whose sources are being fetched in the background,
+// as started by `Preload`.
+type Pending struct {
+	ctx  context.Context
+	c    *confucius
+	done chan struct{}
+	vals decodedObject
+	err  error
+}
+
+// Preload starts finding, reading and merging the config files and the
+// reader in the background, so that a service can go on with its bootstrap
+// while its config sources are fetched. The config is decoded into a
+// struct once `Wait` is called.
+//
+//   pending, err := confucius.Preload(ctx, confucius.File("config.yaml"), confucius.UseEnv("app"))
+//   ...
+//   var cfg Config
+//   err = pending.Wait(&cfg)
+//
+// Preload returns an error if ctx is already done.
+func Preload(ctx context.Context, options ...Option) (*Pending, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	c.spanCtx = ctx
+	p := &Pending{ctx: ctx, c: c, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		c.logger.Debug("confucius preloading")
+		p.vals, p.err = c.fetchValues()
+	}()
+	return p, nil
+}
+
+// Ready returns a channel that is closed once the sources have been
+// fetched, after which Wait returns without blocking on them.
+func (p *Pending) Ready() <-chan struct{} {
+	return p.done
+}
+
+// Wait waits until the sources have been fetched, or until the context
+// passed to Preload is done, and then loads the config into cfg as `Load`
+// does, applying the environment, defaults and validations. The parameter
+// `cfg` must be a pointer to a struct. Wait can be called several times,
+// e.g. to load the same sources into different structs.
+func (p *Pending) Wait(cfg interface{}) error {
+	// values that were fetched are used even if the context is done by now.
+	select {
+	case <-p.done:
+	default:
+		select {
+		case <-p.done:
+		case <-p.ctx.Done():
+			return p.ctx.Err()
+		}
+	}
+	if p.err != nil {
+		return p.err
+	}
+	if !isStructPtr(cfg) {
+		return fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	// decoding records state and modifies the values, so each call gets its
+	// own copies.
+	c := *p.c
+	c.errs = append([]error(nil), p.c.errs...)
+	c.timings = make(map[string]time.Duration, len(p.c.timings))
+	for stage, d := range p.c.timings {
+		c.timings[stage] = d
+	}
+	vals := deepCopy(reflect.ValueOf(p.vals)).Interface().(decodedObject)
+
+	return c.atomically(cfg, func(cfg interface{}) error {
+		return c.decodeValues(vals, cfg)
+	})
+}
@@ -0,0 +1,87 @@
+package confucius
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_Preload(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: app\ntimeout: 5\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	type Config struct {
+		Name    string `conf:"name" validate:"required"`
+		Timeout int    `conf:"timeout,unit=s"`
+		Port    int    `conf:"port" default:"8080"`
+	}
+
+	setenv(t, "APP_PORT", "9090")
+	defer os.Unsetenv("APP_PORT")
+
+	pending, err := Preload(context.Background(), Dirs(dir), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	<-pending.Ready()
+
+	for i := 0; i < 2; i++ {
+		var cfg Config
+		if err := pending.Wait(&cfg); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Name != "app" || cfg.Timeout != 5 || cfg.Port != 9090 {
+			t.Errorf("unexpected cfg: %+v", cfg)
+		}
+	}
+
+	var cfg struct {
+		Missing string `conf:"missing" validate:"required"`
+	}
+	if err := pending.Wait(&cfg); err == nil {
+		t.Errorf("expected validation err")
+	}
+}
+
+func Test_Preload_Errors(t *testing.T) {
+	pending, err := Preload(context.Background(), Dirs(t.TempDir()))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	var cfg struct{}
+	if err := pending.Wait(&cfg); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("expected ErrFileNotFound, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Preload(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func Test_Preload_CancelledAfterFetch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	pending, err := Preload(ctx, String(`{"name": "app"}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	<-pending.Ready()
+	cancel()
+
+	for i := 0; i < 20; i++ {
+		var cfg struct {
+			Name string `conf:"name"`
+		}
+		if err := pending.Wait(&cfg); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Name != "app" {
+			t.Errorf("cfg.Name == %q, expected app", cfg.Name)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package confucius
+
+import (
+	"reflect"
+	"strings"
+)
+
+// The kinds of sources that a field's value can come from.
+const (
+	SourceDefault  = "default"  // the field's default tag or registered base defaults.
+	SourceFile     = "file"     // the config file.
+	SourceProfile  = "profile"  // a profile file.
+	SourceReader   = "reader"   // the reader set by Reader or String.
+	SourceEnv      = "env"      // an environment variable.
+	SourceFlag     = "flag"     // a command line flag.
+	SourceOverride = "override" // a value set with Override or Overrides.
+	SourceProvider = "provider" // a provider set with FromProviders.
+)
+
+// Source describes where the value of a field came from.
+type Source struct {
+	Kind string // one of the Source constants.
+	Name string // the file's path, or the name of the environment variable or flag.
+}
+
+// String formats the source, e.g. `file config.yaml` or `env APP_PORT`.
+func (s Source) String() string {
+	if s.Name == "" {
+		return s.Kind
+	}
+	return s.Kind + " " + s.Name
+}
+
+// sourceValues are the values decoded from a single source.
+type sourceValues struct {
+	source Source
+	vals   decodedObject
+}
+
+// recordSource records the values decoded from a source, if sources are
+// tracked or their order is set with Precedence, so that the fields they
+// set can be attributed to the source.
+func (c *confucius) recordSource(source Source, vals decodedObject) {
+	if (c.sources == nil && c.precedence == nil) || vals == nil {
+		return
+	}
+	copied := deepCopy(reflect.ValueOf(vals)).Interface().(decodedObject)
+	c.sourceVals = append(c.sourceVals, sourceValues{source: source, vals: copied})
+}
+
+// fileSource returns the source of a file found by findFiles.
+func fileSource(file string) Source {
+	kind := SourceFile
+	if strings.Contains(file, ProfileFileIndicator) {
+		kind = SourceProfile
+	}
+	return Source{Kind: kind, Name: file[strings.Index(file, "=")+1:]}
+}
+
+// attributeSources sets the source of the fields of the struct type t that
+// the recorded sources set. Sources are recorded in the order they are
+// merged, so later sources take precedence.
+func (c *confucius) attributeSources(t reflect.Type) {
+	for _, sv := range c.sourceVals {
+		for _, path := range c.sourceKeys(sv.vals, t) {
+			c.sources[path] = sv.source
+		}
+	}
+	c.sourceVals = nil
+}
+
+// sourceKeys returns the paths of the fields of the struct type t that
+// vals set, by decoding them on their own the same way the merged values
+// are decoded.
+func (c *confucius) sourceKeys(vals decodedObject, t reflect.Type) []string {
+	sc := *c
+	sc.logger = defaultLogger()
+	sc.userMetadata = nil
+	sc.disableExpansion = true
+
+	if sc.keyMatcher != nil {
+		_ = sc.matchKeys(vals, t)
+	}
+	_ = sc.transformValues(vals, t, func(_ structTag, _ reflect.Type, val interface{}) (interface{}, error) {
+		return val, nil
+	})
+	_ = sc.decodeMap(vals, reflect.New(t.Elem()).Interface())
+	return sc.metadata.Keys
+}
+
+// setSource records the source of the field with the given path, if
+// sources are tracked, and emits an event for it. value is the value the
+// field was set to, as displayed in the event.
+func (c *confucius) setSource(path string, source Source, value string) {
+	if c.sources != nil {
+		c.sources[path] = source
+	}
+	c.emit(Event{Kind: EventSet, Path: path, Source: source, Value: value})
+}
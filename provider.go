@@ -0,0 +1,105 @@
+package confucius
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/imdario/mergo"
+)
+
+// MapProvider reads a config as nested maps. It is implemented by the
+// providers of koanf, e.g. confmap or env, so that they can be used as
+// sources with `FromProviders`, and by `Provider`.
+type MapProvider interface {
+	Read() (map[string]interface{}, error)
+}
+
+// Provider exposes the merged values of the config files and the reader of
+// a set of options, so that confucius can act as a koanf provider during a
+// migration:
+//
+//   k := koanf.New(".")
+//   err := k.Load(confucius.NewProvider(confucius.File("config.yaml")), nil)
+//
+// The values are read anew on every call, after the profiles are merged and
+// references are resolved. Environment variables, flags and defaults are
+// applied to structs only, so they are not part of the values.
+type Provider struct {
+	options []Option
+}
+
+// NewProvider returns a Provider of the values read with the given options.
+func NewProvider(options ...Option) *Provider {
+	return &Provider{options: append([]Option(nil), options...)}
+}
+
+// Read returns the merged values as nested maps.
+func (p *Provider) Read() (map[string]interface{}, error) {
+	c := defaultConfucius()
+
+	for _, opt := range p.options {
+		opt(c)
+	}
+
+	vals, err := c.fetchValues()
+	if err != nil {
+		return nil, err
+	}
+	if c.subKey != "" {
+		if vals, err = c.selectSubKey(vals); err != nil {
+			return nil, err
+		}
+	} else if !c.disableExpansion {
+		if err := resolveReferences(vals); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.errs) > 0 {
+		return nil, &LoadError{Errors: c.errs}
+	}
+	return vals, nil
+}
+
+// ReadBytes returns the merged values encoded as JSON, for use with koanf's
+// json parser.
+func (p *Provider) ReadBytes() ([]byte, error) {
+	vals, err := p.Read()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(vals)
+}
+
+// decodeProviders merges the values of the providers over vals, in order.
+func (c *confucius) decodeProviders(vals decodedObject) (decodedObject, error) {
+	for i, p := range c.providers {
+		source := Source{Kind: SourceProvider, Name: fmt.Sprintf("%T", p)}
+		c.emit(Event{Kind: EventDecode, Source: source})
+
+		end := c.startSpan(SpanFetch, map[string]string{"source": source.String()})
+		providerVals, err := p.Read()
+		end(err)
+		if err != nil {
+			if err = c.fail(fmt.Errorf("provider %d (%T): %w", i, p, err)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		providerVals = normalizeValue(providerVals).(map[string]interface{})
+		c.recordSource(source, providerVals)
+
+		mergeStart := time.Now()
+		err = mergo.Merge(&vals, decodedObject(providerVals), mergo.WithOverride, mergo.WithTypeCheck)
+		c.track(StageMerge, mergeStart)
+		if err != nil {
+			if err = c.fail(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		c.merged = append(c.merged, source)
+		c.emit(Event{Kind: EventMerge, Source: source})
+	}
+	return vals, nil
+}
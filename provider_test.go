@@ -0,0 +1,101 @@
+package confucius
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// mapProvider mimics koanf's confmap provider.
+type mapProvider map[string]interface{}
+
+func (p mapProvider) Read() (map[string]interface{}, error) {
+	return p, nil
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Read() (map[string]interface{}, error) {
+	return nil, errors.New("unavailable")
+}
+
+func Test_Provider(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":      "host: localhost\nport: 80\nurl: http://${host}:${port}\n",
+		"config.prod.yaml": "host: example.com\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	p := NewProvider(Dirs(dir), Profiles("prod"))
+	vals, err := p.Read()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := map[string]interface{}{"host": "example.com", "port": 80, "url": "http://example.com:80"}
+	if !reflect.DeepEqual(vals, want) {
+		t.Errorf("Read() == %v, expected %v", vals, want)
+	}
+
+	b, err := p.ReadBytes()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if decoded["host"] != "example.com" {
+		t.Errorf("ReadBytes() == %s, expected host example.com", b)
+	}
+
+	if _, err := NewProvider(Dirs(t.TempDir())).Read(); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("err == %v, expected ErrFileNotFound", err)
+	}
+}
+
+func Test_FromProviders(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+		Log  struct {
+			Level string `conf:"level" default:"info"`
+		} `conf:"log"`
+	}
+
+	setenv(t, "APP_PORT", "8080")
+	defer os.Unsetenv("APP_PORT")
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg,
+		String(`{"host": "localhost", "port": 80}`, DecoderJSON),
+		UseEnv("app"),
+		FromProviders(
+			mapProvider{"host": "example.com", "log": map[string]interface{}{"level": "warn"}},
+			mapProvider{"log": map[string]interface{}{"level": "debug"}},
+		),
+	)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Host != "example.com" || cfg.Port != 8080 || cfg.Log.Level != "debug" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+	if got := report.Sources["host"].Kind; got != SourceProvider {
+		t.Errorf("source of host == %q, expected %q", got, SourceProvider)
+	}
+	if len(report.Merged) != 3 {
+		t.Errorf("report.Merged == %v, expected the reader and two providers", report.Merged)
+	}
+
+	err = Load(&cfg, FromProviders(failingProvider{}))
+	if err == nil || err.Error() != "provider 0 (confucius.failingProvider): unavailable" {
+		t.Errorf("err == %v, expected the error of the provider", err)
+	}
+}
@@ -0,0 +1,153 @@
+package confucius
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Proxy is a config section for outbound HTTP proxies.
+//
+//   type Config struct {
+//     Proxy confucius.Proxy `conf:"proxy"`
+//   }
+//
+//   proxy:
+//     http: "http://proxy.internal:3128"
+//     https: "http://proxy.internal:3128"
+//     no_proxy: [".internal", "10.0.0.0/8"]
+//
+// Settings that are left empty fall back to the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables (or their lower-case versions), so the
+// section works out of the box in environments that only set those.
+type Proxy struct {
+	HTTP    string   `conf:"http"`
+	HTTPS   string   `conf:"https"`
+	NoProxy []string `conf:"no_proxy"`
+}
+
+// Validate checks that the proxy URLs are valid.
+func (p Proxy) Validate() error {
+	for _, proxy := range []string{p.HTTP, p.HTTPS} {
+		if _, err := parseProxyURL(proxy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Func returns a function that selects the proxy of a request. It can be
+// used as the Proxy of an http.Transport:
+//
+//   transport := &http.Transport{Proxy: cfg.Proxy.Func()}
+//
+// Requests to hosts matching no_proxy, as well as requests to localhost
+// and loopback addresses, are not proxied.
+func (p Proxy) Func() func(*http.Request) (*url.URL, error) {
+	httpProxy := firstNonEmpty(p.HTTP, getenvAny("HTTP_PROXY", "http_proxy"))
+	httpsProxy := firstNonEmpty(p.HTTPS, getenvAny("HTTPS_PROXY", "https_proxy"))
+	noProxy := p.NoProxy
+	if len(noProxy) == 0 {
+		noProxy = strings.Split(getenvAny("NO_PROXY", "no_proxy"), ",")
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := httpProxy
+		if req.URL.Scheme == "https" {
+			proxy = httpsProxy
+		}
+		if proxy == "" || !useProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		return parseProxyURL(proxy)
+	}
+}
+
+// parseProxyURL parses a proxy URL. URLs without a scheme are treated as
+// http proxies.
+func parseProxyURL(proxy string) (*url.URL, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy address %q", proxy)
+	}
+	return u, nil
+}
+
+// useProxy reports whether requests to u should be proxied.
+func useProxy(u *url.URL, noProxy []string) bool {
+	host, port := u.Hostname(), u.Port()
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return false
+	}
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return false
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return false
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if matchProxyHost(strings.ToLower(host), entryHost) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchProxyHost reports whether host matches a no_proxy host. `example.com`
+// matches the domain and its subdomains, `.example.com` only its subdomains.
+func matchProxyHost(host, entry string) bool {
+	if strings.HasPrefix(entry, ".") {
+		return strings.HasSuffix(host, entry)
+	}
+	return host == entry || strings.HasSuffix(host, "."+entry)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, val := range values {
+		if val != "" {
+			return val
+		}
+	}
+	return ""
+}
+
+// getenvAny returns the value of the first of the environment variables
+// that is set.
+func getenvAny(keys ...string) string {
+	for _, key := range keys {
+		if val := os.Getenv(key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package confucius
+
+import (
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestProxy_Func(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+
+	p := Proxy{
+		HTTP:    "proxy.internal:3128",
+		HTTPS:   "https://secure.internal:3129",
+		NoProxy: []string{".svc", "example.com", "10.0.0.0/8", "db.local:5432"},
+	}
+
+	for _, tc := range []struct {
+		url  string
+		want string
+	}{
+		{url: "http://api.github.com/", want: "http://proxy.internal:3128"},
+		{url: "https://api.github.com/", want: "https://secure.internal:3129"},
+		{url: "http://localhost:8080/", want: ""},
+		{url: "http://127.0.0.1/", want: ""},
+		{url: "http://orders.default.svc/", want: ""},
+		{url: "http://svc/", want: "http://proxy.internal:3128"},
+		{url: "http://example.com/", want: ""},
+		{url: "http://www.example.com/", want: ""},
+		{url: "http://notexample.com/", want: "http://proxy.internal:3128"},
+		{url: "http://10.1.2.3/", want: ""},
+		{url: "http://db.local:5432/", want: ""},
+		{url: "http://db.local:5433/", want: "http://proxy.internal:3128"},
+	} {
+		t.Run(tc.url, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+			got, err := p.Func()(req)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if (got == nil && tc.want != "") || (got != nil && got.String() != tc.want) {
+				t.Errorf("want %q, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestProxy_Func_Environment(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	setenv(t, "http_proxy", "http://env-proxy:8080")
+	setenv(t, "NO_PROXY", "localhost, *.internal")
+
+	fn := Proxy{}.Func()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.org/", nil)
+	if got, _ := fn(req); got == nil || got.Host != "env-proxy:8080" {
+		t.Errorf("expected env-proxy, got %v", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "http://a.internal/", nil)
+	if got, _ := fn(req); got != nil {
+		t.Errorf("expected no proxy, got %v", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://example.org/", nil)
+	if got, _ := fn(req); got != nil {
+		t.Errorf("expected no https proxy, got %v", got)
+	}
+}
+
+func Test_confucius_Load_Proxy(t *testing.T) {
+	type Config struct {
+		Proxy Proxy `conf:"proxy"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`{"proxy": {"http": "http://proxy:3128", "no_proxy": ["a", "b"]}}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Proxy.HTTP != "http://proxy:3128" || len(cfg.Proxy.NoProxy) != 2 {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	err = Load(&cfg, String(`{"proxy": {"https": "http://"}}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["proxy"] == nil {
+		t.Fatalf("expected proxy err, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package confucius
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Quantity is a Kubernetes-style resource quantity, e.g. `500m` CPU or
+// `2Gi` memory, so that structs mirroring Kubernetes manifests can load
+// real cluster YAML. A quantity is a number followed by a binary suffix
+// (`Ki`, `Mi`, `Gi`, `Ti`, `Pi`, `Ei`), a decimal suffix (`n`, `u`, `m`,
+// `k`, `M`, `G`, `T`, `P`, `E`) or a decimal exponent (`1e3`).
+//
+//   type Resources struct {
+//     CPU    confucius.Quantity `conf:"cpu" default:"250m"`
+//     Memory confucius.Quantity `conf:"memory" default:"64Mi"`
+//   }
+//
+// The quantity keeps the form it was set from, which is returned by
+// String.
+type Quantity struct {
+	s string
+	r *big.Rat
+}
+
+// quantitySuffixes are the multipliers of the suffixes of a Quantity.
+var quantitySuffixes = map[string]*big.Rat{
+	"n":  big.NewRat(1, 1e9),
+	"u":  big.NewRat(1, 1e6),
+	"m":  big.NewRat(1, 1e3),
+	"":   big.NewRat(1, 1),
+	"k":  big.NewRat(1e3, 1),
+	"M":  big.NewRat(1e6, 1),
+	"G":  big.NewRat(1e9, 1),
+	"T":  big.NewRat(1e12, 1),
+	"P":  big.NewRat(1e15, 1),
+	"E":  big.NewRat(1e18, 1),
+	"Ki": big.NewRat(1<<10, 1),
+	"Mi": big.NewRat(1<<20, 1),
+	"Gi": big.NewRat(1<<30, 1),
+	"Ti": big.NewRat(1<<40, 1),
+	"Pi": big.NewRat(1<<50, 1),
+	"Ei": big.NewRat(1<<60, 1),
+}
+
+// ParseQuantity parses a quantity such as `500m` or `2Gi`.
+func ParseQuantity(s string) (Quantity, error) {
+	var q Quantity
+	err := q.parse(s)
+	return q, err
+}
+
+// Value returns the quantity as an integer, rounded up, e.g. 2147483648
+// for `2Gi` and 1 for `500m`.
+func (q Quantity) Value() int64 {
+	return q.scaled(1)
+}
+
+// MilliValue returns the quantity in thousandths, rounded up, e.g. 500 for
+// `500m`.
+func (q Quantity) MilliValue() int64 {
+	return q.scaled(1000)
+}
+
+// Float64 returns the quantity as a float64, e.g. 0.5 for `500m`.
+func (q Quantity) Float64() float64 {
+	if q.r == nil {
+		return 0
+	}
+	f, _ := q.r.Float64()
+	return f
+}
+
+// String returns the quantity in the form it was set from.
+func (q Quantity) String() string {
+	if q.s == "" {
+		return "0"
+	}
+	return q.s
+}
+
+// marshalValue returns the quantity in the form it was set from.
+func (q Quantity) marshalValue() interface{} {
+	return q.String()
+}
+
+// scaled returns the quantity multiplied by scale, rounded up.
+func (q Quantity) scaled(scale int64) int64 {
+	if q.r == nil {
+		return 0
+	}
+	r := new(big.Rat).Mul(q.r, big.NewRat(scale, 1))
+	n, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() > 0 {
+		n.Add(n, big.NewInt(1))
+	}
+	return n.Int64()
+}
+
+func (q *Quantity) parse(s string) error {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '+' && r != '-'
+	})
+	number, suffix := trimmed, ""
+	if i != -1 {
+		number, suffix = trimmed[:i], trimmed[i:]
+	}
+
+	r, ok := new(big.Rat).SetString(number)
+	if !ok || number == "" {
+		return fmt.Errorf("invalid quantity %q", s)
+	}
+
+	if multiplier, ok := quantitySuffixes[suffix]; ok {
+		r.Mul(r, multiplier)
+	} else if exp, ok := new(big.Rat).SetString("1" + suffix); ok && (suffix[0] == 'e' || suffix[0] == 'E') {
+		r.Mul(r, exp)
+	} else {
+		return fmt.Errorf("invalid quantity %q: unknown suffix %q", s, suffix)
+	}
+
+	q.s, q.r = trimmed, r
+	return nil
+}
+
+// RFC3339Micro is the layout of the microsecond timestamps used by
+// Kubernetes, e.g. in events and leases.
+const RFC3339Micro = "2006-01-02T15:04:05.000000Z07:00"
+
+// MicroTime is a time with microsecond precision that is formatted using
+// `RFC3339Micro` when the config is encoded, so that it round-trips. It is
+// set from RFC3339 timestamps with any number of fractional digits.
+//
+//   type Lease struct {
+//     RenewTime confucius.MicroTime `conf:"renewTime"`
+//   }
+type MicroTime time.Time
+
+// Time returns the time as a time.Time.
+func (t MicroTime) Time() time.Time {
+	return time.Time(t)
+}
+
+// String formats the time using RFC3339Micro.
+func (t MicroTime) String() string {
+	return time.Time(t).Format(RFC3339Micro)
+}
+
+// marshalValue returns the time formatted using RFC3339Micro.
+func (t MicroTime) marshalValue() interface{} {
+	return t.String()
+}
+
+func (t *MicroTime) parse(s string) error {
+	parsed, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(s))
+	if err != nil {
+		return err
+	}
+	*t = MicroTime(parsed.Truncate(time.Microsecond))
+	return nil
+}
@@ -0,0 +1,94 @@
+package confucius
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuantity_parse(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		value   int64
+		milli   int64
+		wantErr bool
+	}{
+		{in: "500m", value: 1, milli: 500},
+		{in: "2Gi", value: 2 << 30, milli: 2000 << 30},
+		{in: "1.5", value: 2, milli: 1500},
+		{in: "64Mi", value: 64 << 20, milli: 64000 << 20},
+		{in: "100k", value: 100000, milli: 100000000},
+		{in: "1e3", value: 1000, milli: 1000000},
+		{in: "250u", value: 1, milli: 1},
+		{in: "3", value: 3, milli: 3000},
+		{in: "2Gb", wantErr: true},
+		{in: "m", wantErr: true},
+		{in: "lots", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			q, err := ParseQuantity(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if q.Value() != tc.value || q.MilliValue() != tc.milli {
+				t.Errorf("want %d and %dm, got %d and %dm", tc.value, tc.milli, q.Value(), q.MilliValue())
+			}
+			if q.String() != tc.in {
+				t.Errorf("String() == %q, expected %q", q.String(), tc.in)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_Quantity(t *testing.T) {
+	type Config struct {
+		Resources struct {
+			Limits map[string]Quantity `conf:"limits"`
+			CPU    Quantity            `conf:"cpu" default:"250m"`
+			Memory Quantity            `conf:"memory"`
+		} `conf:"resources"`
+		CreationTimestamp time.Time `conf:"creationTimestamp"`
+		RenewTime         MicroTime `conf:"renewTime"`
+	}
+
+	content := `
+resources:
+  limits:
+    cpu: 2
+    memory: 1Gi
+  memory: 512Mi
+creationTimestamp: "2024-01-02T03:04:05Z"
+renewTime: "2024-01-02T03:04:05.123456Z"
+`
+
+	var cfg Config
+	if err := Load(&cfg, String(content, DecoderYaml)); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Resources.CPU.MilliValue() != 250 || cfg.Resources.Memory.Value() != 512<<20 {
+		t.Errorf("unexpected resources: %v %v", cfg.Resources.CPU, cfg.Resources.Memory)
+	}
+	if cfg.Resources.Limits["cpu"].Value() != 2 || cfg.Resources.Limits["memory"].String() != "1Gi" {
+		t.Errorf("unexpected limits: %v", cfg.Resources.Limits)
+	}
+	if got := cfg.RenewTime.String(); got != "2024-01-02T03:04:05.123456Z" {
+		t.Errorf("unexpected renew time %s", got)
+	}
+
+	b, err := Snapshot(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	var restored Config
+	if err := RestoreSnapshot(b, &restored); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if restored.RenewTime != cfg.RenewTime || restored.Resources.Memory.String() != "512Mi" {
+		t.Errorf("snapshot did not round-trip: %+v", restored)
+	}
+}
@@ -0,0 +1,122 @@
+package confucius
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit is a config section for a token bucket rate limit.
+//
+//   type Config struct {
+//     Limit confucius.RateLimit `conf:"limit"`
+//   }
+//
+//   limit:
+//     rps: 50
+//     burst: 100
+//
+// RPS is the number of events allowed per second, Burst the number of events
+// that may happen at once. A zero RPS disables the limit. When Burst is not
+// set it defaults to RPS rounded up.
+type RateLimit struct {
+	RPS   float64 `conf:"rps"`
+	Burst int     `conf:"burst"`
+}
+
+// Validate checks that the rate and the burst are not negative.
+func (r RateLimit) Validate() error {
+	if r.RPS < 0 || math.IsNaN(r.RPS) || math.IsInf(r.RPS, 0) {
+		return fmt.Errorf("invalid rps %v", r.RPS)
+	}
+	if r.Burst < 0 {
+		return fmt.Errorf("invalid burst %d", r.Burst)
+	}
+	return nil
+}
+
+// Limiter returns a new limiter that enforces the rate limit. The limiter
+// starts with a full bucket.
+func (r RateLimit) Limiter() *Limiter {
+	burst := r.Burst
+	if burst == 0 {
+		burst = int(math.Ceil(r.RPS))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rps:    r.RPS,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// Limiter is a token bucket limiter created by `RateLimit.Limiter()`. It is
+// safe for concurrent use.
+type Limiter struct {
+	mu     sync.Mutex
+	rps    float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// Allow reports whether an event may happen now, consuming a token if so.
+func (l *Limiter) Allow() bool {
+	if l.rps == 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait blocks until an event may happen or ctx is done, in which case the
+// context's error is returned.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l.rps == 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	l.refill()
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rps * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// refill adds the tokens accumulated since the last call. l.mu must be held.
+func (l *Limiter) refill() {
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rps)
+	}
+	l.last = now
+}
@@ -0,0 +1,80 @@
+package confucius
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimit_Limiter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := RateLimit{RPS: 2, Burst: 3}.Limiter()
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("expected event %d to be allowed", i)
+		}
+	}
+	if l.Allow() {
+		t.Fatalf("expected burst to be exhausted")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if !l.Allow() {
+		t.Fatalf("expected a token after 500ms")
+	}
+	if l.Allow() {
+		t.Fatalf("expected a single token after 500ms")
+	}
+
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		if !l.Allow() {
+			t.Fatalf("expected event %d to be allowed after refill", i)
+		}
+	}
+	if l.Allow() {
+		t.Fatalf("expected bucket to hold at most burst tokens")
+	}
+}
+
+func TestRateLimit_Limiter_Wait(t *testing.T) {
+	l := RateLimit{RPS: 1000}.Limiter()
+	if l.burst != 1000 {
+		t.Errorf("expected burst to default to rps, got %v", l.burst)
+	}
+
+	l = RateLimit{RPS: 0.001}.Limiter()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	unlimited := RateLimit{}.Limiter()
+	for i := 0; i < 100; i++ {
+		if !unlimited.Allow() {
+			t.Fatalf("expected zero rps to be unlimited")
+		}
+	}
+}
+
+func TestRateLimit_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		limit RateLimit
+		valid bool
+	}{
+		{limit: RateLimit{}, valid: true},
+		{limit: RateLimit{RPS: 10, Burst: 5}, valid: true},
+		{limit: RateLimit{RPS: -1}, valid: false},
+		{limit: RateLimit{RPS: 1, Burst: -1}, valid: false},
+	} {
+		if err := tc.limit.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%+v: unexpected err: %v", tc.limit, err)
+		}
+	}
+}
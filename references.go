@@ -0,0 +1,153 @@
+package confucius
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// indexPattern matches the indexes of a key path segment, e.g. `[0]`.
+var indexPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// referenceResolver replaces references to other keys of the config, e.g.
+// `${server.host}`, in the string values of the merged config values.
+type referenceResolver struct {
+	vals      map[string]interface{}
+	resolving map[string]bool // the paths of the values being resolved, to detect cycles.
+	resolved  map[string]string
+}
+
+// resolveReferences replaces the references to other keys in the string
+// values of vals. References whose name is not a key of vals are left as
+// they are, so that they are looked up in the environment.
+func resolveReferences(vals map[string]interface{}) error {
+	r := &referenceResolver{
+		vals:      vals,
+		resolving: make(map[string]bool),
+		resolved:  make(map[string]string),
+	}
+	return r.walk(vals, "")
+}
+
+func (r *referenceResolver) walk(val interface{}, path string) error {
+	if m, ok := toStringMap(val); ok {
+		for key, v := range m {
+			resolved, err := r.resolveValue(v, joinPath(path, key))
+			if err != nil {
+				return err
+			}
+			m[key] = resolved
+		}
+		if orig, ok := val.(map[interface{}]interface{}); ok {
+			for key := range orig {
+				orig[key] = m[fmt.Sprint(key)]
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// resolveValue returns val with the references in it replaced.
+func (r *referenceResolver) resolveValue(val interface{}, path string) (interface{}, error) {
+	switch v := val.(type) {
+	case string:
+		return r.resolveString(v, path)
+	case []interface{}:
+		for i := range v {
+			resolved, err := r.resolveValue(v[i], fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	}
+	return val, r.walk(val, path)
+}
+
+// resolveString replaces the references in s, which is the value of the
+// key with the given path.
+func (r *referenceResolver) resolveString(s, path string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if resolved, ok := r.resolved[path]; ok {
+		return resolved, nil
+	}
+	if r.resolving[path] {
+		return "", fmt.Errorf("%s: circular reference", path)
+	}
+	r.resolving[path] = true
+	defer delete(r.resolving, path)
+
+	var err error
+	result := environmentPattern.ReplaceAllStringFunc(s, func(whole string) string {
+		if strings.HasPrefix(whole, "$$") || err != nil {
+			return whole
+		}
+		name := whole[2 : len(whole)-1]
+		if i := strings.Index(name, ":"); i != -1 {
+			name = name[:i]
+		}
+
+		ref, ok := lookupPath(r.vals, name)
+		if !ok {
+			return whole
+		}
+		switch ref := ref.(type) {
+		case string:
+			resolved, resolveErr := r.resolveString(ref, name)
+			if resolveErr != nil {
+				err = resolveErr
+				return whole
+			}
+			return resolved
+		case map[string]interface{}, map[interface{}]interface{}, decodedObject, []interface{}:
+			err = fmt.Errorf("%s: reference to %s which is not a single value", path, name)
+			return whole
+		default:
+			return fmt.Sprint(ref)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	r.resolved[path] = result
+	return result, nil
+}
+
+// lookupPath returns the value of vals with the given path, e.g.
+// `server.host` or `servers[0].host`. Keys are matched exactly.
+func lookupPath(vals map[string]interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	var cur interface{} = vals
+	for _, segment := range strings.Split(path, ".") {
+		key := segment
+		if i := strings.Index(segment, "["); i != -1 {
+			key = segment[:i]
+		}
+
+		m, ok := toStringMap(cur)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+
+		for _, match := range indexPattern.FindAllStringSubmatch(segment[len(key):], -1) {
+			s, ok := cur.([]interface{})
+			idx, _ := strconv.Atoi(match[1])
+			if !ok || idx >= len(s) {
+				return nil, false
+			}
+			cur = s[idx]
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,108 @@
+package confucius
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_confucius_Load_References(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Host string `conf:"host"`
+			Port int    `conf:"port"`
+		} `conf:"server"`
+		URL      string   `conf:"url"`
+		Health   string   `conf:"health"`
+		Mirrors  []string `conf:"mirrors"`
+		Home     string   `conf:"home"`
+		Template string   `conf:"template"`
+		First    string   `conf:"first"`
+	}
+
+	setenv(t, "CONFUCIUS_TEST_SCHEME", "https")
+	defer os.Unsetenv("CONFUCIUS_TEST_SCHEME")
+
+	content := `
+server:
+  host: example.com
+  port: 8443
+url: "${CONFUCIUS_TEST_SCHEME}://${server.host}:${server.port}"
+health: "${url}/health"
+mirrors:
+  - "${server.host}"
+  - "backup.${server.host}"
+home: "${server.missing:-none}"
+template: "$${server.host}"
+first: "${servers[0]}"
+`
+	var cfg Config
+	err := Load(&cfg, String(content, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if cfg.URL != "https://example.com:8443" {
+		t.Errorf("cfg.URL == %q", cfg.URL)
+	}
+	if cfg.Health != "https://example.com:8443/health" {
+		t.Errorf("cfg.Health == %q", cfg.Health)
+	}
+	if len(cfg.Mirrors) != 2 || cfg.Mirrors[0] != "example.com" || cfg.Mirrors[1] != "backup.example.com" {
+		t.Errorf("cfg.Mirrors == %v", cfg.Mirrors)
+	}
+	if cfg.Home != "none" || cfg.Template != "${server.host}" || cfg.First != "" {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	cfg = Config{}
+	if err := Load(&cfg, String(content, DecoderYaml), DisableExpansion()); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Health != "${url}/health" {
+		t.Errorf("cfg.Health == %q, expected references to be kept", cfg.Health)
+	}
+}
+
+func Test_resolveReferences(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		vals map[string]interface{}
+		err  string
+	}{
+		{
+			name: "circular",
+			vals: map[string]interface{}{"a": "${b}", "b": "x${a}"},
+			err:  "circular reference",
+		},
+		{
+			name: "self",
+			vals: map[string]interface{}{"a": "${a}"},
+			err:  "circular reference",
+		},
+		{
+			name: "not a single value",
+			vals: map[string]interface{}{"a": "${b}", "b": map[string]interface{}{"c": 1}},
+			err:  "a: reference to b which is not a single value",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := resolveReferences(tc.vals)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected err %q, got %v", tc.err, err)
+			}
+		})
+	}
+
+	vals := map[string]interface{}{
+		"servers": []interface{}{map[interface{}]interface{}{"host": "a"}, map[string]interface{}{"host": "b"}},
+		"hosts":   "${servers[0].host},${servers[1].host}",
+		"nested":  map[interface{}]interface{}{"ref": "${hosts}"},
+	}
+	if err := resolveReferences(vals); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if vals["hosts"] != "a,b" || vals["nested"].(map[interface{}]interface{})["ref"] != "a,b" {
+		t.Errorf("unexpected vals: %v", vals)
+	}
+}
@@ -0,0 +1,141 @@
+package confucius
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The stages of loading a config, in the order they run.
+const (
+	StageDiscover = "discover" // finding the config files.
+	StageFetch    = "fetch"    // reading the config files and the reader.
+	StageDecode   = "decode"   // parsing the contents and decoding them into the struct.
+	StageMerge    = "merge"    // merging the values of the config files.
+	StageEnv      = "env"      // setting values from the environment and flags.
+	StageDefaults = "defaults" // setting default values.
+	StageValidate = "validate" // checking required fields, rules and validators.
+)
+
+var stages = []string{StageDiscover, StageFetch, StageDecode, StageMerge, StageEnv, StageDefaults, StageValidate}
+
+// Report describes how a config was loaded.
+type Report struct {
+	Duration time.Duration // the total duration of the load.
+	Stages   []StageTiming // the time spent in each stage, in the order the stages run.
+
+	// The paths of the fields that were decoded from the files and the
+	// reader, as listed by mapstructure's Metadata. Keys are the fields that
+	// received a value, Unused the keys in the sources that match no field
+	// and Unset the fields absent from the sources. Values set from the
+	// environment, flags and defaults are not covered.
+	Keys   []string
+	Unused []string
+	Unset  []string
+
+	// Sources holds where the final value of each field that received a
+	// value came from, by the field's path.
+	Sources map[string]Source
+
+	// Merged lists the reader and the config files in the order they were
+	// merged: the reader, the config file, the profile files in the order
+	// of `Profiles` and then the providers of `FromProviders`. Later
+	// sources take precedence.
+	Merged []Source
+
+	// Profiles lists the active profiles, including the detected
+	// environment and the default profile. DefaultProfile is true if no
+	// profile was set and the profile of `DefaultProfile` was used.
+	Profiles       []string
+	DefaultProfile bool
+
+	// Warnings lists the failures of warning-level rules, e.g.
+	// `warn:max=100`, in the order of the fields. They are logged but do
+	// not fail loading.
+	Warnings []*FieldError
+
+	// UnusedEnv lists the environment variables with the prefix of
+	// `UseEnv` that match no field, in sorted order, if `ReportUnusedEnv`
+	// is used.
+	UnusedEnv []string
+}
+
+// StageTiming is the time spent in a stage of loading a config.
+type StageTiming struct {
+	Stage    string
+	Duration time.Duration
+}
+
+// Stage returns the time spent in the given stage.
+func (r *Report) Stage(stage string) time.Duration {
+	for _, timing := range r.Stages {
+		if timing.Stage == stage {
+			return timing.Duration
+		}
+	}
+	return 0
+}
+
+// String formats the timings of the report, e.g.
+// `loaded in 1.2ms (discover 120µs, fetch 300µs, ...)`.
+func (r *Report) String() string {
+	timings := make([]string, len(r.Stages))
+	for i, timing := range r.Stages {
+		timings[i] = fmt.Sprintf("%s %v", timing.Stage, timing.Duration)
+	}
+	return fmt.Sprintf("loaded in %v (%s)", r.Duration, strings.Join(timings, ", "))
+}
+
+// LoadWithReport is like Load but also returns a report that describes how
+// the config was loaded, e.g. to find out why startup is slow.
+//
+//   report, err := confucius.LoadWithReport(&cfg, confucius.File("config.yaml"))
+//   log.Println(report) // loaded in 1.2ms (discover 120µs, fetch 300µs, ...)
+//
+// The report is returned even if loading fails and then covers the stages
+// that ran. Tracking the sources of the fields, see `Report.Sources`, makes
+// LoadWithReport slower than Load.
+func LoadWithReport(cfg interface{}, options ...Option) (*Report, error) {
+	c := defaultConfucius()
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	c.sources = make(map[string]Source)
+	start := time.Now()
+	err := c.Load(cfg)
+	return c.report(time.Since(start)), err
+}
+
+// report builds the report of the last load, which took d.
+func (c *confucius) report(d time.Duration) *Report {
+	r := &Report{
+		Duration: d,
+		Stages:   make([]StageTiming, len(stages)),
+		Keys:     c.metadata.Keys,
+		Unused:   c.metadata.Unused,
+		Unset:    c.metadata.Unset,
+		Sources:  c.sources,
+		Merged:   c.merged,
+
+		Profiles:       c.profiles,
+		DefaultProfile: c.usedDefaultProfile,
+		Warnings:       c.warnings,
+		UnusedEnv:      c.unusedEnv,
+	}
+	for i, stage := range stages {
+		r.Stages[i] = StageTiming{Stage: stage, Duration: c.timings[stage]}
+	}
+	return r
+}
+
+// track adds the time elapsed since start to the time spent in stage.
+//
+//   defer c.track(StageDecode, time.Now())
+func (c *confucius) track(stage string, start time.Time) {
+	if c.timings == nil {
+		c.timings = make(map[string]time.Duration)
+	}
+	c.timings[stage] += time.Since(start)
+}
@@ -0,0 +1,300 @@
+package confucius
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+func Test_LoadWithReport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("name: app\n"), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	type Config struct {
+		Name string `conf:"name" validate:"required"`
+		Port int    `conf:"port" default:"8080"`
+	}
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg, Dirs(dir), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Name != "app" || cfg.Port != 8080 {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+
+	if report.Duration <= 0 {
+		t.Errorf("expected a duration, got %v", report.Duration)
+	}
+	if len(report.Stages) != len(stages) {
+		t.Fatalf("expected %d stages, got %+v", len(stages), report.Stages)
+	}
+	var sum time.Duration
+	for i, timing := range report.Stages {
+		if timing.Stage != stages[i] {
+			t.Errorf("stage %d == %q, expected %q", i, timing.Stage, stages[i])
+		}
+		sum += timing.Duration
+	}
+	if sum > report.Duration {
+		t.Errorf("stages took %v, longer than the load %v", sum, report.Duration)
+	}
+	for _, stage := range []string{StageDiscover, StageFetch, StageDecode, StageValidate} {
+		if report.Stage(stage) <= 0 {
+			t.Errorf("expected time spent in %s, got %s", stage, report)
+		}
+	}
+	if !strings.HasPrefix(report.String(), "loaded in ") || !strings.Contains(report.String(), "discover ") {
+		t.Errorf("unexpected report string %q", report)
+	}
+
+	report, err = LoadWithReport(&Config{}, Dirs(t.TempDir()))
+	if err == nil || report == nil {
+		t.Fatalf("expected err and report, got %v %v", err, report)
+	}
+}
+
+func Test_LoadWithReport_Metadata(t *testing.T) {
+	type Shared struct {
+		Base
+		LogLevel string `conf:"log_level"`
+	}
+	type Config struct {
+		Shared
+		Name   string `conf:"name"`
+		Server struct {
+			Host string `fig:"host"`
+			Port int    `fig:"port" default:"8080"`
+		} `conf:"server"`
+	}
+
+	content := `{"name": "app", "log_level": "debug", "server": {"host": "localhost", "hots": "typo"}, "extra": 1}`
+
+	var cfg Config
+	var md mapstructure.Metadata
+	report, err := LoadWithReport(&cfg, String(content, DecoderJSON), Tags("conf", "fig"), Metadata(&md))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	wantKeys := []string{"log_level", "name", "server", "server.host"}
+	keys := append([]string(nil), report.Keys...)
+	sort.Strings(keys)
+	if !reflect.DeepEqual(wantKeys, keys) {
+		t.Errorf("Keys == %v, expected %v", keys, wantKeys)
+	}
+	if want := []string{"extra", "server.hots"}; !reflect.DeepEqual(want, report.Unused) {
+		t.Errorf("Unused == %v, expected %v", report.Unused, want)
+	}
+	if want := []string{"server.port"}; !reflect.DeepEqual(want, report.Unset) {
+		t.Errorf("Unset == %v, expected %v", report.Unset, want)
+	}
+	if !reflect.DeepEqual(md.Keys, report.Keys) || !reflect.DeepEqual(md.Unused, report.Unused) || !reflect.DeepEqual(md.Unset, report.Unset) {
+		t.Errorf("metadata %+v differs from report %+v", md, report)
+	}
+}
+
+func Test_LoadWithReport_Sources(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":      "name: app\nport: 80\nserver:\n  host: localhost\n",
+		"config.prod.yaml": "port: 443\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Config struct {
+		Name    string `conf:"name"`
+		Port    int    `conf:"port"`
+		Timeout int    `conf:"timeout" default:"30"`
+		Debug   bool   `conf:"debug"`
+		Region  string `conf:"region"`
+		Server  struct {
+			Host string `conf:"host"`
+		} `conf:"server"`
+	}
+
+	setenv(t, "APP_DEBUG", "true")
+	defer os.Unsetenv("APP_DEBUG")
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg, Dirs(dir), Profiles("prod"), UseEnv("app"),
+		String(`{"region": "eu", "name": "reader"}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"name":        "file " + filepath.Join(dir, "config.yaml"),
+		"port":        "profile " + filepath.Join(dir, "config.prod.yaml"),
+		"server.host": "file " + filepath.Join(dir, "config.yaml"),
+		"timeout":     "default",
+		"debug":       "env APP_DEBUG",
+		"region":      "reader",
+	} {
+		if got := report.Sources[path].String(); got != want {
+			t.Errorf("%s: source == %q, expected %q", path, got, want)
+		}
+	}
+}
+
+func Test_LoadWithReport_ProfilePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":         "name: app\nregion: none\nport: 80\nlevel: info\n",
+		"config.base.yaml":    "region: base\nport: 8080\n",
+		"config.prod.yaml":    "region: prod\n",
+		"config.eu-west.yaml": "region: eu-west\nlevel: warn\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Config struct {
+		Name   string `conf:"name"`
+		Region string `conf:"region"`
+		Port   int    `conf:"port"`
+		Level  string `conf:"level"`
+	}
+
+	for _, tc := range []struct {
+		profiles []string
+		want     Config
+	}{
+		{[]string{"base", "prod", "eu-west"}, Config{Name: "app", Region: "eu-west", Port: 8080, Level: "warn"}},
+		{[]string{"eu-west", "prod", "base"}, Config{Name: "app", Region: "base", Port: 8080, Level: "warn"}},
+		{[]string{"prod"}, Config{Name: "app", Region: "prod", Port: 80, Level: "info"}},
+	} {
+		var cfg Config
+		report, err := LoadWithReport(&cfg, Dirs(dir), Profiles(tc.profiles...))
+		if err != nil {
+			t.Fatalf("%v: unexpected err: %v", tc.profiles, err)
+		}
+		if cfg != tc.want {
+			t.Errorf("%v: cfg == %+v, expected %+v", tc.profiles, cfg, tc.want)
+		}
+
+		want := []Source{{Kind: SourceFile, Name: filepath.Join(dir, "config.yaml")}}
+		for _, profile := range tc.profiles {
+			want = append(want, Source{Kind: SourceProfile, Name: filepath.Join(dir, "config."+profile+".yaml")})
+		}
+		if !reflect.DeepEqual(report.Merged, want) {
+			t.Errorf("%v: report.Merged == %v, expected %v", tc.profiles, report.Merged, want)
+		}
+		if got, want := report.Sources["region"].Name, want[len(want)-1].Name; got != want {
+			t.Errorf("%v: report.Sources[region] == %q, expected %q", tc.profiles, got, want)
+		}
+	}
+}
+
+func Test_LoadWithReport_DefaultProfile(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":      "name: app\n",
+		"config.dev.yaml":  "name: dev-app\n",
+		"config.prod.yaml": "name: prod-app\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Config struct {
+		Name string `conf:"name"`
+	}
+
+	for _, tc := range []struct {
+		name        string
+		env         string
+		options     []Option
+		want        string
+		wantDefault bool
+	}{
+		{name: "no profile", want: "dev-app", wantDefault: true},
+		{name: "profiles", options: []Option{Profiles("prod")}, want: "prod-app"},
+		{name: "detected environment", env: "production", options: []Option{DetectEnvironment()}, want: "prod-app"},
+		{name: "no detected environment", options: []Option{DetectEnvironment()}, want: "dev-app", wantDefault: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range environmentVariables {
+				os.Unsetenv(key)
+			}
+			if tc.env != "" {
+				setenv(t, "APP_ENV", tc.env)
+				defer os.Unsetenv("APP_ENV")
+			}
+
+			var cfg Config
+			options := append([]Option{Dirs(dir), DefaultProfile("dev")}, tc.options...)
+			report, err := LoadWithReport(&cfg, options...)
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if cfg.Name != tc.want {
+				t.Errorf("cfg.Name == %q, expected %q", cfg.Name, tc.want)
+			}
+			if report.DefaultProfile != tc.wantDefault {
+				t.Errorf("report.DefaultProfile == %v, expected %v", report.DefaultProfile, tc.wantDefault)
+			}
+			if len(report.Profiles) != 1 {
+				t.Errorf("report.Profiles == %v, expected a single profile", report.Profiles)
+			}
+		})
+	}
+}
+
+func Test_LoadWithReport_UnusedEnv(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Host string `conf:"host"`
+		} `conf:"server"`
+		Ports  []int             `conf:"ports"`
+		Labels map[string]string `conf:"labels"`
+		Name   string            `conf:"name,alias=title"`
+	}
+
+	for key, val := range map[string]string{
+		"MYAPP_SERVER_HOST":   "localhost",
+		"MYAPP_SERVERR_HOST":  "typo",
+		"MYAPP_PORTS_0":       "80",
+		"MYAPP_LABELS_TEAM":   "core",
+		"MYAPP_TITLE":         "app",
+		"MYAPP_UNKNOWN":       "x",
+		"OTHERAPP_SERVERHOST": "other",
+	} {
+		setenv(t, key, val)
+		defer os.Unsetenv(key)
+	}
+
+	var cfg Config
+	report, err := LoadWithReport(&cfg, UseEnv("myapp"), ReportUnusedEnv(), String(`{"ports": [0]}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Server.Host != "localhost" || cfg.Name != "app" || cfg.Labels["team"] != "core" {
+		t.Errorf("cfg == %+v", cfg)
+	}
+	if want := []string{"MYAPP_SERVERR_HOST", "MYAPP_UNKNOWN"}; !reflect.DeepEqual(want, report.UnusedEnv) {
+		t.Errorf("want unused env %v, got %v", want, report.UnusedEnv)
+	}
+
+	report, err = LoadWithReport(&cfg, UseEnv("myapp"), String(`{}`, DecoderJSON))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if report.UnusedEnv != nil {
+		t.Errorf("want no unused env without ReportUnusedEnv, got %v", report.UnusedEnv)
+	}
+}
@@ -0,0 +1,58 @@
+package confucius
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// rootListKey is the name of the list at the root of a config document.
+// It is the first element of the paths of the list's fields, e.g. in the
+// environment and in validation errors.
+const rootListKey = "items"
+
+// isSlicePtr reports whether i is a pointer to a slice.
+func isSlicePtr(i interface{}) bool {
+	v := reflect.ValueOf(i)
+	return v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Slice
+}
+
+// loadList loads a config document whose root is a list into cfg, which
+// must be a pointer to a slice. The slice is loaded as the only field of a
+// struct, named rootListKey.
+func (c *confucius) loadList(cfg interface{}) error {
+	sv := reflect.ValueOf(cfg).Elem()
+	wrapper := reflect.New(reflect.StructOf([]reflect.StructField{{
+		Name: "Items",
+		Type: sv.Type(),
+		Tag:  reflect.StructTag(fmt.Sprintf("%s:%q", c.decodeTag(), rootListKey)),
+	}}))
+	wrapper.Elem().Field(0).Set(sv)
+
+	c.rootList = true
+	err := c.Load(wrapper.Interface())
+	sv.Set(wrapper.Elem().Field(0))
+
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		loadErr.Config = cfg
+	}
+	return err
+}
+
+// wrapRootList returns the values of a decoded document whose root is a
+// list, which are the list under rootListKey, or root itself if it is not
+// a list.
+func wrapRootList(root interface{}) (decodedObject, error) {
+	switch root := root.(type) {
+	case []interface{}:
+		return decodedObject{rootListKey: root}, nil
+	case nil:
+		return make(decodedObject), nil
+	}
+	m, ok := toStringMap(root)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %T at the root of the document", root)
+	}
+	return m, nil
+}
@@ -0,0 +1,67 @@
+package confucius
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_Load_RootList(t *testing.T) {
+	type Rule struct {
+		Name   string `conf:"name" validate:"required"`
+		Action string `conf:"action" default:"allow"`
+	}
+
+	dir := t.TempDir()
+	content := "- name: internal\n- name: blocked\n  action: deny\n"
+	if err := os.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	setenv(t, "RULES_ITEMS_1_ACTION", "log")
+	defer os.Unsetenv("RULES_ITEMS_1_ACTION")
+
+	var rules []Rule
+	if err := Load(&rules, File("rules.yaml"), Dirs(dir), UseEnv("rules")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := []Rule{{Name: "internal", Action: "allow"}, {Name: "blocked", Action: "log"}}
+	if !reflect.DeepEqual(want, rules) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, rules)
+	}
+
+	var jsonRules []Rule
+	if err := Load(&jsonRules, String(`[{"name": "a"}, {"action": "deny"}]`, DecoderJSON)); err == nil {
+		t.Fatalf("expected err")
+	} else if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["items[1].name"] == nil {
+		t.Errorf("expected required error for items[1].name, got %v", err)
+	}
+}
+
+func Test_Load_RootListDocuments(t *testing.T) {
+	type Resource struct {
+		Kind string `conf:"kind" validate:"required"`
+		Name string `conf:"name"`
+	}
+
+	var resources []Resource
+	err := Load(&resources, String(`
+kind: Service
+name: web
+---
+- kind: Deployment
+  name: web
+- kind: ConfigMap
+---
+---
+kind: Ingress
+`, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := []Resource{{Kind: "Service", Name: "web"}, {Kind: "Deployment", Name: "web"}, {Kind: "ConfigMap"}, {Kind: "Ingress"}}
+	if !reflect.DeepEqual(want, resources) {
+		t.Errorf("\nwant %+v\ngot  %+v", want, resources)
+	}
+}
@@ -0,0 +1,280 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// rule is a single rule of a field's validate tag, e.g. `required_if=Mode production`.
+type rule struct {
+	name  string
+	param string
+	warn  bool // failures are warnings that do not fail loading, e.g. `warn:max=100`.
+}
+
+// ruleFunc checks a rule with the given parameter against a field.
+type ruleFunc func(f *field, param string) error
+
+// ruleFuncs are the rules that are checked after all fields have been
+// processed. Rules that are not listed here (e.g. rules of other validation
+// libraries sharing the validate tag) are ignored.
+var ruleFuncs = map[string]ruleFunc{
+	"required_if":     requiredIf,
+	"required_unless": requiredUnless,
+	"required_with":   requiredWith,
+	"cron":            cronRule,
+	"mimetype":        mimetypeRule,
+	"expr":            exprRule,
+	"oneof":           oneofRule,
+	"unique":          uniqueRule,
+	"file":            fileRule,
+	"dir":             dirRule,
+	"hostport":        hostportRule,
+	"tcp_addr":        tcpAddrRule,
+	"min":             boundRule("min", ">=", false),
+	"max":             boundRule("max", "<=", false),
+	"gte":             boundRule("gte", ">=", false),
+	"lte":             boundRule("lte", "<=", false),
+	"len":             boundRule("len", "==", false),
+	"minlen":          boundRule("minlen", ">=", true),
+	"maxlen":          boundRule("maxlen", "<=", true),
+}
+
+// parseRules parses the comma separated rules of a validate tag.
+//
+//   "required_if=Mode production,min=1"  --->  [{required_if Mode production} {min 1}]
+func parseRules(tag string) []rule {
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		r := rule{name: part}
+		if strings.HasPrefix(part, warnPrefix) {
+			part = strings.TrimPrefix(part, warnPrefix)
+			r = rule{name: part, warn: true}
+		}
+		if i := strings.Index(part, "="); i != -1 {
+			r.name, r.param = part[:i], part[i+1:]
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// checkRules checks every rule of the field, and its values if its type
+// implements Enum, and returns the first error.
+func checkRules(f *field) error {
+	for _, r := range f.rules {
+		fn, ok := ruleFuncs[r.name]
+		if !ok || r.warn {
+			continue
+		}
+		if err := fn(f, r.param); err != nil {
+			return &FieldError{Rule: r.name, Value: ruleValue(f), Err: err}
+		}
+	}
+	return checkEnum(f)
+}
+
+// warnPrefix marks the rules of a validate tag whose failures are warnings.
+const warnPrefix = "warn:"
+
+// checkWarnRules checks the warning-level rules of the field, including
+// `warn:required`, and returns the first failure.
+func checkWarnRules(f *field) *FieldError {
+	for _, r := range f.rules {
+		if !r.warn {
+			continue
+		}
+		if r.name == "required" {
+			if isZero(f.v) {
+				return &FieldError{Rule: warnPrefix + r.name, Err: ErrRequired}
+			}
+			continue
+		}
+		fn, ok := ruleFuncs[r.name]
+		if !ok {
+			continue
+		}
+		if err := fn(f, r.param); err != nil {
+			if f.secret {
+				err = &redactedError{err: err, values: secretValues(f.v)}
+			}
+			return &FieldError{Rule: warnPrefix + r.name, Value: ruleValue(f), Err: err}
+		}
+	}
+	return nil
+}
+
+// requiredIf fails if the field is not set while all the sibling fields in
+// param have the given values, e.g. `required_if=Mode production`.
+func requiredIf(f *field, param string) error {
+	match, err := siblingsMatch(f, param)
+	if err != nil {
+		return fmt.Errorf("required_if: %v", err)
+	}
+	if match && isZero(f.v) {
+		return fmt.Errorf("%w (required_if=%s)", ErrRequired, param)
+	}
+	return nil
+}
+
+// requiredUnless fails if the field is not set unless all the sibling
+// fields in param have the given values, e.g. `required_unless=Mode dev`.
+func requiredUnless(f *field, param string) error {
+	match, err := siblingsMatch(f, param)
+	if err != nil {
+		return fmt.Errorf("required_unless: %v", err)
+	}
+	if !match && isZero(f.v) {
+		return fmt.Errorf("%w (required_unless=%s)", ErrRequired, param)
+	}
+	return nil
+}
+
+// requiredWith fails if the field is not set while any of the sibling
+// fields in param is set, e.g. `required_with=Password` so that a username
+// and a password are set together.
+func requiredWith(f *field, param string) error {
+	names := strings.Fields(param)
+	if len(names) == 0 {
+		return fmt.Errorf("required_with: parameter must list fields")
+	}
+	if !isZero(f.v) {
+		return nil
+	}
+	for _, name := range names {
+		sibling, ok := lookupSibling(f, name)
+		if !ok {
+			return fmt.Errorf("required_with: field %q not found", name)
+		}
+		if !isZero(sibling) {
+			return fmt.Errorf("%w (required_with=%s)", ErrRequired, name)
+		}
+	}
+	return nil
+}
+
+// uniqueRule fails if two elements of a slice or an array are equal, e.g.
+// `unique` on a list of hosts. On lists of structs, param names the fields
+// whose values must be unique together, e.g. `unique=Name` or
+// `unique=Host Port`. Both indices of the first duplicate are reported.
+func uniqueRule(f *field, param string) error {
+	if f.sliceIdx >= 0 || f.mapKey.IsValid() {
+		return nil
+	}
+	v := f.v
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return fmt.Errorf("unique: not supported for %s", v.Type())
+	}
+
+	names := strings.Fields(param)
+	seen := make(map[string]int)
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+
+		key := formatValue(elem)
+		if len(names) > 0 {
+			if elem.Kind() != reflect.Struct {
+				return fmt.Errorf("unique: fields %v of %s not found", names, elem.Type())
+			}
+			values := make([]string, len(names))
+			for j, name := range names {
+				fv, ok := structField(elem, name, f.tagKey, f.tags)
+				if !ok {
+					return fmt.Errorf("unique: field %q not found", name)
+				}
+				values[j] = formatValue(fv)
+			}
+			key = strings.Join(values, " ")
+		}
+
+		if j, ok := seen[key]; ok {
+			if len(names) > 0 {
+				return fmt.Errorf("[%d] and [%d] have the same %s %q", j, i, strings.Join(names, " "), key)
+			}
+			return fmt.Errorf("[%d] and [%d] are both %q", j, i, key)
+		}
+		seen[key] = i
+	}
+	return nil
+}
+
+// siblingsMatch reports whether all the `name value` pairs in param match the
+// sibling fields of f. Siblings may be referred to by their struct field name
+// or their alt name.
+func siblingsMatch(f *field, param string) (bool, error) {
+	pairs := strings.Fields(param)
+	if len(pairs) == 0 || len(pairs)%2 != 0 {
+		return false, fmt.Errorf("parameter %q must consist of field and value pairs", param)
+	}
+
+	for i := 0; i < len(pairs); i += 2 {
+		sibling, ok := lookupSibling(f, pairs[i])
+		if !ok {
+			return false, fmt.Errorf("field %q not found", pairs[i])
+		}
+		if formatValue(sibling) != pairs[i+1] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// lookupSibling returns the value of the field with the given name in the
+// struct that contains f.
+func lookupSibling(f *field, name string) (reflect.Value, bool) {
+	if f.parent == nil || f.parent.v.Kind() != reflect.Struct || f.sliceIdx >= 0 {
+		return reflect.Value{}, false
+	}
+	return structField(f.parent.v, name, f.tagKey, f.tags)
+}
+
+// structField returns the value of the field of the struct v with the given
+// struct field name or alt name. The tags of the fields are looked up in
+// tags.
+func structField(v reflect.Value, name, tagKey string, tags *tagCache) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if sf.Name == name || tags.parse(sf.Tag, tagKey).altName == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// formatValue formats v, dereferencing pointers, so it can be compared
+// with the values in rule parameters. Nil pointers are formatted as "".
+func formatValue(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.CanInterface() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// ruleValue formats the value of f for the errors of rules. The values of
+// secret fields are redacted.
+func ruleValue(f *field) string {
+	if f.secret {
+		return RedactedValue
+	}
+	return fmt.Sprint(f.v.Interface())
+}
@@ -0,0 +1,256 @@
+package confucius
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseRules(t *testing.T) {
+	for _, tc := range []struct {
+		In   string
+		Want []rule
+	}{
+		{In: "", Want: nil},
+		{In: "required", Want: []rule{{name: "required"}}},
+		{In: "required_if=Mode production, min=1", Want: []rule{{name: "required_if", param: "Mode production"}, {name: "min", param: "1"}}},
+		{In: "expr=a=b", Want: []rule{{name: "expr", param: "a=b"}}},
+		{In: "min=1,warn:max=100", Want: []rule{{name: "min", param: "1"}, {name: "max", param: "100", warn: true}}},
+	} {
+		t.Run(tc.In, func(t *testing.T) {
+			got := parseRules(tc.In)
+			if !reflect.DeepEqual(tc.Want, got) {
+				t.Fatalf("want %+v, got %+v", tc.Want, got)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_RequiredIfUnless(t *testing.T) {
+	type Config struct {
+		Mode   string  `conf:"mode" default:"dev"`
+		Region string  `conf:"region"`
+		Cert   string  `conf:"cert" validate:"required_if=mode production"`
+		Key    *string `conf:"key" validate:"required_if=Mode production Region eu"`
+		Debug  int     `conf:"debug" validate:"required_unless=Mode production"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  string
+		wantErr []string
+	}{
+		{name: "dev", config: `{"debug": 1}`},
+		{name: "dev without debug", config: `{}`, wantErr: []string{"debug"}},
+		{name: "production", config: `{"mode": "production", "cert": "a.pem"}`},
+		{name: "production without cert", config: `{"mode": "production"}`, wantErr: []string{"cert"}},
+		{name: "production in eu", config: `{"mode": "production", "region": "eu", "cert": "a.pem"}`, wantErr: []string{"key"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := Load(&cfg, String(tc.config, DecoderJSON))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(fieldErrors)
+			if !ok || len(fieldErrs) != len(tc.wantErr) {
+				t.Fatalf("want errors for %v, got %v", tc.wantErr, err)
+			}
+			for _, path := range tc.wantErr {
+				if _, ok := fieldErrs[path]; !ok {
+					t.Errorf("want error for %s, got %v", path, err)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		var cfg struct {
+			Cert string `validate:"required_if=Mode production"`
+		}
+		err := Load(&cfg, String(`{}`, DecoderJSON))
+		if err == nil || !strings.Contains(err.Error(), "not found") {
+			t.Fatalf("expected not found err, got %v", err)
+		}
+	})
+}
+
+func Test_confucius_Load_RequiredWith(t *testing.T) {
+	type Credentials struct {
+		Username string `conf:"username" validate:"required_with=Password"`
+		Password string `conf:"password" validate:"required_with=Username" secret:"true"`
+	}
+	type Config struct {
+		Auth Credentials `conf:"auth"`
+		TLS  struct {
+			Cert string `conf:"cert" validate:"required_with=Key"`
+			Key  string `conf:"key" validate:"required_with=cert"`
+		} `conf:"tls"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  string
+		wantErr []string
+	}{
+		{name: "none set", config: `{}`},
+		{name: "all set", config: `{"auth": {"username": "u", "password": "p"}, "tls": {"cert": "c.pem", "key": "k.pem"}}`},
+		{name: "password without username", config: `{"auth": {"password": "p"}}`, wantErr: []string{"auth.username"}},
+		{name: "cert without key", config: `{"tls": {"cert": "c.pem"}}`, wantErr: []string{"tls.key"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := Load(&cfg, String(tc.config, DecoderJSON))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(fieldErrors)
+			if !ok || len(fieldErrs) != len(tc.wantErr) {
+				t.Fatalf("want errors for %v, got %v", tc.wantErr, err)
+			}
+			for _, path := range tc.wantErr {
+				if !errors.Is(fieldErrs[path], ErrRequired) {
+					t.Errorf("want required error for %s, got %v", path, err)
+				}
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_Unique(t *testing.T) {
+	type Listener struct {
+		Name string `conf:"name"`
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+	}
+	type Config struct {
+		Listeners []Listener  `conf:"listeners" validate:"unique=name"`
+		Backends  []*Listener `conf:"backends" validate:"unique=Host port"`
+		Hosts     []string    `conf:"hosts" validate:"unique"`
+	}
+
+	for _, tc := range []struct {
+		name    string
+		config  string
+		wantErr map[string]string
+	}{
+		{name: "empty", config: `{}`},
+		{
+			name:   "unique",
+			config: `{"listeners": [{"name": "a"}, {"name": "b"}], "backends": [{"host": "a", "port": 1}, {"host": "a", "port": 2}], "hosts": ["a", "b"]}`,
+		},
+		{
+			name:   "duplicates",
+			config: `{"listeners": [{"name": "a"}, {"name": "b"}, {"name": "a"}], "backends": [{"host": "a", "port": 1}, {"host": "a", "port": 1}], "hosts": ["a", "b", "b"]}`,
+			wantErr: map[string]string{
+				"listeners": `[0] and [2] have the same name "a"`,
+				"backends":  `[0] and [1] have the same Host port "a 1"`,
+				"hosts":     `[1] and [2] are both "b"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg Config
+			err := Load(&cfg, String(tc.config, DecoderJSON))
+			if len(tc.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				return
+			}
+
+			fieldErrs, ok := err.(fieldErrors)
+			if !ok || len(fieldErrs) != len(tc.wantErr) {
+				t.Fatalf("want errors for %v, got %v", tc.wantErr, err)
+			}
+			for path, want := range tc.wantErr {
+				var fieldErr *FieldError
+				if !errors.As(fieldErrs[path], &fieldErr) || fieldErr.Rule != "unique" || !strings.Contains(fieldErr.Error(), want) {
+					t.Errorf("want unique error %q for %s, got %v", want, path, fieldErrs[path])
+				}
+			}
+		})
+	}
+
+	t.Run("unknown field", func(t *testing.T) {
+		var cfg struct {
+			Listeners []struct{ Name string } `validate:"unique=Port"`
+		}
+		err := Load(&cfg, String(`{"listeners": [{"name": "a"}]}`, DecoderJSON))
+		if err == nil || !strings.Contains(err.Error(), `field "Port" not found`) {
+			t.Fatalf("expected not found err, got %v", err)
+		}
+	})
+}
+
+func Test_confucius_Load_WarnRules(t *testing.T) {
+	type Config struct {
+		Workers int    `conf:"workers" validate:"min=1,warn:max=64"`
+		Owner   string `conf:"owner" validate:"warn:required"`
+		Region  string `conf:"region" validate:"warn:oneof=eu us"`
+	}
+
+	var events []Event
+	var cfg Config
+	report, err := LoadWithReport(&cfg, String(`{"workers": 100, "region": "ap"}`, DecoderJSON), WithLogger(func(e Event) {
+		if e.Kind == EventWarning {
+			events = append(events, e)
+		}
+	}))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Workers != 100 {
+		t.Errorf("cfg.Workers == %d", cfg.Workers)
+	}
+
+	want := []string{"warn:max workers", "warn:required owner", "warn:oneof region"}
+	var got []string
+	for _, warning := range report.Warnings {
+		got = append(got, warning.Rule+" "+warning.Path)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("want warnings %v, got %v", want, got)
+	}
+	if !errors.Is(report.Warnings[1], ErrRequired) {
+		t.Errorf("want ErrRequired, got %v", report.Warnings[1])
+	}
+	if len(events) != len(want) || events[0].Path != "workers" {
+		t.Errorf("want %d warning events, got %+v", len(want), events)
+	}
+
+	err = Load(&cfg, String(`{"workers": 0}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || len(fieldErrs) != 1 || fieldErrs["workers"] == nil {
+		t.Fatalf("expected workers err, got %v", err)
+	}
+
+	var secretCfg struct {
+		Pass string `conf:"pass" secret:"true" validate:"warn:oneof=a b"`
+	}
+	events = nil
+	report, err = LoadWithReport(&secretCfg, String(`{"pass": "hunter2"}`, DecoderJSON), WithLogger(func(e Event) {
+		if e.Kind == EventWarning {
+			events = append(events, e)
+		}
+	}))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(report.Warnings) != 1 || len(events) != 1 {
+		t.Fatalf("want 1 warning, got %v and events %+v", report.Warnings, events)
+	}
+	for _, err := range []error{report.Warnings[0], events[0].Err} {
+		if got := err.Error(); strings.Contains(got, "hunter2") || !strings.Contains(got, RedactedValue) {
+			t.Errorf("warning == %q, expected the value to be redacted", got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package confucius
+
+import (
+	"reflect"
+)
+
+// Secret holds a sensitive value, e.g. a password or an API key. Fields of
+// type Secret are treated as if they were tagged with `secret:"true"`, and
+// the value itself never shows up when it is printed or encoded: String,
+// GoString and MarshalText all return `RedactedValue`, so `%+v` of a config
+// struct does not leak it.
+//
+//   type Config struct {
+//     Password confucius.Secret `conf:"password" validate:"required"`
+//   }
+//
+//   db.Connect(cfg.User, cfg.Password.Value())
+//   cfg.Password.Zero() // once the value is no longer needed
+//
+// The value is held in a byte slice that `Zero` overwrites. Copies of a
+// Secret share the same bytes, so zeroing one zeroes all of them. Strings
+// returned by `Value` are copies that cannot be zeroed.
+type Secret struct {
+	b []byte
+}
+
+var secretType = reflect.TypeOf(Secret{})
+
+// NewSecret returns a Secret that holds s.
+func NewSecret(s string) Secret {
+	var secret Secret
+	_ = secret.parse(s)
+	return secret
+}
+
+// Value returns the sensitive value.
+func (s Secret) Value() string {
+	return string(s.b)
+}
+
+// Bytes returns the sensitive value without copying it. The returned slice
+// must not be modified.
+func (s Secret) Bytes() []byte {
+	return s.b
+}
+
+// IsSet reports whether the secret holds a value.
+func (s Secret) IsSet() bool {
+	return len(s.b) > 0
+}
+
+// Zero overwrites the value with zeros and empties the secret.
+func (s *Secret) Zero() {
+	for i := range s.b {
+		s.b[i] = 0
+	}
+	s.b = nil
+}
+
+// String returns `RedactedValue`.
+func (s Secret) String() string {
+	return RedactedValue
+}
+
+// GoString returns `RedactedValue`, so that `%#v` does not print the value.
+func (s Secret) GoString() string {
+	return RedactedValue
+}
+
+// MarshalText returns `RedactedValue`, so that encoding a config, e.g. as
+// JSON for a log, does not reveal the value.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(RedactedValue), nil
+}
+
+func (s Secret) marshalValue() interface{} {
+	return RedactedValue
+}
+
+func (s *Secret) parse(val string) error {
+	s.b = nil
+	if val != "" {
+		s.b = []byte(val)
+	}
+	return nil
+}
+
+// isSecretType reports whether values of type t, or of the type t points
+// to, are secrets regardless of their tags.
+func isSecretType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == secretType
+}
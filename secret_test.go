@@ -0,0 +1,71 @@
+package confucius
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Secret(t *testing.T) {
+	type Config struct {
+		User     string   `conf:"user"`
+		Password Secret   `conf:"password" validate:"required"`
+		Token    *Secret  `conf:"token"`
+		Keys     []Secret `conf:"keys"`
+	}
+
+	setenv(t, "APP_TOKEN", "t0k3n")
+	defer os.Unsetenv("APP_TOKEN")
+
+	var cfg Config
+	err := Load(&cfg, String(`{"user": "admin", "password": "hunter2", "keys": ["k1"]}`, DecoderJSON), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Password.Value() != "hunter2" || cfg.Token == nil || cfg.Token.Value() != "t0k3n" || len(cfg.Keys) != 1 || cfg.Keys[0].Value() != "k1" {
+		t.Fatalf("unexpected cfg: %#v", cfg)
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		if s := fmt.Sprintf(format, cfg); strings.Contains(s, "hunter2") || strings.Contains(s, "k1") {
+			t.Errorf("%s: %s contains the secrets", format, s)
+		}
+	}
+	if b, _ := json.Marshal(cfg); strings.Contains(string(b), "hunter2") {
+		t.Errorf("json.Marshal() == %s, contains the secret", b)
+	}
+	var buf bytes.Buffer
+	if err := Dump(&cfg, DecoderYaml, &buf); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if strings.Contains(buf.String(), "hunter2") || strings.Contains(buf.String(), "t0k3n") {
+		t.Errorf("Dump() == %s, contains the secrets", buf.String())
+	}
+
+	report, err := LoadWithReport(&Config{}, String(`{"password": "hunter2"}`, DecoderJSON), WithLogger(func(e Event) {
+		if strings.Contains(e.Value, "hunter2") || strings.Contains(e.Value, "t0k3n") {
+			t.Errorf("event %+v contains the secret", e)
+		}
+	}), UseEnv("app"))
+	if err != nil || report == nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	b := cfg.Password.Bytes()
+	cfg.Password.Zero()
+	if cfg.Password.IsSet() || !bytes.Equal(b, make([]byte, len(b))) {
+		t.Errorf("Zero() left %q", b)
+	}
+
+	err = Load(&Config{}, String(`{"password": ""}`, DecoderJSON))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["password"] == nil {
+		t.Errorf("err == %v, expected password to be required", err)
+	}
+
+	if s := NewSecret("x"); s.Value() != "x" || s.String() != RedactedValue {
+		t.Errorf("NewSecret() == %q", s.Value())
+	}
+}
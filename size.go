@@ -0,0 +1,113 @@
+package confucius
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Size is a number of bytes. It can be set from a plain number of bytes
+// (`1048576`) or from a human-readable size with a decimal (`KB`, `MB`,
+// `GB`, `TB`, `PB`) or a binary (`KiB`, `MiB`, `GiB`, `TiB`, `PiB`) unit,
+// e.g. `512KiB`, `10MB` or `1.5GiB`. Units are case-insensitive and `B`
+// may be left out, so `10M` is the same as `10MB`.
+//
+//   type Config struct {
+//     MaxBodySize confucius.Size `conf:"max_body_size" default:"10MB"`
+//   }
+type Size uint64
+
+// The sizes of the units that a Size can be set from.
+const (
+	Byte Size = 1
+
+	KB = 1000 * Byte
+	MB = 1000 * KB
+	GB = 1000 * MB
+	TB = 1000 * GB
+	PB = 1000 * TB
+
+	KiB = 1024 * Byte
+	MiB = 1024 * KiB
+	GiB = 1024 * MiB
+	TiB = 1024 * GiB
+	PiB = 1024 * TiB
+)
+
+// sizeUnits are the units of a Size from the largest to the smallest.
+var sizeUnits = []struct {
+	name string
+	size Size
+}{
+	{"PiB", PiB}, {"PB", PB},
+	{"TiB", TiB}, {"TB", TB},
+	{"GiB", GiB}, {"GB", GB},
+	{"MiB", MiB}, {"MB", MB},
+	{"KiB", KiB}, {"KB", KB},
+	{"B", Byte},
+}
+
+// Bytes returns the size as a number of bytes.
+func (s Size) Bytes() uint64 {
+	return uint64(s)
+}
+
+// String formats the size with the largest unit that it is a whole number
+// of, e.g. `10MB`, `1GiB` or `1500B`.
+func (s Size) String() string {
+	for _, u := range sizeUnits {
+		if s != 0 && s%u.size == 0 {
+			return strconv.FormatUint(uint64(s/u.size), 10) + u.name
+		}
+	}
+	return "0B"
+}
+
+// marshalValue returns the size in the human-readable form it is set from.
+func (s Size) marshalValue() interface{} {
+	return s.String()
+}
+
+func (s *Size) parse(str string) error {
+	trimmed := strings.TrimSpace(str)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '+' && r != 'e' && r != 'E'
+	})
+	// a unit starting with e, like in 1EB, is not supported.
+	number, unit := trimmed, ""
+	if i != -1 {
+		number, unit = trimmed[:i], strings.TrimSpace(trimmed[i:])
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil || f < 0 {
+		return fmt.Errorf("invalid size %q", str)
+	}
+
+	multiplier, ok := parseSizeUnit(unit)
+	if !ok {
+		return fmt.Errorf("invalid size %q: unknown unit %q", str, unit)
+	}
+
+	bytes := f * float64(multiplier)
+	if bytes >= math.MaxUint64 {
+		return fmt.Errorf("size %q out of range", str)
+	}
+	*s = Size(bytes)
+	return nil
+}
+
+// parseSizeUnit returns the size of the unit with the given name. An empty
+// name is a number of bytes.
+func parseSizeUnit(name string) (Size, bool) {
+	if name == "" {
+		return Byte, true
+	}
+	for _, u := range sizeUnits {
+		if strings.EqualFold(name, u.name) || (u.size != Byte && strings.EqualFold(name, strings.TrimSuffix(u.name, "B"))) {
+			return u.size, true
+		}
+	}
+	return 0, false
+}
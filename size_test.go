@@ -0,0 +1,89 @@
+package confucius
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSize_parse(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    Size
+		wantErr bool
+	}{
+		{in: "1048576", want: MiB},
+		{in: "512KiB", want: 512 * KiB},
+		{in: "10MB", want: 10 * MB},
+		{in: "10 mb", want: 10 * MB},
+		{in: "10M", want: 10 * MB},
+		{in: "1.5GiB", want: 1536 * MiB},
+		{in: "2Ti", want: 2 * TiB},
+		{in: "0", want: 0},
+		{in: "1e+07", want: 10 * MB},
+		{in: "-1KB", wantErr: true},
+		{in: "10XB", wantErr: true},
+		{in: "big", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			var s Size
+			err := s.parse(tc.in)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if s != tc.want {
+				t.Errorf("want %d, got %d", tc.want, s)
+			}
+		})
+	}
+}
+
+func TestSize_String(t *testing.T) {
+	for s, want := range map[Size]string{
+		0:           "0B",
+		1500:        "1500B",
+		10 * MB:     "10MB",
+		GiB:         "1GiB",
+		1536 * MiB:  "1536MiB",
+		3 * KB:      "3KB",
+		1024 * 1000: "1000KiB",
+	} {
+		if got := s.String(); got != want {
+			t.Errorf("%d: want %q, got %q", uint64(s), want, got)
+		}
+	}
+}
+
+func Test_confucius_Load_Size(t *testing.T) {
+	type Config struct {
+		MaxBody  Size   `conf:"max_body"`
+		Buffer   Size   `conf:"buffer" default:"64KiB"`
+		Limit    *Size  `conf:"limit"`
+		Segments []Size `conf:"segments"`
+	}
+
+	setenv(t, "APP_LIMIT", "1GiB")
+	defer os.Unsetenv("APP_LIMIT")
+
+	var cfg Config
+	err := Load(&cfg, String(`{"max_body": "10MB", "segments": [1024, "1MiB"]}`, DecoderJSON), UseEnv("app"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.MaxBody != 10*MB || cfg.Buffer != 64*KiB || cfg.Limit == nil || *cfg.Limit != GiB {
+		t.Errorf("unexpected cfg: %+v", cfg)
+	}
+	if len(cfg.Segments) != 2 || cfg.Segments[0] != KiB || cfg.Segments[1] != MiB {
+		t.Errorf("unexpected segments: %v", cfg.Segments)
+	}
+
+	err = Load(&Config{}, String(`{"max_body": "10 parsecs"}`, DecoderJSON))
+	if err == nil {
+		t.Fatalf("expected err")
+	}
+}
@@ -0,0 +1,309 @@
+package confucius
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// skeletonNode is a field in the skeleton of a config.
+type skeletonNode struct {
+	name     string
+	comment  string          // the rules and default of the field, if any.
+	value    interface{}     // the example value of a field that holds a single value.
+	children []*skeletonNode // the fields of a struct, or of the element of a list of structs.
+	list     bool            // true if the field is a list of structs.
+}
+
+// Skeleton generates an example config for the struct type of cfg in the
+// given format. Every field is listed with its default value, or its zero
+// value if it has none, and is commented with its validation rules and
+// default so that required fields stand out.
+//
+//   b, err := confucius.Skeleton(&Config{}, confucius.DecoderYaml)
+//
+// The output for the struct of `Load` starts with:
+//
+//   # required
+//   env: ""
+//
+// Fields of `Enum` types are commented with their values as a `oneof` rule.
+// Lists of structs hold a single example element. JSON has no comments, so
+// a JSON skeleton only holds the values. The parameter `cfg` must be a
+// pointer to a struct; only its type is used.
+func Skeleton(cfg interface{}, format Decoder, options ...Option) ([]byte, error) {
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if !isStructPtr(cfg) {
+		return nil, fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	root, err := c.skeletonFields(reflect.TypeOf(cfg).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case DecoderYaml, DecoderYml:
+		err = writeYamlSkeleton(&buf, root, "")
+	case DecoderJSON, DecoderJSONC:
+		err = writeJSONSkeleton(&buf, root, "")
+		buf.WriteString("\n")
+	case DecoderToml:
+		err = writeTomlSkeleton(&buf, root, "")
+	default:
+		err = fmt.Errorf("%w %s", ErrUnsupportedExtension, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// skeletonFields returns the skeleton of the fields of the struct type t.
+func (c *confucius) skeletonFields(t reflect.Type) ([]*skeletonNode, error) {
+	var nodes []*skeletonNode
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if isSquashed(sf) {
+			embedded, err := c.skeletonFields(ft)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, embedded...)
+			continue
+		}
+
+		st := parseTag(sf.Tag, c.tag)
+		if c.legacyTags {
+			st.applyLegacy()
+		}
+		st.secret = st.secret || isSecretType(sf.Type)
+		node := &skeletonNode{name: sf.Name}
+		if st.altName != "" {
+			node.name = st.altName
+		}
+		rules := explainRules(sf.Type, st)
+		if st.secret {
+			rules = append(rules, "secret")
+		}
+		node.comment = strings.Join(rules, ", ")
+
+		elem := ft
+		if elem.Kind() == reflect.Slice || elem.Kind() == reflect.Array {
+			elem = elem.Elem()
+			for elem.Kind() == reflect.Ptr {
+				elem = elem.Elem()
+			}
+		}
+		var err error
+		switch {
+		case ft.Kind() == reflect.Struct && !isLeafStruct(ft):
+			node.children, err = c.skeletonFields(ft)
+		case ft != elem && elem.Kind() == reflect.Struct && !isLeafStruct(elem) && !st.setDefault:
+			node.list = true
+			node.children, err = c.skeletonFields(elem)
+		default:
+			node.value, err = c.skeletonValue(ft, st)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", node.name, err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// skeletonValue returns the example value of a field of type t, which is
+// its default or its zero value, as written to config files.
+func (c *confucius) skeletonValue(t reflect.Type, st structTag) (interface{}, error) {
+	if isSecretType(t) {
+		return "", nil
+	}
+
+	v := reflect.New(t).Elem()
+	if st.setDefault && !st.secret {
+		if err := c.setDefaultValue(v, st.defaultVal, st); err != nil {
+			return nil, fmt.Errorf("unable to set default: %v", err)
+		}
+	}
+
+	val, ok := c.newValueEncoder().encode(v, "")
+	switch {
+	case ok:
+		return val, nil
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return []interface{}{}, nil
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{}, nil
+	default:
+		return "", nil
+	}
+}
+
+func writeYamlSkeleton(buf *bytes.Buffer, nodes []*skeletonNode, indent string) error {
+	for i, node := range nodes {
+		prefix := indent
+		if i > 0 && strings.HasSuffix(indent, "- ") {
+			prefix = strings.Repeat(" ", len(indent))
+		}
+		if node.comment != "" {
+			fmt.Fprintf(buf, "%s# %s\n", prefix, node.comment)
+			prefix = strings.Repeat(" ", len(indent))
+		}
+		childIndent := strings.Repeat(" ", len(indent)) + "  "
+
+		switch {
+		case node.children != nil && node.list:
+			fmt.Fprintf(buf, "%s%s:\n", prefix, node.name)
+			if err := writeYamlSkeleton(buf, node.children, childIndent+"- "); err != nil {
+				return err
+			}
+		case node.children != nil:
+			fmt.Fprintf(buf, "%s%s:\n", prefix, node.name)
+			if err := writeYamlSkeleton(buf, node.children, childIndent); err != nil {
+				return err
+			}
+		default:
+			val, err := yamlScalar(node.value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "%s%s: %s\n", prefix, node.name, val)
+		}
+	}
+	return nil
+}
+
+// yamlScalar formats val as a YAML value on a single line. Lists and maps
+// are written in flow style.
+func yamlScalar(val interface{}) (string, error) {
+	switch val.(type) {
+	case []interface{}, map[string]interface{}:
+		return jsonValue(val)
+	}
+	b, err := yaml.Marshal(val)
+	return strings.TrimSuffix(string(b), "\n"), err
+}
+
+func writeJSONSkeleton(buf *bytes.Buffer, nodes []*skeletonNode, indent string) error {
+	buf.WriteString("{\n")
+	for i, node := range nodes {
+		name, _ := json.Marshal(node.name)
+		fmt.Fprintf(buf, "%s  %s: ", indent, name)
+		switch {
+		case node.children != nil && node.list:
+			fmt.Fprintf(buf, "[\n%s    ", indent)
+			if err := writeJSONSkeleton(buf, node.children, indent+"    "); err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "\n%s  ]", indent)
+		case node.children != nil:
+			if err := writeJSONSkeleton(buf, node.children, indent+"  "); err != nil {
+				return err
+			}
+		default:
+			val, err := jsonValue(node.value)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(val)
+		}
+		if i < len(nodes)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	fmt.Fprintf(buf, "%s}", indent)
+	return nil
+}
+
+// jsonValue formats val as JSON on a single line, which is also valid YAML
+// and, for scalars and lists of scalars, valid TOML.
+func jsonValue(val interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(val); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// writeTomlSkeleton writes the fields that hold values first and then the
+// tables of the nested structs and maps, as TOML requires. path is the name
+// of the table of nodes.
+func writeTomlSkeleton(buf *bytes.Buffer, nodes []*skeletonNode, path string) error {
+	isTable := func(node *skeletonNode) bool {
+		_, isMap := node.value.(map[string]interface{})
+		return node.children != nil || isMap
+	}
+
+	for _, node := range nodes {
+		if isTable(node) {
+			continue
+		}
+		if node.comment != "" {
+			fmt.Fprintf(buf, "# %s\n", node.comment)
+		}
+		val, err := jsonValue(node.value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s = %s\n", node.name, val)
+	}
+
+	for _, node := range nodes {
+		if !isTable(node) {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		if node.comment != "" {
+			fmt.Fprintf(buf, "# %s\n", node.comment)
+		}
+		table := joinPath(path, node.name)
+		if node.list {
+			fmt.Fprintf(buf, "[[%s]]\n", table)
+		} else {
+			fmt.Fprintf(buf, "[%s]\n", table)
+		}
+		if m, ok := node.value.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(m))
+			for key := range m {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				val, err := jsonValue(m[key])
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(buf, "%s = %s\n", key, val)
+			}
+			continue
+		}
+		if err := writeTomlSkeleton(buf, node.children, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package confucius
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Skeleton(t *testing.T) {
+	type Config struct {
+		Env     string        `conf:"env" validate:"required"`
+		Timeout time.Duration `conf:"timeout" default:"5s"`
+		Token   string        `conf:"token,secret" default:"t0k3n"`
+		Tags    []string      `conf:"tags" default:"[a,b]"`
+		Server  struct {
+			Host   string         `conf:"host" default:"localhost"`
+			Limits map[string]int `conf:"limits"`
+		} `conf:"server"`
+		Backends []struct {
+			Name   string `conf:"name" validate:"required"`
+			Weight int    `conf:"weight" default:"1"`
+		} `conf:"backends"`
+	}
+
+	b, err := Skeleton(&Config{}, DecoderYaml)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := `# required
+env: ""
+# default=5s
+timeout: 5s
+# default=t0k3n, secret
+token: ""
+# default=[a,b]
+tags: ["a","b"]
+server:
+  # default=localhost
+  host: localhost
+  limits: {}
+backends:
+  - # required
+    name: ""
+    # default=1
+    weight: 1
+`
+	if string(b) != want {
+		t.Errorf("Skeleton() == %q, expected %q", b, want)
+	}
+
+	for _, format := range []Decoder{DecoderYaml, DecoderJSON, DecoderToml} {
+		b, err := Skeleton(&Config{}, format)
+		if err != nil {
+			t.Fatalf("%s: unexpected err: %v", format, err)
+		}
+
+		var cfg Config
+		err = Load(&cfg, String(string(b), format))
+		fieldErrs, ok := err.(fieldErrors)
+		if !ok || len(fieldErrs) != 2 || fieldErrs["env"] == nil || fieldErrs["backends[0].name"] == nil {
+			t.Fatalf("%s: expected the required fields to fail, got %v", format, err)
+		}
+		if cfg.Timeout != 5*time.Second || cfg.Server.Host != "localhost" || len(cfg.Backends) != 1 || cfg.Backends[0].Weight != 1 {
+			t.Errorf("%s: cfg == %+v", format, cfg)
+		}
+	}
+
+	if _, err := Skeleton(&Config{}, Decoder(".ini")); !errors.Is(err, ErrUnsupportedExtension) {
+		t.Errorf("err == %v, expected ErrUnsupportedExtension", err)
+	}
+}
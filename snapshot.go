@@ -0,0 +1,84 @@
+package confucius
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format written by Snapshot.
+const SnapshotVersion = 1
+
+// snapshot is the serialized form of a config written by Snapshot.
+type snapshot struct {
+	Version  int                    `json:"version"`
+	Type     string                 `json:"type"`
+	Taken    time.Time              `json:"taken"`
+	Redacted []string               `json:"redacted,omitempty"`
+	Values   map[string]interface{} `json:"values"`
+}
+
+// Snapshot serializes a loaded config into a compact, versioned JSON
+// document that is suitable for attaching to crash reports and support
+// bundles. The parameter `cfg` must be a pointer to a struct.
+//
+//   b, err := confucius.Snapshot(&cfg)
+//
+// Fields are keyed by the names used in config files. Secret fields (see
+// the `secret` tag) are left out of the snapshot and only their paths are
+// recorded. Use `RestoreSnapshot` to load a snapshot back into a struct.
+func Snapshot(cfg interface{}, options ...Option) ([]byte, error) {
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if !isStructPtr(cfg) {
+		return nil, fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	e := c.newValueEncoder()
+	values, _ := e.encode(reflect.ValueOf(cfg), "")
+
+	return json.Marshal(snapshot{
+		Version:  SnapshotVersion,
+		Type:     reflect.TypeOf(cfg).Elem().String(),
+		Taken:    time.Now().UTC(),
+		Redacted: e.redacted,
+		Values:   values.(map[string]interface{}),
+	})
+}
+
+// RestoreSnapshot loads a snapshot written by `Snapshot` into cfg, which
+// must be a pointer to a struct. Secret fields that were redacted from the
+// snapshot are left untouched. Neither defaults nor validations are
+// applied, so cfg holds exactly the values of the snapshot.
+func RestoreSnapshot(data []byte, cfg interface{}, options ...Option) error {
+	c := defaultConfucius()
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if !isStructPtr(cfg) {
+		return fmt.Errorf("cfg must be a pointer to a struct")
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+	if t := reflect.TypeOf(cfg).Elem().String(); s.Type != t {
+		c.logger.Warn("restoring snapshot of %s into %s", s.Type, t)
+	}
+
+	dec, err := c.newDecoder(cfg, false)
+	if err != nil {
+		return err
+	}
+	c.nestSquashed(s.Values, reflect.TypeOf(cfg), "", nil)
+	return dec.Decode(s.Values)
+}
@@ -0,0 +1,97 @@
+package confucius
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Snapshot(t *testing.T) {
+	type Database struct {
+		Host     string `conf:"host"`
+		Password string `conf:"password" secret:"true"`
+		Token    string `conf:"token,secret"`
+	}
+	type Config struct {
+		Name     string            `conf:"name"`
+		Started  time.Time         `conf:"started"`
+		Timeout  time.Duration     `conf:"timeout"`
+		Ports    []int             `conf:"ports"`
+		Labels   map[string]string `conf:"labels"`
+		Database *Database         `conf:"database"`
+		Optional *Database         `conf:"optional"`
+	}
+
+	cfg := Config{
+		Name:     "api",
+		Started:  time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+		Timeout:  5 * time.Second,
+		Ports:    []int{80, 443},
+		Labels:   map[string]string{"team": "core"},
+		Database: &Database{Host: "db", Password: "hunter2", Token: "t0k3n"},
+	}
+
+	b, err := Snapshot(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if strings.Contains(string(b), "hunter2") || strings.Contains(string(b), "t0k3n") {
+		t.Fatalf("snapshot contains secrets: %s", b)
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if s.Version != SnapshotVersion {
+		t.Errorf("s.Version == %d, expected %d", s.Version, SnapshotVersion)
+	}
+	if want := []string{"database.password", "database.token"}; !reflect.DeepEqual(want, s.Redacted) {
+		t.Errorf("s.Redacted == %v, expected %v", s.Redacted, want)
+	}
+
+	var got Config
+	if err := RestoreSnapshot(b, &got); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := cfg
+	want.Database = &Database{Host: "db"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("\nwant %+v\ngot %+v", want, got)
+	}
+}
+
+func Test_RestoreSnapshot(t *testing.T) {
+	var cfg struct {
+		Name string `conf:"name"`
+	}
+
+	for _, tc := range []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "invalid json", data: "{", err: "invalid snapshot"},
+		{name: "unsupported version", data: `{"version": 99, "values": {}}`, err: "unsupported snapshot version"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := RestoreSnapshot([]byte(tc.data), &cfg)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected %q err, got %v", tc.err, err)
+			}
+		})
+	}
+
+	t.Run("placeholders are not expanded", func(t *testing.T) {
+		err := RestoreSnapshot([]byte(`{"version": 1, "values": {"name": "${HOME}"}}`), &cfg)
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Name != "${HOME}" {
+			t.Errorf("cfg.Name == %q, expected %q", cfg.Name, "${HOME}")
+		}
+	})
+}
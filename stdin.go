@@ -0,0 +1,59 @@
+package confucius
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// stdin is read by the Stdin option, a variable so that tests can replace it.
+var stdin io.Reader = os.Stdin
+
+// Stdin returns an option that configures from the standard input, decoded
+// with decoder, like `Reader` does, so that the configuration can be piped
+// into the process:
+//
+//   cat config.yaml | myapp -
+//
+//   confucius.Load(&cfg, confucius.Stdin(confucius.DecoderYaml), confucius.UseEnv("myapp"))
+//
+// The standard input is read until EOF when the option is first used to
+// load, and the same input is decoded by every later load, e.g. with a
+// `Loader`.
+func Stdin(decoder Decoder) Option {
+	input := &cachedInput{r: stdin}
+	return func(c *confucius) {
+		Reader(&cachedReader{input: input}, decoder)(c)
+	}
+}
+
+// cachedInput holds all of r once it was read.
+type cachedInput struct {
+	r    io.Reader
+	once sync.Once
+	b    []byte
+	err  error
+}
+
+// cachedReader reads the data of its input, which is read from the
+// underlying reader on the first read of any of its readers.
+type cachedReader struct {
+	input *cachedInput
+	r     io.Reader
+}
+
+func (cr *cachedReader) Read(p []byte) (int, error) {
+	if cr.r == nil {
+		in := cr.input
+		in.once.Do(func() {
+			in.b, in.err = ioutil.ReadAll(in.r)
+		})
+		if in.err != nil {
+			return 0, in.err
+		}
+		cr.r = bytes.NewReader(in.b)
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,47 @@
+package confucius
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_confucius_Load_Stdin(t *testing.T) {
+	type Config struct {
+		Host string `conf:"host"`
+		Port int    `conf:"port"`
+	}
+
+	defer func(r io.Reader) { stdin = r }(stdin)
+	stdin = strings.NewReader("host: example.com\nport: 80\n")
+
+	setenv(t, "APP_PORT", "8080")
+	defer os.Unsetenv("APP_PORT")
+
+	loader := NewLoader(Stdin(DecoderYaml), UseEnv("app"))
+	for i := 0; i < 2; i++ {
+		var cfg Config
+		if err := loader.Load(&cfg); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if want := (Config{Host: "example.com", Port: 8080}); cfg != want {
+			t.Errorf("load %d: cfg == %+v, expected %+v", i, cfg, want)
+		}
+	}
+
+	stdin = &failingReader{}
+	var cfg Config
+	if err := Load(&cfg, Stdin(DecoderYaml)); !errors.Is(err, errRead) {
+		t.Errorf("err == %v, expected %v", err, errRead)
+	}
+}
+
+var errRead = errors.New("read failed")
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errRead
+}
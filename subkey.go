@@ -0,0 +1,64 @@
+package confucius
+
+import (
+	"fmt"
+	"strings"
+)
+
+// subValues returns the values under the key at path in vals, e.g. the
+// values of the section `server` for the path "server". Nested keys are
+// separated by dots. An absent key has no values.
+func subValues(vals decodedObject, path string) (decodedObject, error) {
+	var cur interface{} = vals
+	for _, key := range strings.Split(path, ".") {
+		var next interface{}
+		switch m := cur.(type) {
+		case decodedObject:
+			next = m[key]
+		case map[string]interface{}:
+			next = m[key]
+		case map[interface{}]interface{}:
+			next = m[key]
+		default:
+			return nil, fmt.Errorf("sub key %s: %s is not a map", path, key)
+		}
+		if next == nil {
+			return decodedObject{}, nil
+		}
+		cur = next
+	}
+
+	switch m := cur.(type) {
+	case decodedObject:
+		return m, nil
+	case map[string]interface{}:
+		return m, nil
+	case map[interface{}]interface{}:
+		sub := make(decodedObject, len(m))
+		for key, val := range m {
+			sub[fmt.Sprint(key)] = val
+		}
+		return sub, nil
+	}
+	return nil, fmt.Errorf("sub key %s is not a map", path)
+}
+
+// selectSubKey replaces vals, and the values of each source if sources are
+// tracked, by their values under the sub key. References are resolved
+// beforehand, so that the section may refer to values outside of it.
+func (c *confucius) selectSubKey(vals decodedObject) (decodedObject, error) {
+	if !c.disableExpansion {
+		if err := resolveReferences(vals); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range c.sourceVals {
+		sub, err := subValues(c.sourceVals[i].vals, c.subKey)
+		if err != nil {
+			return nil, err
+		}
+		c.sourceVals[i].vals = sub
+	}
+	return subValues(vals, c.subKey)
+}
@@ -0,0 +1,49 @@
+package confucius
+
+import (
+	"os"
+	"testing"
+)
+
+func Test_confucius_Load_SubKey(t *testing.T) {
+	type ServerConfig struct {
+		Host    string `conf:"host" validate:"required"`
+		Port    int    `conf:"port" default:"80"`
+		Name    string `conf:"name"`
+		Timeout string `conf:"timeout" default:"5s"`
+	}
+
+	content := `
+app:
+  name: shop
+services:
+  server:
+    host: localhost
+    name: ${app.name}-server
+  worker: none
+`
+	setenv(t, "APP_SERVICES_SERVER_PORT", "8080")
+	defer os.Unsetenv("APP_SERVICES_SERVER_PORT")
+
+	var cfg ServerConfig
+	if err := Load(&cfg, String(content, DecoderYaml), SubKey("services.server"), UseEnv("app")); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	want := ServerConfig{Host: "localhost", Port: 8080, Name: "shop-server", Timeout: "5s"}
+	if cfg != want {
+		t.Errorf("cfg == %+v, expected %+v", cfg, want)
+	}
+
+	cfg = ServerConfig{}
+	err := Load(&cfg, String(content, DecoderYaml), SubKey("services.missing"))
+	if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["host"] == nil {
+		t.Fatalf("expected host err, got %v", err)
+	}
+	if cfg.Port != 80 {
+		t.Errorf("cfg.Port == %d, expected the default", cfg.Port)
+	}
+
+	if err := Load(&cfg, String(content, DecoderYaml), SubKey("services.worker")); err == nil {
+		t.Errorf("expected err for a section that is not a map")
+	}
+}
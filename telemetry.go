@@ -0,0 +1,175 @@
+package confucius
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Telemetry protocols of OTLP exporters.
+const (
+	TelemetryProtocolGRPC         = "grpc"
+	TelemetryProtocolHTTPProtobuf = "http/protobuf"
+	TelemetryProtocolHTTPJSON     = "http/json"
+)
+
+// Telemetry is a config section for an OpenTelemetry OTLP exporter.
+//
+//   type Config struct {
+//     Telemetry confucius.Telemetry `conf:"telemetry"`
+//   }
+//
+//   telemetry:
+//     service_name: checkout
+//     endpoint: "https://otel-collector:4317"
+//     protocol: grpc
+//     headers:
+//       api-key: "${OTLP_API_KEY}"
+//     sampling_ratio: 10%
+//     resource_attributes:
+//       deployment.environment: prod
+//
+// The settings correspond to the variables of the OpenTelemetry environment
+// specification (OTEL_SERVICE_NAME, OTEL_EXPORTER_OTLP_ENDPOINT and so on).
+// `Resolved()` falls back to these variables for settings that are not set,
+// and `Environ()` exports the settings as these variables. Headers are
+// treated as secrets.
+type Telemetry struct {
+	ServiceName        string            `conf:"service_name"`
+	Endpoint           string            `conf:"endpoint"`
+	Protocol           string            `conf:"protocol"`
+	Headers            map[string]string `conf:"headers,secret"`
+	SamplingRatio      *Percent          `conf:"sampling_ratio"`
+	ResourceAttributes map[string]string `conf:"resource_attributes"`
+}
+
+// Validate checks that the endpoint is a URL and that the protocol is one of
+// grpc, http/protobuf and http/json.
+func (t Telemetry) Validate() error {
+	if t.Endpoint != "" {
+		u, err := url.Parse(t.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid endpoint %q", t.Endpoint)
+		}
+	}
+	switch t.Protocol {
+	case "", TelemetryProtocolGRPC, TelemetryProtocolHTTPProtobuf, TelemetryProtocolHTTPJSON:
+	default:
+		return fmt.Errorf("unknown protocol %q", t.Protocol)
+	}
+	if t.SamplingRatio != nil && (*t.SamplingRatio < 0 || *t.SamplingRatio > 1) {
+		return fmt.Errorf("sampling ratio out of range [0%%, 100%%]")
+	}
+	return nil
+}
+
+// Ratio returns the sampling ratio, which is 1 when it is not set.
+func (t Telemetry) Ratio() float64 {
+	if t.SamplingRatio == nil {
+		return 1
+	}
+	return t.SamplingRatio.Float()
+}
+
+// Resolved returns a copy of t where the settings that are not set are taken
+// from the OTEL_* environment variables. Headers and resource attributes
+// from the environment are merged, with the ones of t taking precedence.
+func (t Telemetry) Resolved() (Telemetry, error) {
+	if t.ServiceName == "" {
+		t.ServiceName = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if t.Endpoint == "" {
+		t.Endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if t.Protocol == "" {
+		t.Protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	if t.SamplingRatio == nil {
+		if val, ok := os.LookupEnv("OTEL_TRACES_SAMPLER_ARG"); ok && val != "" {
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil || f < 0 || f > 1 {
+				return t, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG: invalid sampling ratio %q", val)
+			}
+			ratio := Percent(f)
+			t.SamplingRatio = &ratio
+		}
+	}
+
+	var err error
+	if t.Headers, err = mergeTelemetryList(t.Headers, "OTEL_EXPORTER_OTLP_HEADERS"); err != nil {
+		return t, err
+	}
+	if t.ResourceAttributes, err = mergeTelemetryList(t.ResourceAttributes, "OTEL_RESOURCE_ATTRIBUTES"); err != nil {
+		return t, err
+	}
+	return t, t.Validate()
+}
+
+// Environ returns the settings that are set as OTEL_* environment variables
+// in the form "key=value", e.g. to configure an OpenTelemetry SDK or a child
+// process.
+func (t Telemetry) Environ() []string {
+	var env []string
+	add := func(key, val string) {
+		if val != "" {
+			env = append(env, key+"="+val)
+		}
+	}
+	add("OTEL_SERVICE_NAME", t.ServiceName)
+	add("OTEL_EXPORTER_OTLP_ENDPOINT", t.Endpoint)
+	add("OTEL_EXPORTER_OTLP_PROTOCOL", t.Protocol)
+	add("OTEL_EXPORTER_OTLP_HEADERS", formatTelemetryList(t.Headers))
+	if t.SamplingRatio != nil {
+		add("OTEL_TRACES_SAMPLER", "parentbased_traceidratio")
+		add("OTEL_TRACES_SAMPLER_ARG", strconv.FormatFloat(t.SamplingRatio.Float(), 'f', -1, 64))
+	}
+	add("OTEL_RESOURCE_ATTRIBUTES", formatTelemetryList(t.ResourceAttributes))
+	return env
+}
+
+// mergeTelemetryList adds the entries of the list in the environment
+// variable key to m, unless m already contains them.
+func mergeTelemetryList(m map[string]string, key string) (map[string]string, error) {
+	val := os.Getenv(key)
+	if val == "" {
+		return m, nil
+	}
+
+	merged := make(map[string]string, len(m))
+	for _, pair := range strings.Split(val, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		i := strings.Index(pair, "=")
+		if i == -1 {
+			return m, fmt.Errorf("%s: invalid entry %q", key, pair)
+		}
+		name, err := url.PathUnescape(strings.TrimSpace(pair[:i]))
+		if err != nil {
+			return m, fmt.Errorf("%s: invalid entry %q", key, pair)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(pair[i+1:]))
+		if err != nil {
+			return m, fmt.Errorf("%s: invalid entry %q", key, pair)
+		}
+		merged[name] = value
+	}
+	for name, value := range m {
+		merged[name] = value
+	}
+	return merged, nil
+}
+
+// formatTelemetryList formats m as a list of percent-encoded key=value pairs
+// sorted by key.
+func formatTelemetryList(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for name, value := range m {
+		pairs = append(pairs, url.PathEscape(name)+"="+url.PathEscape(value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,105 @@
+package confucius
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestTelemetry_Resolved(t *testing.T) {
+	os.Clearenv()
+	defer os.Clearenv()
+	setenv(t, "OTEL_SERVICE_NAME", "env-service")
+	setenv(t, "OTEL_EXPORTER_OTLP_ENDPOINT", "http://env-collector:4318")
+	setenv(t, "OTEL_EXPORTER_OTLP_HEADERS", "api-key=env%20key,tenant=acme")
+	setenv(t, "OTEL_TRACES_SAMPLER_ARG", "0.25")
+	setenv(t, "OTEL_RESOURCE_ATTRIBUTES", "deployment.environment=prod")
+
+	tel := Telemetry{
+		Endpoint: "https://collector:4317",
+		Protocol: TelemetryProtocolGRPC,
+		Headers:  map[string]string{"api-key": "secret"},
+	}
+	got, err := tel.Resolved()
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	ratio := Percent(0.25)
+	want := Telemetry{
+		ServiceName:        "env-service",
+		Endpoint:           "https://collector:4317",
+		Protocol:           TelemetryProtocolGRPC,
+		Headers:            map[string]string{"api-key": "secret", "tenant": "acme"},
+		SamplingRatio:      &ratio,
+		ResourceAttributes: map[string]string{"deployment.environment": "prod"},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+	if tel.Headers["tenant"] != "" {
+		t.Errorf("expected Resolved not to modify the receiver")
+	}
+
+	setenv(t, "OTEL_TRACES_SAMPLER_ARG", "2")
+	if _, err := (Telemetry{}).Resolved(); err == nil {
+		t.Errorf("expected err for invalid sampler arg")
+	}
+}
+
+func TestTelemetry_Environ(t *testing.T) {
+	ratio := Percent(0.1)
+	tel := Telemetry{
+		ServiceName:        "checkout",
+		Endpoint:           "https://collector:4317",
+		Headers:            map[string]string{"b": "2", "a": "x y"},
+		SamplingRatio:      &ratio,
+		ResourceAttributes: map[string]string{"team": "payments"},
+	}
+	want := []string{
+		"OTEL_SERVICE_NAME=checkout",
+		"OTEL_EXPORTER_OTLP_ENDPOINT=https://collector:4317",
+		"OTEL_EXPORTER_OTLP_HEADERS=a=x%20y,b=2",
+		"OTEL_TRACES_SAMPLER=parentbased_traceidratio",
+		"OTEL_TRACES_SAMPLER_ARG=0.1",
+		"OTEL_RESOURCE_ATTRIBUTES=team=payments",
+	}
+	if got := tel.Environ(); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if (Telemetry{}).Ratio() != 1 || tel.Ratio() != 0.1 {
+		t.Errorf("unexpected ratio")
+	}
+}
+
+func Test_confucius_Load_Telemetry(t *testing.T) {
+	type Config struct {
+		Telemetry Telemetry `conf:"telemetry"`
+	}
+
+	var cfg Config
+	err := Load(&cfg, String(`
+telemetry:
+  endpoint: "http://collector:4318"
+  protocol: http/protobuf
+  headers:
+    api-key: secret
+  sampling_ratio: 10%
+`, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Telemetry.Ratio() != 0.1 || cfg.Telemetry.Headers["api-key"] != "secret" {
+		t.Errorf("unexpected cfg: %+v", cfg.Telemetry)
+	}
+
+	for _, content := range []string{
+		`{"telemetry": {"protocol": "udp"}}`,
+		`{"telemetry": {"endpoint": "collector:4317"}}`,
+	} {
+		err := Load(&Config{}, String(content, DecoderJSON))
+		if fieldErrs, ok := err.(fieldErrors); !ok || fieldErrs["telemetry"] == nil {
+			t.Errorf("%s: expected telemetry err, got %v", content, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package confucius
+
+import (
+	"io"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// decodeToml decodes a TOML 1.0 document. Integers are decoded as int64
+// and floats as float64. Offset date-times are decoded as time.Time, as are
+// local date-times and local dates, in the local time zone, while local
+// times are kept as strings such as "07:32:00".
+func decodeToml(reader io.Reader) (decodedObject, error) {
+	vals := make(decodedObject)
+	if err := toml.NewDecoder(reader).Decode(&vals); err != nil {
+		return nil, err
+	}
+	for key, val := range vals {
+		vals[key] = tomlValue(val)
+	}
+	return vals, nil
+}
+
+// tomlValue converts the local date and time values of go-toml in val,
+// which may be a table or an array, into plain values.
+func tomlValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case toml.LocalDateTime:
+		return v.AsTime(time.Local)
+	case toml.LocalDate:
+		return v.AsTime(time.Local)
+	case toml.LocalTime:
+		return v.String()
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = tomlValue(elem)
+		}
+		return v
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = tomlValue(elem)
+		}
+		return v
+	default:
+		return val
+	}
+}
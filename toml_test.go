@@ -0,0 +1,94 @@
+package confucius
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_confucius_Load_Types(t *testing.T) {
+	type Server struct {
+		Name   string `conf:"name"`
+		Weight int    `conf:"weight"`
+	}
+	type Config struct {
+		Count    int64         `conf:"count"`
+		Ratio    float32       `conf:"ratio"`
+		Whole    float64       `conf:"whole"`
+		Released time.Time     `conf:"released"`
+		Mixed    []interface{} `conf:"mixed"`
+		Server   struct {
+			Host string `conf:"host"`
+			Port int    `conf:"port"`
+		} `conf:"server"`
+		Servers []Server `conf:"servers"`
+	}
+
+	for _, f := range []string{"types.yaml", "types.json", "types.toml"} {
+		t.Run(f, func(t *testing.T) {
+			var cfg Config
+			if err := Load(&cfg, File(f), Dirs(filepath.Join("testdata", "valid"))); err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+
+			if cfg.Count != 1000 || cfg.Ratio != 0.5 || cfg.Whole != 2 {
+				t.Errorf("cfg == %+v", cfg)
+			}
+			if want := time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC); !cfg.Released.Equal(want) {
+				t.Errorf("cfg.Released == %v, expected %v", cfg.Released, want)
+			}
+			if got := fmt.Sprint(cfg.Mixed); got != "[1 two 3.5]" {
+				t.Errorf("cfg.Mixed == %v", got)
+			}
+			if cfg.Server.Host != "localhost" || cfg.Server.Port != 8080 {
+				t.Errorf("cfg.Server == %+v", cfg.Server)
+			}
+			if want := []Server{{Name: "a", Weight: 1}, {Name: "b", Weight: 2}}; !reflect.DeepEqual(want, cfg.Servers) {
+				t.Errorf("cfg.Servers == %+v", cfg.Servers)
+			}
+		})
+	}
+}
+
+func Test_decodeToml(t *testing.T) {
+	vals, err := decodeToml(strings.NewReader(`
+int = 0xff
+float = 1e3
+inf = inf
+date = 1979-05-27
+datetime = 1979-05-27T07:32:00
+time = 07:32:00
+table = { a.b = 1 }
+`))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := decodedObject{
+		"int":      int64(255),
+		"float":    float64(1000),
+		"date":     time.Date(1979, 5, 27, 0, 0, 0, 0, time.Local),
+		"datetime": time.Date(1979, 5, 27, 7, 32, 0, 0, time.Local),
+		"time":     "07:32:00",
+		"table":    map[string]interface{}{"a": map[string]interface{}{"b": int64(1)}},
+	}
+	inf := vals["inf"]
+	delete(vals, "inf")
+	if !reflect.DeepEqual(want, vals) {
+		t.Errorf("\nwant %#v\ngot  %#v", want, vals)
+	}
+	if f, ok := inf.(float64); !ok || !math.IsInf(f, 1) {
+		t.Errorf("inf == %#v", inf)
+	}
+
+	_, err = decodeToml(strings.NewReader("a = 1\nb = \n"))
+	var decodeErr *DecodeError
+	if !errors.As(newDecodeError("config.toml", nil, err), &decodeErr) || decodeErr.Line != 2 {
+		t.Errorf("expected a *DecodeError on line 2, got %v", err)
+	}
+}
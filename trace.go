@@ -0,0 +1,41 @@
+package confucius
+
+import (
+	"context"
+)
+
+// The names of the spans started around the steps of loading a config.
+const (
+	SpanLoad     = "confucius.load"     // the whole load.
+	SpanDiscover = "confucius.discover" // finding the config files.
+	SpanDecode   = "confucius.decode"   // decoding a config file or the reader; attribute "source".
+	SpanFetch    = "confucius.fetch"    // reading a provider; attribute "source".
+	SpanProcess  = "confucius.process"  // setting the environment, flags and defaults and validating.
+)
+
+// SpanStarter starts a span with the given name and attributes as a child
+// of the span in ctx. It returns the context of the new span and a function
+// that ends the span with the error of the step, if any. It is set with
+// `WithSpans` and adapts confucius to a tracing library, see the package
+// github.com/hasanozgan/confucius/otel for OpenTelemetry.
+type SpanStarter func(ctx context.Context, name string, attrs map[string]string) (context.Context, func(err error))
+
+// startSpan starts a span as a child of the current span, if spans are
+// enabled, and makes it the current span until the returned function ends
+// it.
+func (c *confucius) startSpan(name string, attrs map[string]string) func(err error) {
+	if c.spans == nil {
+		return func(error) {}
+	}
+
+	parent := c.spanCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, end := c.spans(parent, name, attrs)
+	c.spanCtx = ctx
+	return func(err error) {
+		c.spanCtx = parent
+		end(err)
+	}
+}
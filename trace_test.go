@@ -0,0 +1,75 @@
+package confucius
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type spanKey struct{}
+
+// spanRecorder records the spans as "parent > name source" when they end.
+type spanRecorder struct {
+	ended []string
+	errs  map[string]error
+}
+
+func (r *spanRecorder) start(ctx context.Context, name string, attrs map[string]string) (context.Context, func(error)) {
+	parent, _ := ctx.Value(spanKey{}).(string)
+	span := name
+	if source := attrs["source"]; source != "" {
+		span += " " + filepath.Base(source)
+	}
+	return context.WithValue(ctx, spanKey{}, span), func(err error) {
+		r.ended = append(r.ended, parent+" > "+span)
+		if err != nil {
+			r.errs[span] = err
+		}
+	}
+}
+
+func Test_WithSpans(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.yaml":      "name: app\n",
+		"config.prod.yaml": "port: 80\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	}
+
+	type Config struct {
+		Name string `conf:"name"`
+		Port int    `conf:"port" validate:"required"`
+	}
+
+	r := &spanRecorder{errs: make(map[string]error)}
+	var cfg Config
+	err := Load(&cfg, Dirs(dir), Profiles("prod"), WithSpans(r.start),
+		FromProviders(mapProvider{"port": 8080}, failingProvider{}), Lenient())
+	if err == nil {
+		t.Fatalf("expected the err of the failing provider")
+	}
+
+	want := []string{
+		"confucius.load > confucius.discover",
+		"confucius.load > confucius.decode config.yaml",
+		"confucius.load > confucius.decode config.prod.yaml",
+		"confucius.load > confucius.fetch provider confucius.mapProvider",
+		"confucius.load > confucius.fetch provider confucius.failingProvider",
+		"confucius.load > confucius.process",
+		" > confucius.load",
+	}
+	if !reflect.DeepEqual(r.ended, want) {
+		t.Errorf("spans == %q, expected %q", r.ended, want)
+	}
+	if err := r.errs["confucius.fetch provider confucius.failingProvider"]; err == nil {
+		t.Errorf("expected the span of the failing provider to end with its err")
+	}
+	if r.errs["confucius.load"] != err {
+		t.Errorf("expected the load span to end with the err of the load")
+	}
+}
@@ -0,0 +1,188 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// valueTransform rewrites the decoded value val of the struct field with
+// tag st and type t before it is decoded into the struct.
+type valueTransform func(st structTag, t reflect.Type, val interface{}) (interface{}, error)
+
+// chainTransforms returns a valueTransform that applies fns in order.
+func chainTransforms(fns ...valueTransform) valueTransform {
+	return func(st structTag, t reflect.Type, val interface{}) (interface{}, error) {
+		var err error
+		for _, fn := range fns {
+			if val, err = fn(st, t, val); err != nil {
+				return nil, err
+			}
+		}
+		return val, nil
+	}
+}
+
+// transformValues walks the decoded values alongside the struct type t and
+// applies fn to the value of every struct field that is present in vals.
+// Nested maps decoded by yaml are converted to map[string]interface{} on
+// the way so they can be walked and modified.
+func (c *confucius) transformValues(vals map[string]interface{}, t reflect.Type, fn valueTransform) error {
+	errs := make(fieldErrors)
+	c.transformStruct(vals, t, "", fn, errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *confucius) transformStruct(vals map[string]interface{}, t reflect.Type, path string, fn valueTransform, errs fieldErrors) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		if isSquashed(sf) {
+			c.transformStruct(vals, sf.Type, path, fn, errs)
+			continue
+		}
+
+		st := c.tags.parse(sf.Tag, c.tag)
+		name := sf.Name
+		if st.altName != "" {
+			name = st.altName
+		}
+		fieldPath := joinPath(path, name)
+
+		key, ok := matchKey(vals, name)
+		for _, alias := range st.aliases {
+			aliasKey, found := matchKey(vals, alias)
+			if !found {
+				continue
+			}
+			if ok {
+				c.logger.Warn("%s: deprecated key %q is ignored in favor of %q", fieldPath, aliasKey, key)
+				continue
+			}
+			c.logger.Warn("%s: key %q is deprecated, use %q instead", fieldPath, aliasKey, name)
+			vals[name] = vals[aliasKey]
+			delete(vals, aliasKey)
+			key, ok = name, true
+		}
+		if !ok {
+			continue
+		}
+
+		val, err := fn(st, sf.Type, vals[key])
+		if err != nil {
+			errs[fieldPath] = err
+			continue
+		}
+		vals[key] = c.transformNested(val, sf.Type, fieldPath, fn, errs)
+	}
+}
+
+// transformNested descends into val if it holds the values of a struct, of
+// a slice or of a map.
+func (c *confucius) transformNested(val interface{}, t reflect.Type, path string, fn valueTransform, errs fieldErrors) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := toStringMap(val); ok {
+			c.transformStruct(m, t, path, fn, errs)
+			return m
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := val.([]interface{}); ok {
+			for i := range s {
+				s[i] = c.transformNested(s[i], t.Elem(), fmt.Sprintf("%s[%d]", path, i), fn, errs)
+			}
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			break
+		}
+		if m, ok := toStringMap(val); ok {
+			for key := range m {
+				m[key] = c.transformNested(m[key], t.Elem(), fmt.Sprintf("%s[%s]", path, key), fn, errs)
+			}
+			return m
+		}
+	}
+	return val
+}
+
+// matchKey returns the key of vals that matches name. Keys are matched
+// case-insensitively if there is no exact match, as mapstructure does.
+func matchKey(vals map[string]interface{}, name string) (string, bool) {
+	if _, ok := vals[name]; ok {
+		return name, true
+	}
+	for key := range vals {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// toStringMap converts a decoded map into a map[string]interface{}.
+func toStringMap(val interface{}) (map[string]interface{}, bool) {
+	switch m := val.(type) {
+	case map[string]interface{}:
+		return m, true
+	case decodedObject:
+		return m, true
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(m))
+		for key, val := range m {
+			result[fmt.Sprint(key)] = val
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// normalizeValue returns val with the map[interface{}]interface{} values
+// that yaml.v2 decodes maps into, including the maps that merge keys (`<<`)
+// produce, converted into map[string]interface{}. Keys that are not
+// strings, e.g. `1` or `true`, are formatted with fmt. Nested maps of all
+// sources then have the same type, so that they are merged key by key and
+// their fields attributed to their sources. Maps and slices are copied.
+func normalizeValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = normalizeValue(val)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = normalizeValue(val)
+		}
+		return m
+	case decodedObject:
+		return decodedObject(normalizeValue(map[string]interface{}(v)).(map[string]interface{}))
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, elem := range v {
+			s[i] = normalizeValue(elem)
+		}
+		return s
+	default:
+		return val
+	}
+}
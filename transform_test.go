@@ -0,0 +1,100 @@
+package confucius
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_confucius_transformValues(t *testing.T) {
+	type Inner struct {
+		Name string `conf:"name"`
+	}
+	type Config struct {
+		Inner  Inner    `conf:"inner"`
+		Items  []*Inner `conf:"items"`
+		Title  string
+		hidden string
+	}
+
+	vals := map[string]interface{}{
+		"inner": map[interface{}]interface{}{"name": "a"},
+		"items": []interface{}{map[interface{}]interface{}{"name": "b"}, map[string]interface{}{"name": "bad"}},
+		"title": "c",
+	}
+
+	c := defaultConfucius()
+	err := c.transformValues(vals, reflect.TypeOf(&Config{}), func(st structTag, t reflect.Type, val interface{}) (interface{}, error) {
+		s, ok := val.(string)
+		if !ok {
+			return val, nil
+		}
+		if s == "bad" {
+			return nil, errors.New("bad value")
+		}
+		return strings.ToUpper(s), nil
+	})
+
+	fieldErrs, ok := err.(fieldErrors)
+	if !ok || len(fieldErrs) != 1 || fieldErrs["items[1].name"] == nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"inner": map[string]interface{}{"name": "A"},
+		"items": []interface{}{map[string]interface{}{"name": "B"}, map[string]interface{}{"name": "bad"}},
+		"title": "C",
+	}
+	if !reflect.DeepEqual(want, vals) {
+		t.Errorf("want %+v, got %+v", want, vals)
+	}
+}
+
+func Test_confucius_Load_Alias(t *testing.T) {
+	type Config struct {
+		Server struct {
+			Port int `conf:"port,alias=listen_port,alias=http_port"`
+		} `conf:"server"`
+		Name string `conf:"name,alias=title"`
+	}
+
+	var warnings []string
+	logger := Logger(SetLevel(WarningLevel), Callback(func(level LogLevel, message, file string, line int) {
+		warnings = append(warnings, message)
+	}))
+
+	t.Run("alias in file", func(t *testing.T) {
+		warnings = nil
+		var cfg Config
+		err := Load(&cfg, logger, String(`{"server": {"http_port": 8080}, "name": "api", "title": "old"}`, DecoderJSON))
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Server.Port != 8080 || cfg.Name != "api" {
+			t.Errorf("unexpected cfg: %+v", cfg)
+		}
+		if len(warnings) != 2 {
+			t.Errorf("expected 2 warnings, got %v", warnings)
+		}
+	})
+
+	t.Run("alias in env", func(t *testing.T) {
+		warnings = nil
+		setenv(t, "APP_SERVER_LISTEN_PORT", "9090")
+		defer os.Unsetenv("APP_SERVER_LISTEN_PORT")
+
+		var cfg Config
+		err := Load(&cfg, logger, UseEnv("app"), String(`{}`, DecoderJSON))
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Server.Port != 9090 {
+			t.Errorf("cfg.Server.Port == %d, expected %d", cfg.Server.Port, 9090)
+		}
+		if len(warnings) != 1 || !strings.Contains(warnings[0], "APP_SERVER_PORT") {
+			t.Errorf("unexpected warnings: %v", warnings)
+		}
+	})
+}
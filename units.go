@@ -0,0 +1,100 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// units are the units supported by the `unit` option of the conf tag.
+var units = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"us": time.Microsecond,
+	"µs": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+// durationType is the reflect.Type of time.Duration.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// convertUnit converts val, which is expressed in unit, into a value that
+// can be decoded into a field of type t.
+//
+// For time.Duration fields a bare number is multiplied by the unit while a
+// duration string (e.g. "1m") is left as-is. For other integer fields a
+// duration string is converted into a number of units and a bare number
+// is left as-is. Strings are returned as strings so they can be used for
+// env and default values.
+//
+//   (500, "ms", time.Duration)    --->   500ms
+//   ("2s", "ms", int64)           --->   "2000"
+func convertUnit(val interface{}, unit string, t reflect.Type) (interface{}, error) {
+	u, ok := units[unit]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	n, isNumber := toFloat(val)
+	s, isString := val.(string)
+
+	switch {
+	case t == durationType:
+		if !isNumber {
+			return val, nil
+		}
+		d := time.Duration(n * float64(u))
+		if isString {
+			return d.String(), nil
+		}
+		return d, nil
+	case isIntKind(t.Kind()) && isString && !isNumber:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.FormatInt(int64(d/u), 10), nil
+	}
+	return val, nil
+}
+
+// toFloat converts a number, or a string holding a number, into a float64.
+func toFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// unitTransform applies the unit of a field to its decoded value.
+func unitTransform(st structTag, t reflect.Type, val interface{}) (interface{}, error) {
+	if st.unit == "" {
+		return val, nil
+	}
+	return convertUnit(val, st.unit, t)
+}
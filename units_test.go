@@ -0,0 +1,91 @@
+package confucius
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_convertUnit(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		val     interface{}
+		unit    string
+		t       reflect.Type
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "int to duration", val: 500, unit: "ms", t: durationType, want: 500 * time.Millisecond},
+		{name: "float to duration", val: 1.5, unit: "s", t: durationType, want: 1500 * time.Millisecond},
+		{name: "numeric string to duration", val: "90", unit: "s", t: durationType, want: "1m30s"},
+		{name: "duration string to duration", val: "2h", unit: "s", t: durationType, want: "2h"},
+		{name: "duration string to int", val: "2s", unit: "ms", t: reflect.TypeOf(int64(0)), want: "2000"},
+		{name: "number to int", val: 250, unit: "ms", t: reflect.TypeOf(int64(0)), want: 250},
+		{name: "bad duration string to int", val: "soon", unit: "ms", t: reflect.TypeOf(0), wantErr: true},
+		{name: "unknown unit", val: 1, unit: "days", t: durationType, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := convertUnit(tc.val, tc.unit, tc.t)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected err")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected err: %v", err)
+			}
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func Test_confucius_Load_Unit(t *testing.T) {
+	type Config struct {
+		Timeout  time.Duration  `conf:"timeout,unit=ms"`
+		Interval *time.Duration `conf:"interval,unit=s"`
+		Backoffs []struct {
+			Delay time.Duration `conf:"delay,unit=ms"`
+		} `conf:"backoffs"`
+		TTL    int64         `conf:"ttl,unit=s" default:"1h"`
+		Grace  time.Duration `conf:"grace,unit=s" default:"30"`
+		Linger time.Duration `conf:"linger,unit=ms"`
+	}
+
+	setenv(t, "APP_LINGER", "250")
+	defer os.Unsetenv("APP_LINGER")
+
+	var cfg Config
+	err := Load(&cfg, UseEnv("app"), String(`
+timeout: 1500
+interval: 10
+backoffs:
+  - delay: 100
+  - delay: 1s
+`, DecoderYaml))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if cfg.Timeout != 1500*time.Millisecond {
+		t.Errorf("cfg.Timeout == %v, expected %v", cfg.Timeout, 1500*time.Millisecond)
+	}
+	if cfg.Interval == nil || *cfg.Interval != 10*time.Second {
+		t.Errorf("cfg.Interval == %v, expected %v", cfg.Interval, 10*time.Second)
+	}
+	if len(cfg.Backoffs) != 2 || cfg.Backoffs[0].Delay != 100*time.Millisecond || cfg.Backoffs[1].Delay != time.Second {
+		t.Errorf("cfg.Backoffs == %+v", cfg.Backoffs)
+	}
+	if cfg.TTL != 3600 {
+		t.Errorf("cfg.TTL == %d, expected %d", cfg.TTL, 3600)
+	}
+	if cfg.Grace != 30*time.Second {
+		t.Errorf("cfg.Grace == %v, expected %v", cfg.Grace, 30*time.Second)
+	}
+	if cfg.Linger != 250*time.Millisecond {
+		t.Errorf("cfg.Linger == %v, expected %v", cfg.Linger, 250*time.Millisecond)
+	}
+}
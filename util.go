@@ -46,6 +46,12 @@ func isZero(v reflect.Value) bool {
 		if t, ok := v.Interface().(time.Time); ok {
 			return t.IsZero()
 		}
+		if opt, ok := v.Interface().(optional); ok {
+			return !opt.isSet()
+		}
+		if reflect.PtrTo(v.Type()).Implements(parserType) || isTextUnmarshalerType(v.Type()) || isNetType(v.Type()) {
+			return v.IsZero()
+		}
 		return false
 	case reflect.Invalid:
 		return true
@@ -0,0 +1,92 @@
+package confucius
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Validator is implemented by config structs that validate themselves.
+// Constraints that span multiple fields, such as a certificate that is only
+// required when TLS is enabled, can be expressed in `Validate`:
+//
+//   type TLS struct {
+//     Enabled bool   `conf:"enabled"`
+//     Cert    string `conf:"cert"`
+//   }
+//
+//   func (t TLS) Validate() error {
+//     if t.Enabled && t.Cert == "" {
+//       return errors.New("cert is required when tls is enabled")
+//     }
+//     return nil
+//   }
+//
+// Validate is called on the root struct and on every nested struct,
+// including the elements of lists and the values of maps, after the
+// environment has been processed and the defaults have been set. The
+// returned error is reported under the path of the struct.
+type Validator interface {
+	Validate() error
+}
+
+// validateStructs calls Validate on every struct reachable from f that
+// implements Validator, collecting the errors in errs.
+func (c *confucius) validateStructs(f *field, errs fieldErrors) {
+	for (f.v.Kind() == reflect.Ptr || f.v.Kind() == reflect.Interface) && !f.v.IsNil() {
+		f.v = f.v.Elem()
+		f.t = f.v.Type()
+	}
+
+	switch f.v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < f.t.NumField(); i++ {
+			unexported := f.t.Field(i).PkgPath != ""
+			embedded := f.t.Field(i).Anonymous
+			if unexported && !embedded {
+				continue
+			}
+			c.validateStructs(newStructField(f, i, c.tag), errs)
+		}
+
+		if err := callValidator(f.v); err != nil {
+			errs.merge(f.path(), err)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < f.v.Len(); i++ {
+			c.validateStructs(newSliceField(f, i, c.tag), errs)
+		}
+
+	case reflect.Map:
+		elem := f.t.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct || isLeafStruct(elem) {
+			return
+		}
+		keys := f.v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		for _, key := range keys {
+			c.validateStructs(newMapField(f, key, c.tag), errs)
+		}
+	}
+}
+
+// callValidator calls Validate on v if v, or a pointer to v, implements
+// Validator.
+func callValidator(v reflect.Value) error {
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	if !v.CanInterface() {
+		return nil
+	}
+	if validator, ok := v.Interface().(Validator); ok {
+		return validator.Validate()
+	}
+	return nil
+}
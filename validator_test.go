@@ -0,0 +1,127 @@
+package confucius
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+type tlsConfig struct {
+	Enabled bool   `conf:"enabled"`
+	Cert    string `conf:"cert"`
+}
+
+func (t tlsConfig) Validate() error {
+	if t.Enabled && t.Cert == "" {
+		return errors.New("cert is required when tls is enabled")
+	}
+	return nil
+}
+
+type listenerConfig struct {
+	Name string    `conf:"name"`
+	TLS  tlsConfig `conf:"tls"`
+}
+
+type validatedConfig struct {
+	Listeners []listenerConfig      `conf:"listeners"`
+	Admin     *tlsConfig            `conf:"admin"`
+	Port      int                   `conf:"port"`
+	Upstreams map[string]tlsConfig  `conf:"upstreams"`
+	Backends  map[string]*tlsConfig `conf:"backends"`
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Port == 0 {
+		return fieldErrors{"port": errors.New("must be set")}
+	}
+	return nil
+}
+
+func Test_confucius_validateStructs(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		var cfg validatedConfig
+		err := Load(&cfg, String(`
+port: 80
+listeners:
+  - name: public
+    tls:
+      enabled: true
+      cert: /etc/cert.pem
+`, DecoderYaml))
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		var cfg validatedConfig
+		err := Load(&cfg, String(`
+listeners:
+  - name: public
+  - name: private
+    tls:
+      enabled: true
+admin:
+  enabled: true
+upstreams:
+  a:
+    enabled: true
+    cert: /etc/a.pem
+  b:
+    enabled: true
+backends:
+  c:
+    enabled: true
+`, DecoderYaml))
+		if err == nil {
+			t.Fatalf("expected err")
+		}
+
+		fieldErrs := err.(fieldErrors)
+		for _, path := range []string{"port", "listeners[1].tls", "admin", "upstreams[b]", "backends[c]"} {
+			if _, ok := fieldErrs[path]; !ok {
+				t.Errorf("expected error for %s, got %v", path, fieldErrs)
+			}
+		}
+		if len(fieldErrs) != 5 {
+			t.Errorf("expected 5 errors, got %v", fieldErrs)
+		}
+	})
+}
+
+func Test_Validate(t *testing.T) {
+	type Config struct {
+		Host  string `conf:"host" validate:"required"`
+		Port  int    `conf:"port" default:"8080"`
+		Admin *tlsConfig
+	}
+
+	setenv(t, "APP_HOST", "from-env")
+	defer os.Unsetenv("APP_HOST")
+
+	t.Run("valid", func(t *testing.T) {
+		cfg := Config{Host: "example.com"}
+		if err := Validate(&cfg, UseEnv("app")); err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if cfg.Host != "example.com" || cfg.Port != 8080 {
+			t.Errorf("unexpected cfg: %+v", cfg)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cfg := Config{Admin: &tlsConfig{Enabled: true}}
+		err := Validate(&cfg, UseEnv("app"))
+		fieldErrs, ok := err.(fieldErrors)
+		if !ok || len(fieldErrs) != 2 || fieldErrs["host"] == nil || fieldErrs["Admin"] == nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+	})
+
+	t.Run("non struct pointer", func(t *testing.T) {
+		if err := Validate(Config{}); err == nil {
+			t.Fatalf("expected err")
+		}
+	})
+}